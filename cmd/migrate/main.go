@@ -0,0 +1,97 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "os"
+    "strconv"
+
+    "github.com/alanowatson/LeadGenAPI/internal/db"
+    "github.com/alanowatson/LeadGenAPI/internal/migrations"
+    "github.com/joho/godotenv"
+)
+
+const usage = `usage: migrate <command>
+
+commands:
+  up            apply all pending migrations
+  down [n]      revert the last n migrations (default 1)
+  status        list migrations and when each was applied
+  to <version>  migrate up or down to exactly <version>`
+
+func main() {
+    if len(os.Args) < 2 {
+        fmt.Fprintln(os.Stderr, usage)
+        os.Exit(2)
+    }
+
+    if err := godotenv.Load(); err != nil {
+        log.Printf("No .env file loaded: %v", err)
+    }
+
+    if err := db.InitDB(); err != nil {
+        log.Fatalf("Error initializing database: %v", err)
+    }
+    defer db.DB.Close()
+
+    migrator, err := migrations.New(db.DB)
+    if err != nil {
+        log.Fatalf("Error loading migrations: %v", err)
+    }
+
+    ctx := context.Background()
+    switch os.Args[1] {
+    case "up":
+        err = migrator.Up(ctx)
+    case "down":
+        steps := 1
+        if len(os.Args) > 2 {
+            steps, err = strconv.Atoi(os.Args[2])
+            if err != nil || steps < 1 {
+                log.Fatalf("Invalid step count %q", os.Args[2])
+            }
+        }
+        err = migrator.Down(ctx, steps)
+    case "to":
+        if len(os.Args) < 3 {
+            log.Fatal("to requires a version")
+        }
+        version, convErr := strconv.Atoi(os.Args[2])
+        if convErr != nil {
+            log.Fatalf("Invalid version %q", os.Args[2])
+        }
+        err = migrator.To(ctx, version)
+    case "status":
+        err = printStatus(ctx, migrator)
+    default:
+        fmt.Fprintln(os.Stderr, usage)
+        os.Exit(2)
+    }
+    if err != nil {
+        log.Fatalf("Migration failed: %v", err)
+    }
+
+    if os.Args[1] != "status" {
+        current, err := migrator.Current(ctx)
+        if err != nil {
+            log.Fatalf("Error reading schema version: %v", err)
+        }
+        log.Printf("Schema is at version %d of %d", current, migrator.Latest())
+    }
+}
+
+func printStatus(ctx context.Context, migrator *migrations.Migrator) error {
+    statuses, err := migrator.Status(ctx)
+    if err != nil {
+        return err
+    }
+    for _, s := range statuses {
+        applied := "pending"
+        if s.AppliedAt != nil {
+            applied = s.AppliedAt.Format("2006-01-02 15:04:05 MST")
+        }
+        fmt.Printf("%04d  %-40s  %s\n", s.Version, s.Name, applied)
+    }
+    return nil
+}
@@ -1,12 +1,23 @@
 package main
 
 import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
     "log"
     "net/http"
+    "os"
 
+    "github.com/alanowatson/LeadGenAPI/internal/db"
     "github.com/alanowatson/LeadGenAPI/internal/handlers"
     "github.com/alanowatson/LeadGenAPI/internal/middleware"
-    "github.com/alanowatson/LeadGenAPI/internal/db"
+    "github.com/alanowatson/LeadGenAPI/internal/migrations"
+    "github.com/alanowatson/LeadGenAPI/internal/playlistsync"
+    "github.com/alanowatson/LeadGenAPI/internal/spotify"
+    "github.com/alanowatson/LeadGenAPI/internal/store"
+    "github.com/alanowatson/LeadGenAPI/internal/store/memory"
+    "github.com/alanowatson/LeadGenAPI/internal/store/postgres"
     "github.com/gorilla/mux"
     "github.com/joho/godotenv"
 )
@@ -17,10 +28,12 @@ func main() {
         log.Fatal("Error loading .env file")
     }
 
-
-    if err := db.InitDB(); err != nil {
-        log.Fatalf("Error initializing database: %v", err)
+    s, err := openStore()
+    if err != nil {
+        log.Fatalf("Error initializing store: %v", err)
     }
+    syncer := openSyncer(s)
+    h := handlers.New(s, syncer)
 
     r := mux.NewRouter()
 
@@ -28,36 +41,131 @@ func main() {
     r.HandleFunc("/login", handlers.Login).Methods("POST")
 
     // Protected routes - Playlisters
-    r.HandleFunc("/playlisters", middleware.RateLimitMiddleware(middleware.AuthMiddleware(handlers.GetPlaylisters))).Methods("GET")
-    r.HandleFunc("/playlisters", middleware.RateLimitMiddleware(middleware.AuthMiddleware(handlers.CreatePlaylister))).Methods("POST")
-    r.HandleFunc("/playlisters/{id}", middleware.RateLimitMiddleware(middleware.AuthMiddleware(handlers.GetPlaylister))).Methods("GET")
-    r.HandleFunc("/playlisters/{id}", middleware.RateLimitMiddleware(middleware.AuthMiddleware(handlers.UpdatePlaylister))).Methods("PUT")
-    r.HandleFunc("/playlisters/{id}", middleware.RateLimitMiddleware(middleware.AuthMiddleware(handlers.DeletePlaylister))).Methods("DELETE")
+    r.HandleFunc("/playlisters", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.GetPlaylisters))).Methods("GET")
+    r.HandleFunc("/playlisters", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.CreatePlaylister))).Methods("POST")
+    r.HandleFunc("/playlisters/duplicates", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.GetPlaylisterDuplicates))).Methods("GET")
+    r.HandleFunc("/playlisters/{id}", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.GetPlaylister))).Methods("GET")
+    r.HandleFunc("/playlisters/{id}", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.UpdatePlaylister))).Methods("PUT")
+    r.HandleFunc("/playlisters/{id}", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.PatchPlaylister))).Methods("PATCH")
+    r.HandleFunc("/playlisters/{id}", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.DeletePlaylister))).Methods("DELETE")
+    r.HandleFunc("/playlisters/{id}/playlists", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.GetPlaylisterPlaylists))).Methods("GET")
+    r.HandleFunc("/playlisters/{id}/placements", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.GetPlaylisterPlacements))).Methods("GET")
+    r.HandleFunc("/playlisters/{id}/history", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.GetPlaylisterHistory))).Methods("GET")
+    r.HandleFunc("/playlisters/{id}/restore", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.RestorePlaylister))).Methods("POST")
+    r.HandleFunc("/playlisters/{id}/merge", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.MergePlaylister))).Methods("POST")
+    r.HandleFunc("/playlisters/{id}/purge", middleware.RateLimitMiddleware(middleware.AuthMiddleware(middleware.AdminMiddleware(h.PurgePlaylister)))).Methods("POST")
 
     // Protected routes - Playlists
-    r.HandleFunc("/playlists", middleware.RateLimitMiddleware(middleware.AuthMiddleware(handlers.GetPlaylists))).Methods("GET")
-    r.HandleFunc("/playlists", middleware.RateLimitMiddleware(middleware.AuthMiddleware(handlers.CreatePlaylist))).Methods("POST")
-    r.HandleFunc("/playlists/{id}", middleware.RateLimitMiddleware(middleware.AuthMiddleware(handlers.GetPlaylist))).Methods("GET")
-    r.HandleFunc("/playlists/{id}", middleware.RateLimitMiddleware(middleware.AuthMiddleware(handlers.UpdatePlaylist))).Methods("PUT")
-    r.HandleFunc("/playlists/{id}", middleware.RateLimitMiddleware(middleware.AuthMiddleware(handlers.DeletePlaylist))).Methods("DELETE")
+    r.HandleFunc("/playlists", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.GetPlaylists))).Methods("GET")
+    r.HandleFunc("/playlists", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.CreatePlaylist))).Methods("POST")
+    r.HandleFunc("/playlists/{id}", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.GetPlaylist))).Methods("GET")
+    r.HandleFunc("/playlists/{id}", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.UpdatePlaylist))).Methods("PUT")
+    r.HandleFunc("/playlists/{id}", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.PatchPlaylist))).Methods("PATCH")
+    r.HandleFunc("/playlists/{id}", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.DeletePlaylist))).Methods("DELETE")
+    r.HandleFunc("/playlists/{id}/campaigns", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.GetPlaylistCampaignsForPlaylist))).Methods("GET")
+    r.HandleFunc("/playlists/{id}/placements", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.GetPlaylistPlacements))).Methods("GET")
+    r.HandleFunc("/playlists/{id}/history", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.GetPlaylistHistory))).Methods("GET")
+    r.HandleFunc("/playlists/{id}/followers", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.GetPlaylistFollowers))).Methods("GET")
+    r.HandleFunc("/playlists/{id}/sync", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.SyncPlaylist))).Methods("POST")
+    r.HandleFunc("/playlists/{id}/restore", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.RestorePlaylist))).Methods("POST")
+    r.HandleFunc("/playlists/{id}/purge", middleware.RateLimitMiddleware(middleware.AuthMiddleware(middleware.AdminMiddleware(h.PurgePlaylist)))).Methods("POST")
 
     // Protected routes - Campaigns
-    r.HandleFunc("/campaigns", middleware.RateLimitMiddleware(middleware.AuthMiddleware(handlers.GetCampaigns))).Methods("GET")
-    r.HandleFunc("/campaigns", middleware.RateLimitMiddleware(middleware.AuthMiddleware(handlers.CreateCampaign))).Methods("POST")
-    r.HandleFunc("/campaigns/{id}", middleware.RateLimitMiddleware(middleware.AuthMiddleware(handlers.GetCampaign))).Methods("GET")
-    r.HandleFunc("/campaigns/{id}", middleware.RateLimitMiddleware(middleware.AuthMiddleware(handlers.UpdateCampaign))).Methods("PUT")
-    r.HandleFunc("/campaigns/{id}", middleware.RateLimitMiddleware(middleware.AuthMiddleware(handlers.DeleteCampaign))).Methods("DELETE")
+    r.HandleFunc("/campaigns", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.GetCampaigns))).Methods("GET")
+    r.HandleFunc("/campaigns", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.CreateCampaign))).Methods("POST")
+    r.HandleFunc("/campaigns/{id}", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.GetCampaign))).Methods("GET")
+    r.HandleFunc("/campaigns/{id}", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.UpdateCampaign))).Methods("PUT")
+    r.HandleFunc("/campaigns/{id}", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.PatchCampaign))).Methods("PATCH")
+    r.HandleFunc("/campaigns/{id}", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.DeleteCampaign))).Methods("DELETE")
+    r.HandleFunc("/campaigns/{id}/playlists", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.GetCampaignPlaylists))).Methods("GET")
+    r.HandleFunc("/campaigns/{id}/placements", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.GetCampaignPlacements))).Methods("GET")
+    r.HandleFunc("/campaigns/{id}/history", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.GetCampaignHistory))).Methods("GET")
+    r.HandleFunc("/campaigns/{id}/verification", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.GetCampaignVerification))).Methods("GET")
+    r.HandleFunc("/campaigns/{id}/verify", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.VerifyCampaign))).Methods("POST")
+    r.HandleFunc("/campaigns/{id}/restore", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.RestoreCampaign))).Methods("POST")
+    r.HandleFunc("/campaigns/{id}/purge", middleware.RateLimitMiddleware(middleware.AuthMiddleware(middleware.AdminMiddleware(h.PurgeCampaign)))).Methods("POST")
 
     // Protected routes - PlaylistCampaigns
-    r.HandleFunc("/playlistcampaigns", middleware.RateLimitMiddleware(middleware.AuthMiddleware(handlers.GetPlaylistCampaigns))).Methods("GET")
-    r.HandleFunc("/playlistcampaigns", middleware.RateLimitMiddleware(middleware.AuthMiddleware(handlers.CreatePlaylistCampaign))).Methods("POST")
-    r.HandleFunc("/playlistcampaigns/{playlistId}/{campaignId}", middleware.RateLimitMiddleware(middleware.AuthMiddleware(handlers.GetPlaylistCampaign))).Methods("GET")
-    r.HandleFunc("/playlistcampaigns/{playlistId}/{campaignId}", middleware.RateLimitMiddleware(middleware.AuthMiddleware(handlers.UpdatePlaylistCampaign))).Methods("PUT")
-    r.HandleFunc("/playlistcampaigns/{playlistId}/{campaignId}", middleware.RateLimitMiddleware(middleware.AuthMiddleware(handlers.DeletePlaylistCampaign))).Methods("DELETE")
+    r.HandleFunc("/playlistcampaigns", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.GetPlaylistCampaigns))).Methods("GET")
+    r.HandleFunc("/playlistcampaigns", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.CreatePlaylistCampaign))).Methods("POST")
+    r.HandleFunc("/playlistcampaigns/{playlistId}/{campaignId}", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.GetPlaylistCampaign))).Methods("GET")
+    r.HandleFunc("/playlistcampaigns/{playlistId}/{campaignId}", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.UpdatePlaylistCampaign))).Methods("PUT")
+    r.HandleFunc("/playlistcampaigns/{playlistId}/{campaignId}", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.PatchPlaylistCampaign))).Methods("PATCH")
+    r.HandleFunc("/playlistcampaigns/{playlistId}/{campaignId}", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.DeletePlaylistCampaign))).Methods("DELETE")
+    r.HandleFunc("/playlistcampaigns/{playlistId}/{campaignId}/history", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.GetPlaylistCampaignHistory))).Methods("GET")
+    r.HandleFunc("/playlistcampaigns/{playlistId}/{campaignId}/transitions", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.GetPlaylistCampaignTransitions))).Methods("GET")
+    r.HandleFunc("/playlistcampaigns/{playlistId}/{campaignId}/transitions", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.TransitionPlaylistCampaign))).Methods("POST")
+    r.HandleFunc("/playlistcampaigns/{playlistId}/{campaignId}/messages", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.GetPlaylistCampaignMessages))).Methods("GET")
+    r.HandleFunc("/playlistcampaigns/{playlistId}/{campaignId}/messages", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.LogPlaylistCampaignMessage))).Methods("POST")
+    r.HandleFunc("/playlistcampaigns/{playlistId}/{campaignId}/verify", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.VerifyPlacement))).Methods("POST")
+    r.HandleFunc("/playlistcampaigns/{playlistId}/{campaignId}/restore", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.RestorePlaylistCampaign))).Methods("POST")
+    r.HandleFunc("/playlistcampaigns/{playlistId}/{campaignId}/purge", middleware.RateLimitMiddleware(middleware.AuthMiddleware(middleware.AdminMiddleware(h.PurgePlaylistCampaign)))).Methods("POST")
+
+    // Protected routes - Follow-ups
+    r.HandleFunc("/followups/due", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.GetDueFollowups))).Methods("GET")
+
+    // Protected routes - Import
+    r.HandleFunc("/import/{resource}", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.ImportResource))).Methods("POST")
+
+    // Protected routes - Search
+    r.HandleFunc("/search", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.Search))).Methods("GET")
+
+    // Protected routes - Spotify links
+    r.HandleFunc("/resolve", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.Resolve))).Methods("GET")
+
+    // Protected routes - Audit
+    r.HandleFunc("/audit", middleware.RateLimitMiddleware(middleware.AuthMiddleware(h.GetAudit))).Methods("GET")
 
     // Start the cleanup goroutine
     go middleware.CleanupVisitors()
 
+    if syncer != nil {
+        go syncer.Run(context.Background(), playlistsync.OptionsFromEnv())
+    }
+
     log.Println("Starting server on :8000")
     log.Fatal(http.ListenAndServe(":8000", r))
 }
+
+// openStore selects the repository backend from STORE_DRIVER. "memory" runs
+// without a database, which is handy for demos; anything else uses Postgres.
+func openStore() (*store.Store, error) {
+    if os.Getenv("STORE_DRIVER") == "memory" {
+        log.Println("Using in-memory store")
+        return memory.New(), nil
+    }
+
+    if err := db.InitDB(); err != nil {
+        return nil, err
+    }
+    if err := checkSchema(db.DB); err != nil {
+        return nil, err
+    }
+    return postgres.New(db.DB), nil
+}
+
+// openSyncer sets up the Spotify playlist sync, or returns nil when
+// SPOTIFY_CLIENT_ID is not set.
+func openSyncer(s *store.Store) *playlistsync.Syncer {
+    cfg, ok := spotify.ConfigFromEnv()
+    if !ok {
+        log.Println("SPOTIFY_CLIENT_ID not set, Spotify sync disabled")
+        return nil
+    }
+    return playlistsync.New(s, spotify.NewClient(cfg))
+}
+
+// checkSchema refuses to serve against a database with pending migrations.
+func checkSchema(conn *sql.DB) error {
+    migrator, err := migrations.New(conn)
+    if err != nil {
+        return err
+    }
+
+    err = migrator.Check(context.Background())
+    var behind *migrations.ErrSchemaBehind
+    if errors.As(err, &behind) {
+        return fmt.Errorf("%w; run `go run ./cmd/migrate up`", err)
+    }
+    return err
+}
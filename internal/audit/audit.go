@@ -0,0 +1,150 @@
+// Package audit describes the change history recorded for every write to the
+// store, and carries the acting user from the request to the repositories.
+package audit
+
+import (
+    "context"
+    "database/sql/driver"
+    "encoding/json"
+    "fmt"
+    "reflect"
+    "time"
+)
+
+// Action is the kind of write an entry records.
+type Action string
+
+const (
+    Create Action = "create"
+    Update Action = "update"
+    Delete Action = "delete"
+    // Restore takes a record out of the trash; Purge removes it for good.
+    Restore Action = "restore"
+    Purge   Action = "purge"
+    // Merge folds one playlister into another.
+    Merge Action = "merge"
+)
+
+// Change holds one field's value before and after a write. Old is null for
+// creates and New is null for deletes.
+type Change struct {
+    Old interface{} `json:"old"`
+    New interface{} `json:"new"`
+}
+
+// Changes maps JSON field names to their change. It is stored as jsonb.
+type Changes map[string]Change
+
+// Value implements driver.Valuer.
+func (c Changes) Value() (driver.Value, error) {
+    if c == nil {
+        return []byte("{}"), nil
+    }
+    return json.Marshal(c)
+}
+
+// Scan implements sql.Scanner.
+func (c *Changes) Scan(src interface{}) error {
+    switch v := src.(type) {
+    case []byte:
+        return json.Unmarshal(v, c)
+    case string:
+        return json.Unmarshal([]byte(v), c)
+    case nil:
+        *c = nil
+        return nil
+    }
+    return fmt.Errorf("cannot scan %T into audit.Changes", src)
+}
+
+// Entry is one recorded write.
+type Entry struct {
+    ID      int       `json:"id"`
+    Actor   string    `json:"actor"`
+    At      time.Time `json:"at"`
+    Entity  string    `json:"entity"`
+    Key     string    `json:"key"`
+    Action  Action    `json:"action"`
+    Changes Changes   `json:"changes"`
+}
+
+// NewEntry describes a write to the record of entity identified by key.
+// before is nil for creates and after is nil for deletes; otherwise only the
+// fields whose JSON representation differs are kept.
+func NewEntry(ctx context.Context, action Action, entity, key string, before, after interface{}) (Entry, error) {
+    changes, err := diff(before, after)
+    if err != nil {
+        return Entry{}, fmt.Errorf("error diffing %s %s: %w", entity, key, err)
+    }
+    return Entry{
+        Actor:   Actor(ctx),
+        At:      time.Now().UTC(),
+        Entity:  entity,
+        Key:     key,
+        Action:  action,
+        Changes: changes,
+    }, nil
+}
+
+// Note records a value that is not a field of the record, such as the other
+// side of a merge, as a change from null.
+func (e *Entry) Note(name string, value interface{}) {
+    if e.Changes == nil {
+        e.Changes = make(Changes)
+    }
+    e.Changes[name] = Change{New: value}
+}
+
+func diff(before, after interface{}) (Changes, error) {
+    old, err := fields(before)
+    if err != nil {
+        return nil, err
+    }
+    updated, err := fields(after)
+    if err != nil {
+        return nil, err
+    }
+
+    changes := make(Changes)
+    for name, v := range old {
+        if nv, ok := updated[name]; !ok || !reflect.DeepEqual(v, nv) {
+            changes[name] = Change{Old: v, New: updated[name]}
+        }
+    }
+    for name, v := range updated {
+        if _, ok := old[name]; !ok {
+            changes[name] = Change{New: v}
+        }
+    }
+    return changes, nil
+}
+
+// fields flattens a model into its JSON fields.
+func fields(v interface{}) (map[string]interface{}, error) {
+    if v == nil {
+        return nil, nil
+    }
+    raw, err := json.Marshal(v)
+    if err != nil {
+        return nil, err
+    }
+    var m map[string]interface{}
+    err = json.Unmarshal(raw, &m)
+    return m, err
+}
+
+type actorKey struct{}
+
+// WithActor returns a context whose writes are attributed to actor.
+func WithActor(ctx context.Context, actor string) context.Context {
+    return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Actor returns the user writes in ctx are attributed to, or "system" when
+// the write did not come from an authenticated request.
+func Actor(ctx context.Context) string {
+    if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+        return actor
+    }
+    return "system"
+}
@@ -0,0 +1,130 @@
+// Package contact normalizes the contact details stored for a playlister —
+// social handles, email addresses and WhatsApp numbers — and derives the
+// profile links they point at.
+//
+// The normalizers never fail: input they cannot make sense of is returned
+// trimmed but otherwise as typed, so that validation can reject it with the
+// field's usual message.
+package contact
+
+import (
+    "net/url"
+    "os"
+    "strings"
+)
+
+// fallbackRegion is used for numbers without a country code when
+// PHONE_DEFAULT_REGION is not set.
+const fallbackRegion = "US"
+
+// Hosts a pasted profile URL may use, mapped to their canonical form.
+var (
+    instagramHosts = []string{"instagram.com", "instagr.am"}
+    facebookHosts  = []string{"facebook.com", "fb.com", "fb.me"}
+)
+
+// DefaultRegion is the ISO 3166 region assumed for WhatsApp numbers typed
+// without a country code, taken from PHONE_DEFAULT_REGION ("US" by default).
+func DefaultRegion() string {
+    if region := strings.TrimSpace(os.Getenv("PHONE_DEFAULT_REGION")); region != "" {
+        return strings.ToUpper(region)
+    }
+    return fallbackRegion
+}
+
+// Email trims and lowercases an address.
+func Email(email string) string {
+    return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Instagram reduces an Instagram handle or profile URL to the lowercase
+// handle, e.g. "https://www.instagram.com/Ann.Lee/" and "@Ann.Lee" to
+// "ann.lee".
+func Instagram(handle string) string {
+    return strings.ToLower(profilePath(handle, instagramHosts))
+}
+
+// Facebook reduces a Facebook username or profile URL to the lowercase
+// username, or to the numeric ID of a profile.php?id= link.
+func Facebook(handle string) string {
+    handle = strings.TrimSpace(handle)
+    if u, ok := parseProfileURL(handle, facebookHosts); ok {
+        if strings.Trim(u.Path, "/") == "profile.php" && u.Query().Get("id") != "" {
+            return u.Query().Get("id")
+        }
+    }
+    return strings.ToLower(profilePath(handle, facebookHosts))
+}
+
+// InstagramURL returns the profile link of a normalized Instagram handle, or
+// "" when there is none.
+func InstagramURL(handle string) string {
+    if handle == "" {
+        return ""
+    }
+    return "https://www.instagram.com/" + url.PathEscape(handle) + "/"
+}
+
+// FacebookURL returns the profile link of a normalized Facebook username or
+// numeric ID, or "" when there is none.
+func FacebookURL(handle string) string {
+    if handle == "" {
+        return ""
+    }
+    if isDigits(handle) {
+        return "https://www.facebook.com/profile.php?id=" + handle
+    }
+    return "https://www.facebook.com/" + url.PathEscape(handle)
+}
+
+// WhatsappURL returns the click-to-chat link of an E.164 number, or "" when
+// the number is empty or not in E.164 form.
+func WhatsappURL(number string) string {
+    if len(number) < 2 || number[0] != '+' || !isDigits(number[1:]) {
+        return ""
+    }
+    return "https://wa.me/" + number[1:]
+}
+
+// profilePath strips a profile URL on one of hosts down to its first path
+// segment, and a bare handle of its leading "@".
+func profilePath(handle string, hosts []string) string {
+    handle = strings.TrimSpace(handle)
+    if u, ok := parseProfileURL(handle, hosts); ok {
+        segment, _, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+        return segment
+    }
+    return strings.TrimPrefix(handle, "@")
+}
+
+// parseProfileURL parses handle as a URL on one of hosts or their
+// subdomains, with or without a scheme.
+func parseProfileURL(handle string, hosts []string) (*url.URL, bool) {
+    raw := handle
+    if !strings.Contains(raw, "://") {
+        raw = "https://" + raw
+    }
+    u, err := url.Parse(raw)
+    if err != nil {
+        return nil, false
+    }
+    host := strings.ToLower(u.Hostname())
+    for _, h := range hosts {
+        if host == h || strings.HasSuffix(host, "."+h) {
+            return u, true
+        }
+    }
+    return nil, false
+}
+
+func isDigits(s string) bool {
+    if s == "" {
+        return false
+    }
+    for _, r := range s {
+        if r < '0' || r > '9' {
+            return false
+        }
+    }
+    return true
+}
@@ -0,0 +1,132 @@
+package contact
+
+import (
+    "strings"
+)
+
+// dialing describes how numbers are written within one region.
+type dialing struct {
+    // code is the country calling code.
+    code string
+    // trunk is the national prefix dropped when the country code is added.
+    trunk string
+    // intl is the region's international call prefix besides "00".
+    intl string
+}
+
+// regions maps ISO 3166 codes to their dialing rules. Regions missing here can
+// still be used by typing numbers with their country code.
+var regions = map[string]dialing{
+    "AE": {code: "971", trunk: "0"},
+    "AR": {code: "54", trunk: "0"},
+    "AT": {code: "43", trunk: "0"},
+    "AU": {code: "61", trunk: "0", intl: "0011"},
+    "BE": {code: "32", trunk: "0"},
+    "BR": {code: "55", trunk: "0"},
+    "CA": {code: "1", trunk: "1", intl: "011"},
+    "CH": {code: "41", trunk: "0"},
+    "CL": {code: "56"},
+    "CN": {code: "86", trunk: "0"},
+    "CO": {code: "57"},
+    "CZ": {code: "420"},
+    "DE": {code: "49", trunk: "0"},
+    "DK": {code: "45"},
+    "EG": {code: "20", trunk: "0"},
+    "ES": {code: "34"},
+    "FI": {code: "358", trunk: "0"},
+    "FR": {code: "33", trunk: "0"},
+    "GB": {code: "44", trunk: "0"},
+    "GR": {code: "30"},
+    "HK": {code: "852"},
+    "ID": {code: "62", trunk: "0"},
+    "IE": {code: "353", trunk: "0"},
+    "IL": {code: "972", trunk: "0"},
+    "IN": {code: "91", trunk: "0"},
+    "IT": {code: "39"},
+    "JP": {code: "81", trunk: "0", intl: "010"},
+    "KE": {code: "254", trunk: "0"},
+    "KR": {code: "82", trunk: "0"},
+    "MX": {code: "52"},
+    "MY": {code: "60", trunk: "0"},
+    "NG": {code: "234", trunk: "0"},
+    "NL": {code: "31", trunk: "0"},
+    "NO": {code: "47"},
+    "NZ": {code: "64", trunk: "0"},
+    "PE": {code: "51"},
+    "PH": {code: "63", trunk: "0"},
+    "PK": {code: "92", trunk: "0"},
+    "PL": {code: "48"},
+    "PR": {code: "1", trunk: "1", intl: "011"},
+    "PT": {code: "351"},
+    "RU": {code: "7", trunk: "8", intl: "810"},
+    "SA": {code: "966", trunk: "0"},
+    "SE": {code: "46", trunk: "0"},
+    "SG": {code: "65"},
+    "TH": {code: "66", trunk: "0"},
+    "TR": {code: "90", trunk: "0"},
+    "TW": {code: "886", trunk: "0"},
+    "UA": {code: "380", trunk: "0"},
+    "US": {code: "1", trunk: "1", intl: "011"},
+    "VN": {code: "84", trunk: "0"},
+    "ZA": {code: "27", trunk: "0"},
+}
+
+// nanpLength is the length of a North American number with its trunk prefix,
+// the only length at which a leading 1 is a prefix rather than the area code.
+const nanpLength = 11
+
+// Whatsapp converts a phone number to E.164, e.g. "(415) 555-0132" in region
+// "US" or "+1 415 555 0132" anywhere to "+14155550132". Numbers with a "+" or
+// an international prefix keep their country code; others are taken as
+// national numbers of region.
+func Whatsapp(number, region string) string {
+    number = strings.TrimSpace(number)
+    if number == "" {
+        return ""
+    }
+
+    var digits strings.Builder
+    for i, r := range number {
+        switch {
+        case r >= '0' && r <= '9':
+            digits.WriteRune(r)
+        case r == '+' && i == 0:
+        case strings.ContainsRune(" -.()/", r):
+        default:
+            return number
+        }
+    }
+    d := digits.String()
+
+    e164, ok := "", false
+    if strings.HasPrefix(number, "+") {
+        e164, ok = "+"+d, true
+    } else if rule, known := regions[strings.ToUpper(region)]; known {
+        e164, ok = national(d, rule), true
+    }
+    if !ok || !validE164(e164) {
+        return number
+    }
+    return e164
+}
+
+// national prefixes a number dialled within a region with its country code,
+// or just with "+" when it starts with an international prefix.
+func national(d string, rule dialing) string {
+    if rule.intl != "" && strings.HasPrefix(d, rule.intl) {
+        return "+" + d[len(rule.intl):]
+    }
+    if strings.HasPrefix(d, "00") {
+        return "+" + d[2:]
+    }
+    if rule.trunk != "" && strings.HasPrefix(d, rule.trunk) && (rule.code != "1" || len(d) == nanpLength) {
+        d = d[len(rule.trunk):]
+    }
+    return "+" + rule.code + d
+}
+
+// validE164 mirrors the e164 validation tag: a "+", a non-zero digit and at
+// most 15 digits in all.
+func validE164(s string) bool {
+    return len(s) >= 8 && len(s) <= 16 && s[0] == '+' && s[1] != '0' && isDigits(s[1:])
+}
@@ -3,12 +3,13 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
-	"github.com/alanowatson/LeadGenAPI/internal/models"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 var DB *sql.DB
@@ -40,36 +41,65 @@ func InitDB() error {
     return nil
 }
 
-func BeginTx() (*sql.Tx, error) {
-    return DB.Begin()
+// Postgres SQLSTATE codes that map to client errors rather than server faults.
+const (
+    foreignKeyViolation = "23503"
+    uniqueViolation     = "23505"
+)
+
+// IsUniqueViolation reports whether err is a Postgres unique-constraint violation.
+func IsUniqueViolation(err error) bool {
+    var pqErr *pq.Error
+    return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
 }
 
-func PlaylistExists(id int) (bool, error) {
-    var exists bool
-    err := DB.QueryRow("SELECT EXISTS(SELECT 1 FROM playlists WHERE id=$1)", id).Scan(&exists)
-    if err != nil {
-        return false, fmt.Errorf("error checking playlist existence: %w", err)
-    }
-    return exists, nil
+// IsForeignKeyViolation reports whether err is a Postgres foreign-key violation.
+func IsForeignKeyViolation(err error) bool {
+    var pqErr *pq.Error
+    return errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation
 }
 
-func CampaignExists(id int) (bool, error) {
-    var exists bool
-    err := DB.QueryRow("SELECT EXISTS(SELECT 1 FROM campaigns WHERE id=$1)", id).Scan(&exists)
-    if err != nil {
-        return false, fmt.Errorf("error checking campaign existence: %w", err)
-    }
-    return exists, nil
+// IsStillReferenced reports whether err is a foreign-key violation raised by
+// deleting or re-keying a row that other rows still point at, as opposed to
+// inserting a row whose reference does not exist.
+func IsStillReferenced(err error) bool {
+    var pqErr *pq.Error
+    return errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation &&
+        strings.Contains(pqErr.Detail, "is still referenced")
 }
 
-func CreatePlaylistCampaignTx(tx *sql.Tx, pc models.PlaylistCampaign) error {
-    _, err := tx.Exec(`
-        INSERT INTO playlist_campaigns (playlist_id, campaign_id, playlister_id, reference_artists, placement_status, number_of_messages, purchased)
-        VALUES ($1, $2, $3, $4, $5, $6, $7)
-    `, pc.PlaylistID, pc.CampaignID, pc.PlaylisterId, pc.ReferenceArtists, pc.PlacementStatus, pc.NumberOfMessages, pc.Purchased)
+// ConstraintTable extracts the other table named in a foreign-key violation's
+// detail, e.g. `Key (playlistid)=(9) is not present in table "playlists".`
+// yields "playlists".
+func ConstraintTable(err error) string {
+    var pqErr *pq.Error
+    if !errors.As(err, &pqErr) {
+        return ""
+    }
+    detail := strings.TrimSuffix(pqErr.Detail, ".")
+    end := strings.LastIndex(detail, `"`)
+    if end <= 0 {
+        return ""
+    }
+    start := strings.LastIndex(detail[:end], `"`)
+    if start == -1 {
+        return ""
+    }
+    return detail[start+1 : end]
+}
 
-    if err != nil {
-        return fmt.Errorf("error creating playlist campaign: %w", err)
+// ConstraintColumn extracts the offending column list from a constraint
+// violation's detail, e.g. "Key (email)=(a@b.com) already exists." yields "email".
+func ConstraintColumn(err error) string {
+    var pqErr *pq.Error
+    if !errors.As(err, &pqErr) {
+        return ""
     }
-    return nil
+    detail := pqErr.Detail
+    start := strings.Index(detail, "(")
+    end := strings.Index(detail, ")=")
+    if start == -1 || end <= start {
+        return ""
+    }
+    return detail[start+1 : end]
 }
@@ -0,0 +1,247 @@
+// Package dedup finds playlisters that are likely to be the same curator,
+// scoring candidate pairs on the identifiers and contact details they share
+// and on how alike their names are.
+package dedup
+
+import (
+    "math"
+    "sort"
+    "strings"
+    "unicode"
+
+    "github.com/alanowatson/LeadGenAPI/internal/contact"
+    "github.com/alanowatson/LeadGenAPI/internal/models"
+    "github.com/alanowatson/LeadGenAPI/internal/search"
+)
+
+// Signal weights: the probability, on its own, that two playlisters sharing
+// the signal are the same curator. Signals combine as independent evidence.
+const (
+    spotifyUserIDWeight = 0.95
+    emailWeight         = 0.9
+    instagramWeight     = 0.8
+    facebookWeight      = 0.7
+    nameWeight          = 0.6
+
+    // nameThreshold is the similarity below which names are not evidence.
+    nameThreshold = 0.85
+)
+
+// Reason is one signal behind a pair's score.
+type Reason struct {
+    Field string  `json:"field"`
+    Score float64 `json:"score"`
+}
+
+// Pair is two playlisters that may be the same curator. A has the lower ID.
+type Pair struct {
+    A       models.Playlister `json:"a"`
+    B       models.Playlister `json:"b"`
+    Score   float64           `json:"score"`
+    Reasons []Reason          `json:"reasons"`
+}
+
+// Score rates how likely a and b are to be the same curator, between 0 and 1,
+// and lists the signals it is based on.
+func Score(a, b models.Playlister) (float64, []Reason) {
+    var reasons []Reason
+    add := func(field string, weight float64) {
+        reasons = append(reasons, Reason{Field: field, Score: round(weight)})
+    }
+
+    if same(a.SpotifyUserID.String, b.SpotifyUserID.String, strings.ToLower) {
+        add("spotifyuserid", spotifyUserIDWeight)
+    }
+    if same(a.Email.String, b.Email.String, NormalizeEmail) {
+        add("email", emailWeight)
+    }
+    if same(a.Instagram.String, b.Instagram.String, contact.Instagram) {
+        add("instagram", instagramWeight)
+    }
+    if same(a.Facebook.String, b.Facebook.String, contact.Facebook) {
+        add("facebook", facebookWeight)
+    }
+    if sim := NameSimilarity(a.CuratorFullName.String, b.CuratorFullName.String); sim >= nameThreshold {
+        add("curatorfullname", nameWeight*sim)
+    }
+
+    miss := 1.0
+    for _, r := range reasons {
+        miss *= 1 - r.Score
+    }
+    return round(1 - miss), reasons
+}
+
+// Candidates scores every pair of playlisters that share a normalized
+// identifier, handle or name word, and returns those scoring at least
+// minScore, best first.
+func Candidates(playlisters []models.Playlister, minScore float64) []Pair {
+    blocks := make(map[string][]int)
+    for i, p := range playlisters {
+        for _, key := range blockingKeys(p) {
+            blocks[key] = append(blocks[key], i)
+        }
+    }
+
+    seen := make(map[[2]int]bool)
+    var pairs []Pair
+    for _, members := range blocks {
+        for x := 0; x < len(members); x++ {
+            for y := x + 1; y < len(members); y++ {
+                a, b := playlisters[members[x]], playlisters[members[y]]
+                if a.ID > b.ID {
+                    a, b = b, a
+                }
+                key := [2]int{a.ID, b.ID}
+                if seen[key] {
+                    continue
+                }
+                seen[key] = true
+                if score, reasons := Score(a, b); score >= minScore && len(reasons) > 0 {
+                    pairs = append(pairs, Pair{A: a, B: b, Score: score, Reasons: reasons})
+                }
+            }
+        }
+    }
+
+    sort.Slice(pairs, func(i, j int) bool {
+        if pairs[i].Score != pairs[j].Score {
+            return pairs[i].Score > pairs[j].Score
+        }
+        if pairs[i].A.ID != pairs[j].A.ID {
+            return pairs[i].A.ID < pairs[j].A.ID
+        }
+        return pairs[i].B.ID < pairs[j].B.ID
+    })
+    return pairs
+}
+
+// blockingKeys lists the keys a playlister is grouped under; only playlisters
+// sharing a key are compared.
+func blockingKeys(p models.Playlister) []string {
+    var keys []string
+    add := func(prefix, value string) {
+        if value != "" {
+            keys = append(keys, prefix+":"+value)
+        }
+    }
+    add("spotify", strings.ToLower(strings.TrimSpace(p.SpotifyUserID.String)))
+    add("email", NormalizeEmail(p.Email.String))
+    add("instagram", contact.Instagram(p.Instagram.String))
+    add("facebook", contact.Facebook(p.Facebook.String))
+    for _, word := range strings.Fields(NormalizeName(p.CuratorFullName.String)) {
+        if len(word) >= 3 {
+            add("name", word)
+        }
+    }
+    return keys
+}
+
+// same reports whether two non-empty values are equal once normalized.
+func same(a, b string, normalize func(string) string) bool {
+    a, b = normalize(strings.TrimSpace(a)), normalize(strings.TrimSpace(b))
+    return a != "" && a == b
+}
+
+// NormalizeEmail goes further than the stored form (see contact.Email) and
+// drops any +tag from the local part, and for Gmail the dots too, so aliases
+// of one mailbox compare equal.
+func NormalizeEmail(email string) string {
+    email = contact.Email(email)
+    at := strings.LastIndex(email, "@")
+    if at <= 0 {
+        return email
+    }
+    local, domain := email[:at], email[at+1:]
+    if plus := strings.Index(local, "+"); plus >= 0 {
+        local = local[:plus]
+    }
+    if domain == "googlemail.com" {
+        domain = "gmail.com"
+    }
+    if domain == "gmail.com" {
+        local = strings.ReplaceAll(local, ".", "")
+    }
+    return local + "@" + domain
+}
+
+// NormalizeName folds case and accents and collapses punctuation and spacing.
+func NormalizeName(name string) string {
+    return strings.Join(strings.FieldsFunc(search.Fold(name), func(r rune) bool {
+        return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+    }), " ")
+}
+
+// NameSimilarity compares two names with the Jaro-Winkler similarity of their
+// normalized forms, also trying the words in sorted order so that "Lee, Ann"
+// matches "Ann Lee".
+func NameSimilarity(a, b string) float64 {
+    a, b = NormalizeName(a), NormalizeName(b)
+    if a == "" || b == "" {
+        return 0
+    }
+    return math.Max(jaroWinkler(a, b), jaroWinkler(sortedWords(a), sortedWords(b)))
+}
+
+func sortedWords(s string) string {
+    words := strings.Fields(s)
+    sort.Strings(words)
+    return strings.Join(words, " ")
+}
+
+// jaroWinkler returns the Jaro-Winkler similarity of a and b, from 0 for
+// nothing in common to 1 for equal strings.
+func jaroWinkler(a, b string) float64 {
+    s, t := []rune(a), []rune(b)
+    if len(s) == 0 || len(t) == 0 {
+        return 0
+    }
+
+    window := max(len(s), len(t))/2 - 1
+    if window < 0 {
+        window = 0
+    }
+    sMatched := make([]bool, len(s))
+    tMatched := make([]bool, len(t))
+    matches := 0
+    for i := range s {
+        lo, hi := max(0, i-window), min(len(t), i+window+1)
+        for j := lo; j < hi; j++ {
+            if !tMatched[j] && s[i] == t[j] {
+                sMatched[i], tMatched[j] = true, true
+                matches++
+                break
+            }
+        }
+    }
+    if matches == 0 {
+        return 0
+    }
+
+    transpositions, j := 0, 0
+    for i := range s {
+        if !sMatched[i] {
+            continue
+        }
+        for !tMatched[j] {
+            j++
+        }
+        if s[i] != t[j] {
+            transpositions++
+        }
+        j++
+    }
+
+    m := float64(matches)
+    jaro := (m/float64(len(s)) + m/float64(len(t)) + (m-float64(transpositions)/2)/m) / 3
+
+    prefix := 0
+    for prefix < min(4, len(s), len(t)) && s[prefix] == t[prefix] {
+        prefix++
+    }
+    return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+func round(f float64) float64 {
+    return math.Round(f*1000) / 1000
+}
@@ -0,0 +1,110 @@
+package dedup
+
+import (
+    "database/sql"
+    "reflect"
+    "testing"
+
+    "github.com/alanowatson/LeadGenAPI/internal/models"
+)
+
+func valid(s string) sql.NullString {
+    return sql.NullString{String: s, Valid: true}
+}
+
+func TestScore(t *testing.T) {
+    tests := []struct {
+        name    string
+        a, b    models.Playlister
+        score   float64
+        reasons []Reason
+    }{
+        {
+            name: "nothing shared",
+            a:    models.Playlister{CuratorFullName: valid("Ann Lee"), Email: valid("ann@example.com")},
+            b:    models.Playlister{CuratorFullName: valid("Bob Smith"), Email: valid("bob@example.com")},
+        },
+        {
+            name: "empty values are not evidence",
+            a:    models.Playlister{Email: valid(" "), Instagram: sql.NullString{}},
+            b:    models.Playlister{Email: valid(""), Instagram: sql.NullString{}},
+        },
+        {
+            name:    "spotify user id ignores case",
+            a:       models.Playlister{SpotifyUserID: valid("AnnLee")},
+            b:       models.Playlister{SpotifyUserID: valid("annlee ")},
+            score:   0.95,
+            reasons: []Reason{{"spotifyuserid", 0.95}},
+        },
+        {
+            name:    "gmail aliases",
+            a:       models.Playlister{Email: valid("Ann.Lee+promo@gmail.com")},
+            b:       models.Playlister{Email: valid("annlee@googlemail.com")},
+            score:   0.9,
+            reasons: []Reason{{"email", 0.9}},
+        },
+        {
+            name:    "instagram handle and link",
+            a:       models.Playlister{Instagram: valid("@Ann.Lee")},
+            b:       models.Playlister{Instagram: valid("https://www.instagram.com/ann.lee/")},
+            score:   0.8,
+            reasons: []Reason{{"instagram", 0.8}},
+        },
+        {
+            name:    "facebook profile id",
+            a:       models.Playlister{Facebook: valid("https://www.facebook.com/profile.php?id=1234")},
+            b:       models.Playlister{Facebook: valid("facebook.com/profile.php?id=1234")},
+            score:   0.7,
+            reasons: []Reason{{"facebook", 0.7}},
+        },
+        {
+            name:    "reordered name",
+            a:       models.Playlister{CuratorFullName: valid("Lee, Ann")},
+            b:       models.Playlister{CuratorFullName: valid("Ann Lee")},
+            score:   0.6,
+            reasons: []Reason{{"curatorfullname", 0.6}},
+        },
+        {
+            name:    "accented name",
+            a:       models.Playlister{CuratorFullName: valid("José Núñez")},
+            b:       models.Playlister{CuratorFullName: valid("jose nunez")},
+            score:   0.6,
+            reasons: []Reason{{"curatorfullname", 0.6}},
+        },
+        {
+            name:    "similar name",
+            a:       models.Playlister{CuratorFullName: valid("Ann Lee")},
+            b:       models.Playlister{CuratorFullName: valid("Anna Leigh")},
+            score:   0.524,
+            reasons: []Reason{{"curatorfullname", 0.524}},
+        },
+        {
+            name:    "signals combine",
+            a:       models.Playlister{CuratorFullName: valid("Jon Smith"), Email: valid("jon@example.com")},
+            b:       models.Playlister{CuratorFullName: valid("John Smith"), Email: valid("JON@example.com")},
+            score:   0.958,
+            reasons: []Reason{{"email", 0.9}, {"curatorfullname", 0.584}},
+        },
+        {
+            name: "every signal",
+            a: models.Playlister{SpotifyUserID: valid("annlee"), Email: valid("ann@example.com"), Instagram: valid("annlee"),
+                Facebook: valid("annlee"), CuratorFullName: valid("Ann Lee")},
+            b: models.Playlister{SpotifyUserID: valid("annlee"), Email: valid("ann@example.com"), Instagram: valid("annlee"),
+                Facebook: valid("annlee"), CuratorFullName: valid("Ann Lee")},
+            score: 1,
+            reasons: []Reason{{"spotifyuserid", 0.95}, {"email", 0.9}, {"instagram", 0.8},
+                {"facebook", 0.7}, {"curatorfullname", 0.6}},
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            score, reasons := Score(tt.a, tt.b)
+            if score != tt.score || !reflect.DeepEqual(reasons, tt.reasons) {
+                t.Errorf("Score() = %v, %v, want %v, %v", score, reasons, tt.score, tt.reasons)
+            }
+            if swapped, _ := Score(tt.b, tt.a); swapped != score {
+                t.Errorf("Score is not symmetric: %v one way, %v the other", score, swapped)
+            }
+        })
+    }
+}
@@ -1,10 +1,14 @@
 package errors
 
 import (
+    stderrors "errors"
     "fmt"
     "log"
     "net/http"
+    "strings"
 
+    "github.com/alanowatson/LeadGenAPI/internal/models"
+    "github.com/alanowatson/LeadGenAPI/internal/store"
     "github.com/alanowatson/LeadGenAPI/pkg/util"
 )
 
@@ -12,3 +16,63 @@ func HandleError(w http.ResponseWriter, err error, status int, message string) {
     log.Printf("Error: %v", err)
     util.RespondWithError(w, status, fmt.Sprintf("%s: %v", message, err))
 }
+
+// HandleStoreError translates constraint violations reported by a repository
+// into client errors and reports everything else as an internal server error.
+func HandleStoreError(w http.ResponseWriter, err error, message string) {
+    log.Printf("Store error: %v", err)
+
+    if status, clientMessage, ok := ClientError(err); ok {
+        util.RespondWithError(w, status, clientMessage)
+        return
+    }
+    util.RespondWithError(w, http.StatusInternalServerError, message)
+}
+
+// ClientError describes a repository error caused by the request: the status
+// and message to answer it with. ok is false for any other error.
+func ClientError(err error) (status int, message string, ok bool) {
+    var constraintErr *store.ConstraintError
+    if stderrors.As(err, &constraintErr) {
+        switch constraintErr.Kind {
+        case store.Unique:
+            if constraintErr.Column != "" {
+                return http.StatusConflict, fmt.Sprintf("A record with this %s already exists", constraintErr.Column), true
+            }
+            return http.StatusConflict, "A record with these values already exists", true
+        case store.StillReferenced:
+            return http.StatusConflict, fmt.Sprintf("Record is still referenced by %s", constraintErr.Table), true
+        case store.MissingReference:
+            return http.StatusUnprocessableEntity, fmt.Sprintf("Referenced %s does not exist", constraintErr.Column), true
+        }
+    }
+
+    var transitionErr *store.TransitionError
+    if stderrors.As(err, &transitionErr) {
+        next := models.NextPlacementStatuses(transitionErr.From)
+        if transitionErr.From == "" {
+            return http.StatusUnprocessableEntity, fmt.Sprintf("Placement cannot start as %s; allowed: %s",
+                transitionErr.To, strings.Join(next, ", ")), true
+        }
+        if len(next) == 0 {
+            return http.StatusConflict, fmt.Sprintf("Placement cannot move from %s to %s", transitionErr.From, transitionErr.To), true
+        }
+        return http.StatusConflict, fmt.Sprintf("Placement cannot move from %s to %s; allowed: %s",
+            transitionErr.From, transitionErr.To, strings.Join(next, ", ")), true
+    }
+
+    var capErr *store.MessageCapError
+    if stderrors.As(err, &capErr) {
+        return http.StatusConflict, fmt.Sprintf("Placement has reached its campaign's cap of %d outbound messages", capErr.Cap), true
+    }
+
+    switch {
+    case stderrors.Is(err, store.ErrVersionConflict):
+        return http.StatusPreconditionFailed, "Precondition failed: the record has been modified", true
+    case stderrors.Is(err, store.ErrNotDeleted):
+        return http.StatusConflict, "Record is not in the trash", true
+    case stderrors.Is(err, store.ErrOwnerMismatch):
+        return http.StatusUnprocessableEntity, "Playlister does not own the referenced Playlist", true
+    }
+    return 0, "", false
+}
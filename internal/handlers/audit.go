@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/alanowatson/LeadGenAPI/internal/audit"
+	"github.com/alanowatson/LeadGenAPI/internal/store"
+	"github.com/alanowatson/LeadGenAPI/pkg/util"
+	"github.com/gorilla/mux"
+)
+
+// GetAudit lists the audit log, filterable by actor, entity, key, action and
+// at (the day of the write).
+func (h *Handler) GetAudit(w http.ResponseWriter, r *http.Request) {
+    h.listAudit(w, r, "", "")
+}
+
+// GetPlaylisterHistory lists the audit entries of one playlister.
+func (h *Handler) GetPlaylisterHistory(w http.ResponseWriter, r *http.Request) {
+    h.recordHistory(w, r, store.EntityPlaylister)
+}
+
+// GetPlaylistHistory lists the audit entries of one playlist.
+func (h *Handler) GetPlaylistHistory(w http.ResponseWriter, r *http.Request) {
+    h.recordHistory(w, r, store.EntityPlaylist)
+}
+
+// GetCampaignHistory lists the audit entries of one campaign.
+func (h *Handler) GetCampaignHistory(w http.ResponseWriter, r *http.Request) {
+    h.recordHistory(w, r, store.EntityCampaign)
+}
+
+// GetPlaylistCampaignHistory lists the audit entries of one placement.
+func (h *Handler) GetPlaylistCampaignHistory(w http.ResponseWriter, r *http.Request) {
+    playlistID, campaignID, ok := placementKey(w, r)
+    if !ok {
+        return
+    }
+    h.listAudit(w, r, store.EntityPlaylistCampaign, store.PlacementKey(playlistID, campaignID))
+}
+
+// recordHistory serves the history of the record named by the {id} route
+// variable. Deleted records keep their history, so a missing record is not
+// an error.
+func (h *Handler) recordHistory(w http.ResponseWriter, r *http.Request, entity string) {
+    id, err := strconv.Atoi(mux.Vars(r)["id"])
+    if err != nil {
+        util.RespondWithError(w, http.StatusBadRequest, "Invalid ID")
+        return
+    }
+    h.listAudit(w, r, entity, store.Key(id))
+}
+
+func (h *Handler) listAudit(w http.ResponseWriter, r *http.Request, entity, key string) {
+    if r.URL.Query().Has("q") {
+        util.RespondWithError(w, http.StatusBadRequest, "Search is not supported for the audit log")
+        return
+    }
+
+    opts, ok := listOptions(w, r, store.AuditSchema)
+    if !ok {
+        return
+    }
+    if entity != "" {
+        opts.Query.Filters = append(opts.Query.Filters,
+            store.AuditSchema.Equals("entity", entity),
+            store.AuditSchema.Equals("key", key))
+    }
+
+    if exported(w, r, opts, view[audit.Entry]{}, auditColumns, h.store.Audit.Each) {
+        return
+    }
+
+    page, err := h.store.Audit.List(r.Context(), opts)
+    if err != nil {
+        log.Printf("Error listing audit entries: %v", err)
+        util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving audit log")
+        return
+    }
+    respondWithPage(w, r, page, opts.Page)
+}
@@ -5,108 +5,46 @@ import (
 	"log"
 	"net/http"
 	"strconv"
-	"sync"
-    "database/sql"
 
-	"github.com/alanowatson/LeadGenAPI/internal/db"
 	"github.com/alanowatson/LeadGenAPI/internal/errors"
 	"github.com/alanowatson/LeadGenAPI/internal/models"
-	"github.com/alanowatson/LeadGenAPI/internal/pagination"
+	"github.com/alanowatson/LeadGenAPI/internal/store"
 	"github.com/alanowatson/LeadGenAPI/internal/validation"
-
 	"github.com/alanowatson/LeadGenAPI/pkg/util"
 	"github.com/gorilla/mux"
 )
 
-var (
-    campaigns     = make(map[int]models.Campaign)
-    campaignID    = 1
-    campaignMutex sync.RWMutex
-)
-
-func GetCampaigns(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetCampaigns(w http.ResponseWriter, r *http.Request) {
     log.Println("GetCampaigns function called")
 
-    paginationParams := pagination.GetPaginationParams(r)
-    log.Printf("Pagination params: page=%d, per_page=%d", paginationParams.Page, paginationParams.PerPage)
-
-    var totalItems int
-    err := db.DB.QueryRow("SELECT COUNT(*) FROM campaigns").Scan(&totalItems)
-    if err != nil {
-        log.Printf("Error getting total count: %v", err)
-        util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving campaigns")
+    opts, ok := listOptions(w, r, store.CampaignSchema)
+    if !ok {
         return
     }
-    log.Printf("Total campaigns in database: %d", totalItems)
-
-    totalPages := (totalItems + paginationParams.PerPage - 1) / paginationParams.PerPage
-    log.Printf("Total pages: %d", totalPages)
-
-    if paginationParams.Page > totalPages && totalPages > 0 {
-        log.Printf("Requested page %d exceeds total pages %d", paginationParams.Page, totalPages)
-        util.RespondWithError(w, http.StatusNotFound, "Page not found")
+    v, ok := parseView[models.Campaign](w, r, store.CampaignSchema, nil)
+    if !ok {
         return
     }
+    opts.Fields = v.load()
+    log.Printf("Pagination params: page=%d, per_page=%d", opts.Page.Page, opts.Page.PerPage)
 
-    offset := (paginationParams.Page - 1) * paginationParams.PerPage
-
-    query := `
-        SELECT campaignid, campaignname, referenceartists, trello_link, spotify_link, launchdate, promoted_artist
-        FROM campaigns
-        ORDER BY campaignid
-        LIMIT $1 OFFSET $2
-    `
-    log.Printf("Executing query: %s", query)
-    rows, err := db.DB.Query(query, paginationParams.PerPage, offset)
-    if err != nil {
-        log.Printf("Error querying campaigns: %v", err)
-        util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving campaigns")
+    if exported(w, r, opts, v, campaignColumns, h.store.Campaigns.Each) {
         return
     }
-    defer rows.Close()
-
-    var campaigns []models.Campaign
-    for rows.Next() {
-        var c models.Campaign
-        err := rows.Scan(
-            &c.ID,
-            &c.CampaignName,
-            &c.ReferenceArtists,
-            &c.TrelloLink,
-            &c.SpotifyLink,
-            &c.LaunchDate,
-            &c.PromotedArtist,
-        )
-        if err != nil {
-            log.Printf("Error scanning campaign row: %v", err)
-            util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving campaigns")
-            return
-        }
-        campaigns = append(campaigns, c)
-    }
 
-    if err = rows.Err(); err != nil {
-        log.Printf("Error after scanning all rows: %v", err)
+    page, err := h.store.Campaigns.List(r.Context(), opts)
+    if err != nil {
+        log.Printf("Error listing campaigns: %v", err)
         util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving campaigns")
         return
     }
 
-    log.Printf("Number of campaigns retrieved: %d", len(campaigns))
-
-    response := map[string]interface{}{
-        "data":        campaigns,
-        "page":        paginationParams.Page,
-        "per_page":    paginationParams.PerPage,
-        "total_items": totalItems,
-        "total_pages": totalPages,
-    }
-
-    log.Printf("Response prepared: %+v", response)
-    util.RespondWithJSON(w, http.StatusOK, response)
+    log.Printf("Number of campaigns retrieved: %d", len(page.Items))
+    respondWithViewPage(w, r, page, opts.Page, v)
     log.Println("GetCampaigns function completed")
 }
 
-func GetCampaign(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetCampaign(w http.ResponseWriter, r *http.Request) {
     log.Println("GetCampaign function called")
 
     vars := mux.Vars(r)
@@ -116,29 +54,19 @@ func GetCampaign(w http.ResponseWriter, r *http.Request) {
         util.RespondWithError(w, http.StatusBadRequest, "Invalid campaign ID")
         return
     }
-
     log.Printf("Looking up campaign with ID: %d", id)
 
-    query := `
-        SELECT campaignid, campaignname, referenceartists, trello_link, spotify_link,
-               launchdate, promoted_artist
-        FROM campaigns
-        WHERE campaignid = $1
-    `
-
-    var c models.Campaign
-    err = db.DB.QueryRow(query, id).Scan(
-        &c.ID,
-        &c.CampaignName,
-        &c.ReferenceArtists,
-        &c.TrelloLink,
-        &c.SpotifyLink,
-        &c.LaunchDate,
-        &c.PromotedArtist,
-    )
+    v, ok := parseView[models.Campaign](w, r, store.CampaignSchema, nil)
+    if !ok {
+        return
+    }
 
+    p, err := h.store.Campaigns.Get(r.Context(), id, v.load()...)
+    if err == nil && hidden(r, p.DeletedAt) {
+        err = store.ErrNotFound
+    }
     if err != nil {
-        if err == sql.ErrNoRows {
+        if err == store.ErrNotFound {
             log.Printf("Campaign not found with ID: %d", id)
             util.RespondWithError(w, http.StatusNotFound, "Campaign not found")
             return
@@ -149,11 +77,14 @@ func GetCampaign(w http.ResponseWriter, r *http.Request) {
     }
 
     log.Printf("Successfully retrieved campaign with ID: %d", id)
-    util.RespondWithJSON(w, http.StatusOK, c)
+    if notModified(w, r, p.Version) {
+        return
+    }
+    respondWithView(w, r, http.StatusOK, p, v)
     log.Println("GetCampaign function completed")
 }
 
-func CreateCampaign(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) CreateCampaign(w http.ResponseWriter, r *http.Request) {
     var campaign models.Campaign
     decoder := json.NewDecoder(r.Body)
     if err := decoder.Decode(&campaign); err != nil {
@@ -162,27 +93,33 @@ func CreateCampaign(w http.ResponseWriter, r *http.Request) {
     }
     defer r.Body.Close()
 
+    campaign.Normalize()
     if err := validation.ValidateStruct(campaign); err != nil {
         errors.HandleError(w, err, http.StatusBadRequest, "Validation error")
         return
     }
 
-    campaignMutex.Lock()
-    campaign.ID = campaignID
-    campaigns[campaignID] = campaign
-    campaignID++
-    campaignMutex.Unlock()
+    if err := h.store.Campaigns.Create(r.Context(), &campaign); err != nil {
+        errors.HandleStoreError(w, err, "Error creating campaign")
+        return
+    }
 
+    log.Printf("Created campaign with ID: %d", campaign.ID)
+    setETag(w, campaign.Version)
     util.RespondWithJSON(w, http.StatusCreated, campaign)
 }
 
-func UpdateCampaign(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) UpdateCampaign(w http.ResponseWriter, r *http.Request) {
     vars := mux.Vars(r)
     id, err := strconv.Atoi(vars["id"])
     if err != nil {
         errors.HandleError(w, err, http.StatusBadRequest, "Invalid campaign ID")
         return
     }
+    version, ok := ifMatch(w, r)
+    if !ok {
+        return
+    }
 
     var campaign models.Campaign
     decoder := json.NewDecoder(r.Body)
@@ -192,42 +129,108 @@ func UpdateCampaign(w http.ResponseWriter, r *http.Request) {
     }
     defer r.Body.Close()
 
+    campaign.Normalize()
     if err := validation.ValidateStruct(campaign); err != nil {
         errors.HandleError(w, err, http.StatusBadRequest, "Validation error")
         return
     }
 
-    campaignMutex.Lock()
-    defer campaignMutex.Unlock()
+    campaign.ID = id
+    campaign.Version = version
+    if err := h.store.Campaigns.Update(r.Context(), &campaign); err != nil {
+        if err == store.ErrNotFound {
+            util.RespondWithError(w, http.StatusNotFound, "Campaign not found")
+            return
+        }
+        errors.HandleStoreError(w, err, "Error updating campaign")
+        return
+    }
+
+    setETag(w, campaign.Version)
+    util.RespondWithJSON(w, http.StatusOK, campaign)
+}
+
+// PatchCampaign applies a JSON Merge Patch to a campaign. Keys missing from the
+// patch keep their current value and null clears a nullable column; only the
+// merged result is validated.
+func (h *Handler) PatchCampaign(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    id, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        errors.HandleError(w, err, http.StatusBadRequest, "Invalid campaign ID")
+        return
+    }
+    version, ok := ifMatch(w, r)
+    if !ok {
+        return
+    }
+
+    current, err := h.store.Campaigns.Get(r.Context(), id)
+    if err == nil && current.DeletedAt.Valid {
+        err = store.ErrNotFound
+    }
+    if err != nil {
+        if err == store.ErrNotFound {
+            util.RespondWithError(w, http.StatusNotFound, "Campaign not found")
+            return
+        }
+        errors.HandleStoreError(w, err, "Error retrieving campaign")
+        return
+    }
+    if !checkVersion(w, version, current.Version) {
+        return
+    }
+
+    campaign, ok := applyPatch(w, r, current)
+    if !ok {
+        return
+    }
+    // The update is conditional on the version the patch was applied to.
+    campaign.Version = current.Version
+    if campaign.ID != id {
+        util.RespondWithError(w, http.StatusBadRequest, "Campaign ID in URL must match payload")
+        return
+    }
 
-    if _, found := campaigns[id]; !found {
-        util.RespondWithError(w, http.StatusNotFound, "Campaign not found")
+    campaign.Normalize()
+    if err := validation.ValidateStruct(campaign); err != nil {
+        errors.HandleError(w, err, http.StatusBadRequest, "Validation error")
         return
     }
 
-    campaign.ID = id
-    campaigns[id] = campaign
+    if err := h.store.Campaigns.Update(r.Context(), &campaign); err != nil {
+        if err == store.ErrNotFound {
+            util.RespondWithError(w, http.StatusNotFound, "Campaign not found")
+            return
+        }
+        errors.HandleStoreError(w, err, "Error updating campaign")
+        return
+    }
 
+    setETag(w, campaign.Version)
     util.RespondWithJSON(w, http.StatusOK, campaign)
 }
 
-func DeleteCampaign(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) DeleteCampaign(w http.ResponseWriter, r *http.Request) {
     vars := mux.Vars(r)
     id, err := strconv.Atoi(vars["id"])
     if err != nil {
         util.RespondWithError(w, http.StatusBadRequest, "Invalid campaign ID")
         return
     }
-
-    campaignMutex.Lock()
-    defer campaignMutex.Unlock()
-
-    if _, found := campaigns[id]; !found {
-        util.RespondWithError(w, http.StatusNotFound, "Campaign not found")
+    version, ok := ifMatch(w, r)
+    if !ok {
         return
     }
 
-    delete(campaigns, id)
+    if err := h.store.Campaigns.Delete(r.Context(), id, version); err != nil {
+        if err == store.ErrNotFound {
+            util.RespondWithError(w, http.StatusNotFound, "Campaign not found")
+            return
+        }
+        errors.HandleStoreError(w, err, "Error deleting campaign")
+        return
+    }
 
     util.RespondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
 }
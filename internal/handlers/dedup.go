@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/alanowatson/LeadGenAPI/internal/dedup"
+	"github.com/alanowatson/LeadGenAPI/internal/errors"
+	"github.com/alanowatson/LeadGenAPI/internal/models"
+	"github.com/alanowatson/LeadGenAPI/internal/pagination"
+	"github.com/alanowatson/LeadGenAPI/internal/store"
+	"github.com/alanowatson/LeadGenAPI/pkg/util"
+	"github.com/gorilla/mux"
+)
+
+// defaultMinDuplicateScore is the lowest score a pair needs to be listed when
+// the request does not set min_score.
+const defaultMinDuplicateScore = 0.5
+
+// GetPlaylisterDuplicates lists pairs of live playlisters that are likely to
+// be the same curator, best match first. min_score sets the lowest score
+// listed and playlisterid restricts the list to pairs involving one
+// playlister.
+func (h *Handler) GetPlaylisterDuplicates(w http.ResponseWriter, r *http.Request) {
+    params := pagination.GetPaginationParams(r)
+    if params.Cursor != "" {
+        util.RespondWithError(w, http.StatusBadRequest, "cursor is not supported here; use page")
+        return
+    }
+
+    minScore := defaultMinDuplicateScore
+    if raw := r.URL.Query().Get("min_score"); raw != "" {
+        var err error
+        if minScore, err = strconv.ParseFloat(raw, 64); err != nil || minScore < 0 || minScore > 1 {
+            util.RespondWithError(w, http.StatusBadRequest, "min_score must be a number between 0 and 1")
+            return
+        }
+    }
+    playlisterID := 0
+    if raw := r.URL.Query().Get("playlisterid"); raw != "" {
+        var err error
+        if playlisterID, err = strconv.Atoi(raw); err != nil {
+            util.RespondWithError(w, http.StatusBadRequest, "Invalid playlister ID")
+            return
+        }
+    }
+
+    var playlisters []models.Playlister
+    err := h.store.Playlisters.Each(r.Context(), store.ListOptions{}, func(p models.Playlister) error {
+        playlisters = append(playlisters, p)
+        return nil
+    })
+    if err != nil {
+        log.Printf("Error listing playlisters: %v", err)
+        util.RespondWithError(w, http.StatusInternalServerError, "Error finding duplicates")
+        return
+    }
+
+    pairs := []dedup.Pair{}
+    for _, pair := range dedup.Candidates(playlisters, minScore) {
+        if playlisterID == 0 || pair.A.ID == playlisterID || pair.B.ID == playlisterID {
+            pairs = append(pairs, pair)
+        }
+    }
+    log.Printf("Found %d likely duplicate playlister pairs among %d playlisters", len(pairs), len(playlisters))
+
+    respondWithPage(w, r, store.Page[dedup.Pair]{
+        Items:      pagination.PaginateSlice(pairs, params),
+        TotalItems: len(pairs),
+    }, params)
+}
+
+// MergePlaylister folds the playlister named by duplicate_id into the one in
+// the URL, moving its playlists and placements over and trashing it. An
+// If-Match header is checked against the surviving playlister.
+func (h *Handler) MergePlaylister(w http.ResponseWriter, r *http.Request) {
+    id, err := strconv.Atoi(mux.Vars(r)["id"])
+    if err != nil {
+        util.RespondWithError(w, http.StatusBadRequest, "Invalid playlister ID")
+        return
+    }
+    version, ok := ifMatch(w, r)
+    if !ok {
+        return
+    }
+
+    var body struct {
+        DuplicateID int `json:"duplicate_id"`
+    }
+    if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+        errors.HandleError(w, err, http.StatusBadRequest, "Invalid request payload")
+        return
+    }
+    defer r.Body.Close()
+    if body.DuplicateID == 0 {
+        util.RespondWithError(w, http.StatusBadRequest, "duplicate_id is required")
+        return
+    }
+    if body.DuplicateID == id {
+        util.RespondWithError(w, http.StatusBadRequest, "Cannot merge a playlister into itself")
+        return
+    }
+
+    if err := h.store.Playlisters.Merge(r.Context(), id, body.DuplicateID, version); err != nil {
+        if err == store.ErrNotFound {
+            util.RespondWithError(w, http.StatusNotFound, "Playlister not found")
+            return
+        }
+        errors.HandleStoreError(w, err, "Error merging playlisters")
+        return
+    }
+
+    p, err := h.store.Playlisters.Get(r.Context(), id)
+    if err != nil {
+        errors.HandleStoreError(w, err, "Error retrieving playlister")
+        return
+    }
+    log.Printf("Merged playlister %d into %d", body.DuplicateID, id)
+    setETag(w, p.Version)
+    util.RespondWithJSON(w, http.StatusOK, p)
+}
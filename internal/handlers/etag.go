@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alanowatson/LeadGenAPI/pkg/util"
+)
+
+// Records are tagged with their version, so an ETag changes exactly when the
+// stored row does.
+
+func etag(version int) string {
+    return `"` + strconv.Itoa(version) + `"`
+}
+
+func setETag(w http.ResponseWriter, version int) {
+    w.Header().Set("ETag", etag(version))
+}
+
+// entityTags splits an If-Match or If-None-Match header into its tags.
+func entityTags(header string) []string {
+    var tags []string
+    for _, tag := range strings.Split(header, ",") {
+        if tag = strings.TrimSpace(tag); tag != "" {
+            tags = append(tags, tag)
+        }
+    }
+    return tags
+}
+
+// notModified sets the ETag for version and, when If-None-Match already names
+// it, writes 304 Not Modified and returns true. Tags are compared weakly, as
+// RFC 9110 prescribes for If-None-Match.
+func notModified(w http.ResponseWriter, r *http.Request, version int) bool {
+    setETag(w, version)
+    for _, tag := range entityTags(r.Header.Get("If-None-Match")) {
+        if tag == "*" || strings.TrimPrefix(tag, "W/") == etag(version) {
+            w.WriteHeader(http.StatusNotModified)
+            return true
+        }
+    }
+    return false
+}
+
+// ifMatch returns the version a write is conditional on, or 0 when the
+// request has no If-Match header or uses "*". It responds with 400 or 412 and
+// returns false when the header cannot match any version.
+func ifMatch(w http.ResponseWriter, r *http.Request) (int, bool) {
+    tags := entityTags(r.Header.Get("If-Match"))
+    switch {
+    case len(tags) == 0:
+        return 0, true
+    case len(tags) > 1:
+        util.RespondWithError(w, http.StatusBadRequest, "If-Match must name a single entity tag")
+        return 0, false
+    case tags[0] == "*":
+        return 0, true
+    }
+
+    // Weak tags never match under the strong comparison If-Match requires.
+    version, err := strconv.Atoi(strings.Trim(tags[0], `"`))
+    if err != nil || version <= 0 || !strings.HasPrefix(tags[0], `"`) {
+        util.RespondWithError(w, http.StatusPreconditionFailed, "Precondition failed: the record has been modified")
+        return 0, false
+    }
+    return version, true
+}
+
+// checkVersion responds with 412 and returns false when the request named a
+// version other than current.
+func checkVersion(w http.ResponseWriter, expected, current int) bool {
+    if expected != 0 && expected != current {
+        util.RespondWithError(w, http.StatusPreconditionFailed, "Precondition failed: the record has been modified")
+        return false
+    }
+    return true
+}
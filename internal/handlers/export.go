@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/alanowatson/LeadGenAPI/internal/query"
+	"github.com/alanowatson/LeadGenAPI/internal/store"
+	"github.com/alanowatson/LeadGenAPI/pkg/util"
+)
+
+// Export formats a list endpoint can stream instead of a JSON page.
+const (
+    formatCSV    = "csv"
+    formatNDJSON = "ndjson"
+)
+
+var exportMediaTypes = map[string]string{
+    formatCSV:    "text/csv",
+    formatNDJSON: "application/x-ndjson",
+}
+
+// The columns of full CSV exports: the resource's schema fields followed by
+// the fields its JSON adds that lists cannot filter on.
+var (
+    playlisterColumns = exportColumns(store.PlaylisterSchema, "instagram_url", "facebook_url", "whatsapp_url")
+    playlistColumns   = exportColumns(store.PlaylistSchema)
+    campaignColumns   = exportColumns(store.CampaignSchema, "followup_sequence")
+    placementColumns  = exportColumns(store.PlaylistCampaignSchema)
+    auditColumns      = exportColumns(store.AuditSchema, "changes")
+)
+
+func exportColumns(schema query.Schema, extra ...string) []string {
+    return append(schema.Names(), extra...)
+}
+
+// exportFlushEvery is how many rows are buffered between flushes to the client.
+const exportFlushEvery = 100
+
+// exportFormat picks the response format from the format parameter or, when
+// that is absent, the Accept header. It returns "" for the default JSON page,
+// and responds with 400 and returns false for an unknown format.
+func exportFormat(w http.ResponseWriter, r *http.Request) (string, bool) {
+    if format := r.URL.Query().Get("format"); format != "" {
+        if format == "json" {
+            return "", true
+        }
+        if _, ok := exportMediaTypes[format]; ok {
+            return format, true
+        }
+        util.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Unsupported format %q (supported: csv, json, ndjson)", format))
+        return "", false
+    }
+
+    for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+        mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+        if err != nil {
+            continue
+        }
+        for format, t := range exportMediaTypes {
+            if mediaType == t {
+                return format, true
+            }
+        }
+    }
+    return "", true
+}
+
+// exported streams every item matching opts in the requested export format,
+// ignoring pagination. CSV exports have the view's fields as columns, or all
+// of columns when it names none. It returns false, having written nothing,
+// when the request asked for a JSON page instead.
+func exported[T any](w http.ResponseWriter, r *http.Request, opts store.ListOptions, v view[T], columns []string,
+    each func(ctx context.Context, opts store.ListOptions, fn func(T) error) error) bool {
+    format, ok := exportFormat(w, r)
+    if !ok {
+        return true
+    }
+    if format == "" {
+        return false
+    }
+    if len(v.rels) > 0 {
+        util.RespondWithError(w, http.StatusBadRequest, "include is not supported by "+format+" exports")
+        return true
+    }
+
+    if v.fields != nil {
+        columns = v.fields
+    }
+
+    var out rowWriter
+    buf := bufio.NewWriter(w)
+    if format == formatCSV {
+        out = &csvRows{w: csv.NewWriter(buf), columns: columns}
+    } else {
+        out = &ndjsonRows{w: buf, fields: v.fields}
+    }
+
+    // Headers go out with the first row, so an error before it can still be
+    // answered with a status.
+    started := false
+    start := func() error {
+        started = true
+        w.Header().Set("Content-Type", exportMediaTypes[format]+"; charset=utf-8")
+        w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, path.Base(r.URL.Path), format))
+        w.WriteHeader(http.StatusOK)
+        return out.header()
+    }
+
+    rows := 0
+    err := each(r.Context(), opts, func(item T) error {
+        if !started {
+            if err := start(); err != nil {
+                return err
+            }
+        }
+        if err := out.row(item); err != nil {
+            return err
+        }
+        if rows++; rows%exportFlushEvery == 0 {
+            return flush(w, buf, out)
+        }
+        return nil
+    })
+    if err == nil && !started {
+        err = start()
+    }
+    if err != nil {
+        log.Printf("Error exporting %s: %v", r.URL.Path, err)
+        if !started {
+            util.RespondWithError(w, http.StatusInternalServerError, "Error exporting records")
+        }
+        return true
+    }
+    if err := flush(w, buf, out); err != nil {
+        log.Printf("Error exporting %s: %v", r.URL.Path, err)
+    }
+    log.Printf("Exported %d rows of %s as %s", rows, r.URL.Path, format)
+    return true
+}
+
+// flush pushes the buffered rows to the client.
+func flush(w http.ResponseWriter, buf *bufio.Writer, out rowWriter) error {
+    if err := out.flush(); err != nil {
+        return err
+    }
+    if err := buf.Flush(); err != nil {
+        return err
+    }
+    if f, ok := w.(http.Flusher); ok {
+        f.Flush()
+    }
+    return nil
+}
+
+// rowWriter encodes exported items in one format.
+type rowWriter interface {
+    header() error
+    row(item interface{}) error
+    flush() error
+}
+
+// csvRows writes one column per field, named after its JSON key.
+type csvRows struct {
+    w       *csv.Writer
+    columns []string
+}
+
+func (c *csvRows) header() error {
+    return c.w.Write(c.columns)
+}
+
+func (c *csvRows) row(item interface{}) error {
+    fields, err := jsonFields(item)
+    if err != nil {
+        return err
+    }
+    record := make([]string, len(c.columns))
+    for i, name := range c.columns {
+        record[i] = csvCell(fields[name])
+    }
+    return c.w.Write(record)
+}
+
+func (c *csvRows) flush() error {
+    c.w.Flush()
+    return c.w.Error()
+}
+
+// ndjsonRows writes one JSON object per line, holding the selected fields.
+type ndjsonRows struct {
+    w      *bufio.Writer
+    fields []string
+}
+
+func (n *ndjsonRows) header() error {
+    return nil
+}
+
+func (n *ndjsonRows) row(item interface{}) error {
+    var line interface{} = item
+    if n.fields != nil {
+        fields, err := jsonFields(item)
+        if err != nil {
+            return err
+        }
+        doc := make(document, len(n.fields))
+        for _, name := range n.fields {
+            doc[name] = fields[name]
+        }
+        line = doc
+    }
+    data, err := json.Marshal(line)
+    if err != nil {
+        return err
+    }
+    n.w.Write(data)
+    return n.w.WriteByte('\n')
+}
+
+func (n *ndjsonRows) flush() error {
+    return nil
+}
+
+// jsonFields marshals item and splits the resulting object into its fields.
+func jsonFields(item interface{}) (map[string]json.RawMessage, error) {
+    raw, err := json.Marshal(item)
+    if err != nil {
+        return nil, err
+    }
+    var fields map[string]json.RawMessage
+    err = json.Unmarshal(raw, &fields)
+    return fields, err
+}
+
+// csvCell renders a JSON value as a CSV cell: strings unquoted, null empty
+// and objects or arrays as compact JSON.
+func csvCell(raw json.RawMessage) string {
+    if len(raw) == 0 || string(raw) == "null" {
+        return ""
+    }
+    var s string
+    if raw[0] == '"' && json.Unmarshal(raw, &s) == nil {
+        return s
+    }
+    var compact bytes.Buffer
+    if json.Compact(&compact, raw) == nil {
+        return compact.String()
+    }
+    return string(raw)
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alanowatson/LeadGenAPI/internal/store"
+	"github.com/alanowatson/LeadGenAPI/pkg/util"
+	"github.com/gorilla/mux"
+)
+
+// GetPlaylistFollowers returns the follower count series of a playlist, oldest
+// first. since and until, both YYYY-MM-DD and inclusive, limit it to a range
+// of UTC days.
+func (h *Handler) GetPlaylistFollowers(w http.ResponseWriter, r *http.Request) {
+    id, err := strconv.Atoi(mux.Vars(r)["id"])
+    if err != nil {
+        util.RespondWithError(w, http.StatusBadRequest, "Invalid playlist ID")
+        return
+    }
+    since, ok := dayParam(w, r, "since")
+    if !ok {
+        return
+    }
+    until, ok := dayParam(w, r, "until")
+    if !ok {
+        return
+    }
+    if !until.IsZero() {
+        until = until.AddDate(0, 0, 1)
+    }
+
+    p, err := h.store.Playlists.Get(r.Context(), id, "playlistid")
+    if err == nil && hidden(r, p.DeletedAt) {
+        err = store.ErrNotFound
+    }
+    if err != nil {
+        if err == store.ErrNotFound {
+            util.RespondWithError(w, http.StatusNotFound, "Playlist not found")
+            return
+        }
+        log.Printf("Error querying playlist: %v", err)
+        util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving playlist")
+        return
+    }
+
+    snapshots, err := h.store.Playlists.Followers(r.Context(), id, since, until)
+    if err != nil {
+        log.Printf("Error listing follower snapshots of playlist %d: %v", id, err)
+        util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving follower history")
+        return
+    }
+    util.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+        "playlistid": id,
+        "data":       snapshots,
+    })
+}
+
+// dayParam parses an optional YYYY-MM-DD parameter as the start of that UTC
+// day, responding with 400 and returning false when it is malformed.
+func dayParam(w http.ResponseWriter, r *http.Request, name string) (time.Time, bool) {
+    raw := r.URL.Query().Get(name)
+    if raw == "" {
+        return time.Time{}, true
+    }
+    day, err := time.Parse("2006-01-02", raw)
+    if err != nil {
+        util.RespondWithError(w, http.StatusBadRequest, name+" must be a date in YYYY-MM-DD format")
+        return time.Time{}, false
+    }
+    return day, true
+}
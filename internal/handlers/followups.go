@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alanowatson/LeadGenAPI/internal/models"
+	"github.com/alanowatson/LeadGenAPI/internal/store"
+	"github.com/alanowatson/LeadGenAPI/pkg/util"
+)
+
+const (
+    defaultFollowupLimit = 100
+    maxFollowupLimit     = 500
+)
+
+// GetDueFollowups lists the follow-ups due now, or by until (an RFC 3339
+// time), the longest overdue first. assignee and channel narrow the queue
+// and limit caps it.
+func (h *Handler) GetDueFollowups(w http.ResponseWriter, r *http.Request) {
+    params := r.URL.Query()
+    q := store.FollowupQuery{
+        Assignee: strings.TrimSpace(params.Get("assignee")),
+        Channel:  params.Get("channel"),
+        Until:    time.Now(),
+        Limit:    defaultFollowupLimit,
+    }
+    if q.Channel != "" && !models.ValidChannel(q.Channel) {
+        util.RespondWithError(w, http.StatusBadRequest, "channel must be one of "+strings.Join(models.MessageChannels, ", "))
+        return
+    }
+    if raw := params.Get("until"); raw != "" {
+        until, err := time.Parse(time.RFC3339, raw)
+        if err != nil {
+            util.RespondWithError(w, http.StatusBadRequest, "until must be an RFC 3339 time")
+            return
+        }
+        q.Until = until
+    }
+    if raw := params.Get("limit"); raw != "" {
+        limit, err := strconv.Atoi(raw)
+        if err != nil || limit < 1 || limit > maxFollowupLimit {
+            util.RespondWithError(w, http.StatusBadRequest, "limit must be between 1 and 500")
+            return
+        }
+        q.Limit = limit
+    }
+
+    due, err := h.store.PlaylistCampaigns.DueFollowups(r.Context(), q)
+    if err != nil {
+        log.Printf("Error listing due follow-ups: %v", err)
+        util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving due follow-ups")
+        return
+    }
+    util.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+        "until": q.Until.UTC().Format(time.RFC3339),
+        "data":  due,
+    })
+}
@@ -1,11 +1,126 @@
 package handlers
 
 import (
+    "fmt"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "github.com/alanowatson/LeadGenAPI/internal/pagination"
+    "github.com/alanowatson/LeadGenAPI/internal/playlistsync"
+    "github.com/alanowatson/LeadGenAPI/internal/query"
+    "github.com/alanowatson/LeadGenAPI/internal/search"
+    "github.com/alanowatson/LeadGenAPI/internal/store"
+    "github.com/alanowatson/LeadGenAPI/pkg/util"
     "github.com/gorilla/mux"
 )
 
+// Handler serves the resource endpoints on top of the injected repositories.
+// syncer is nil when the Spotify integration is not configured.
+type Handler struct {
+    store  *store.Store
+    syncer *playlistsync.Syncer
+}
+
+func New(s *store.Store, syncer *playlistsync.Syncer) *Handler {
+    return &Handler{store: s, syncer: syncer}
+}
+
 func SetupRoutes(r *mux.Router) {
     // Define your routes here, for example:
     // r.HandleFunc("/api/playlists", GetPlaylists).Methods("GET")
     // Add more routes as needed
 }
+
+// listOptions parses the pagination, filter and sort parameters of a list
+// request, responding with 400 and returning false when they are invalid.
+func listOptions(w http.ResponseWriter, r *http.Request, schema query.Schema) (store.ListOptions, bool) {
+    spec, err := query.Parse(r.URL.Query(), schema)
+    if err != nil {
+        util.RespondWithError(w, http.StatusBadRequest, err.Error())
+        return store.ListOptions{}, false
+    }
+
+    opts := store.ListOptions{
+        Query:  spec,
+        Page:   pagination.GetPaginationParams(r),
+        Search: search.Terms(r.URL.Query().Get("q")),
+    }
+
+    if raw := r.URL.Query().Get("include_deleted"); raw != "" {
+        opts.IncludeDeleted, err = strconv.ParseBool(raw)
+        if err != nil {
+            util.RespondWithError(w, http.StatusBadRequest, "include_deleted must be true or false")
+            return store.ListOptions{}, false
+        }
+    }
+
+    if opts.Page.Cursor != "" {
+        opts.After, err = spec.DecodeCursor(opts.Page.Cursor)
+        if err != nil {
+            util.RespondWithError(w, http.StatusBadRequest, err.Error())
+            return store.ListOptions{}, false
+        }
+    }
+
+    return opts, true
+}
+
+// respondWithPage writes a page of results together with its pagination
+// metadata and RFC 8288 Link header, or a 404 when a numbered page lies past
+// the last one.
+func respondWithPage[T any](w http.ResponseWriter, r *http.Request, page store.Page[T], params pagination.PaginationParams) {
+    keyset := params.Cursor != ""
+    response := map[string]interface{}{
+        "data":        page.Items,
+        "per_page":    params.PerPage,
+        "next_cursor": nil,
+    }
+    if page.NextCursor != "" {
+        response["next_cursor"] = page.NextCursor
+    }
+    if !keyset {
+        response["page"] = params.Page
+    }
+
+    totalPages := -1
+    if page.TotalItems >= 0 {
+        totalPages = (page.TotalItems + params.PerPage - 1) / params.PerPage
+        response["total_items"] = page.TotalItems
+        response["total_pages"] = totalPages
+    }
+
+    if !keyset && params.Page > totalPages && totalPages > 0 {
+        util.RespondWithError(w, http.StatusNotFound, "Page not found")
+        return
+    }
+
+    var links []string
+    link := func(rel string, set map[string]string) {
+        q := r.URL.Query()
+        q.Del("page")
+        q.Del("cursor")
+        for k, v := range set {
+            q.Set(k, v)
+        }
+        links = append(links, fmt.Sprintf(`<%s?%s>; rel="%s"`, r.URL.Path, q.Encode(), rel))
+    }
+
+    link("first", nil)
+    if page.NextCursor != "" {
+        if keyset {
+            link("next", map[string]string{"cursor": page.NextCursor})
+        } else {
+            link("next", map[string]string{"page": strconv.Itoa(params.Page + 1)})
+        }
+    }
+    if !keyset && params.Page > 1 {
+        link("prev", map[string]string{"page": strconv.Itoa(params.Page - 1)})
+    }
+    if !keyset && totalPages > 0 {
+        link("last", map[string]string{"page": strconv.Itoa(totalPages)})
+    }
+    w.Header().Set("Link", strings.Join(links, ", "))
+
+    util.RespondWithJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alanowatson/LeadGenAPI/internal/contact"
+	"github.com/alanowatson/LeadGenAPI/internal/errors"
+	"github.com/alanowatson/LeadGenAPI/internal/mergepatch"
+	"github.com/alanowatson/LeadGenAPI/internal/models"
+	"github.com/alanowatson/LeadGenAPI/internal/query"
+	"github.com/alanowatson/LeadGenAPI/internal/spotify"
+	"github.com/alanowatson/LeadGenAPI/internal/store"
+	"github.com/alanowatson/LeadGenAPI/internal/validation"
+	"github.com/alanowatson/LeadGenAPI/pkg/util"
+	"github.com/gorilla/mux"
+)
+
+// maxImportSize caps the multipart body of an import request.
+const maxImportSize = 10 << 20
+
+// Import row outcomes.
+const (
+    importCreated = "created"
+    importUpdated = "updated"
+    importFailed  = "failed"
+)
+
+// importer describes how CSV rows become records of one resource.
+type importer[T any] struct {
+    schema query.Schema
+    // id is the primary key field, which rows cannot set.
+    id string
+    // key is the natural key rows are upserted on.
+    key string
+    // canonical reduces a key cell to the form it is stored in.
+    canonical func(string) string
+    // list finds the live record holding a key value.
+    list   func(ctx context.Context, opts store.ListOptions) (store.Page[T], error)
+    create func(ctx context.Context, v *T) error
+    update func(ctx context.Context, v *T) error
+    idOf   func(T) int
+    // normalize rewrites a row's record to its stored form before
+    // validation.
+    normalize func(*T)
+}
+
+func (h *Handler) playlisterImporter() importer[models.Playlister] {
+    return importer[models.Playlister]{
+        schema: store.PlaylisterSchema,
+        id:     "playlisterid",
+        key:    "spotifyuserid",
+        canonical: func(s string) string {
+            return spotify.Canonical(s, spotify.User)
+        },
+        list:   h.store.Playlisters.List,
+        create: h.store.Playlisters.Create,
+        update: h.store.Playlisters.Update,
+        idOf:   func(p models.Playlister) int { return p.ID },
+        normalize: func(p *models.Playlister) {
+            p.Normalize(contact.DefaultRegion())
+        },
+    }
+}
+
+func (h *Handler) playlistImporter() importer[models.Playlist] {
+    return importer[models.Playlist]{
+        schema: store.PlaylistSchema,
+        id:     "playlistid",
+        key:    "playlistspotifyid",
+        canonical: func(s string) string {
+            return spotify.Canonical(s, spotify.Playlist)
+        },
+        list:   h.store.Playlists.List,
+        create: h.store.Playlists.Create,
+        update: h.store.Playlists.Update,
+        idOf:   func(p models.Playlist) int { return p.ID },
+        normalize: func(p *models.Playlist) {
+            p.Normalize()
+        },
+    }
+}
+
+// readOnly lists the schema fields the store derives, which rows cannot set.
+var readOnly = map[string]bool{
+    "deleted_at":          true,
+    "lastcontacted":       true,
+    "status_changed_at":   true,
+    "last_synced_at":      true,
+    "spotify_deleted_at":  true,
+    "verified_at":         true,
+    "track_present":       true,
+    "track_first_seen_at": true,
+    "track_removed_at":    true,
+    "flagged":             true,
+    "growth_7d":           true,
+    "growth_30d":          true,
+    "growth_90d":          true,
+    "growth_rate_7d":      true,
+    "growth_rate_30d":     true,
+    "growth_rate_90d":     true,
+}
+
+// importRow reports what happened to one CSV row. Row is its line number in
+// the file.
+type importRow struct {
+    Row    int    `json:"row"`
+    Action string `json:"action"`
+    ID     int    `json:"id,omitempty"`
+    Error  string `json:"error,omitempty"`
+}
+
+// ImportResource upserts the rows of an uploaded CSV file into playlisters or
+// playlists. The header row names the fields; rows matching an existing record
+// on its Spotify ID update the fields they set and other rows are created.
+// With dry_run=true every row is checked but nothing is written.
+func (h *Handler) ImportResource(w http.ResponseWriter, r *http.Request) {
+    switch resource := mux.Vars(r)["resource"]; resource {
+    case store.EntityPlaylister:
+        runImport(w, r, h.playlisterImporter())
+    case store.EntityPlaylist:
+        runImport(w, r, h.playlistImporter())
+    default:
+        util.RespondWithError(w, http.StatusNotFound, fmt.Sprintf("Cannot import %s", resource))
+    }
+}
+
+func runImport[T any](w http.ResponseWriter, r *http.Request, imp importer[T]) {
+    dryRun := false
+    if raw := r.URL.Query().Get("dry_run"); raw != "" {
+        var err error
+        if dryRun, err = strconv.ParseBool(raw); err != nil {
+            util.RespondWithError(w, http.StatusBadRequest, "dry_run must be true or false")
+            return
+        }
+    }
+
+    r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+    file, _, err := r.FormFile("file")
+    if err != nil {
+        errors.HandleError(w, err, http.StatusBadRequest, "Expected a multipart upload with a CSV file field")
+        return
+    }
+    defer file.Close()
+
+    reader := csv.NewReader(file)
+    header, err := reader.Read()
+    if err != nil {
+        errors.HandleError(w, err, http.StatusBadRequest, "Invalid CSV header")
+        return
+    }
+    columns, err := imp.columns(header)
+    if err != nil {
+        util.RespondWithError(w, http.StatusBadRequest, err.Error())
+        return
+    }
+
+    // A dry run writes nothing, so later rows repeating a key are merged
+    // onto the earlier row's result here rather than onto a stored record.
+    pending := map[string][]byte{}
+    rows := []importRow{}
+    counts := map[string]int{}
+    for {
+        record, err := reader.Read()
+        if err == io.EOF {
+            break
+        }
+        line, _ := reader.FieldPos(0)
+        result := importRow{Row: line}
+        if err != nil {
+            result.Action, result.Error = importFailed, err.Error()
+        } else {
+            result = imp.row(r.Context(), line, columns, record, dryRun, pending)
+        }
+        counts[result.Action]++
+        rows = append(rows, result)
+    }
+
+    log.Printf("Imported %d rows: %d created, %d updated, %d failed (dry run: %t)",
+        len(rows), counts[importCreated], counts[importUpdated], counts[importFailed], dryRun)
+    util.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+        "dry_run": dryRun,
+        "created": counts[importCreated],
+        "updated": counts[importUpdated],
+        "failed":  counts[importFailed],
+        "rows":    rows,
+    })
+}
+
+// columns resolves the header row to schema fields. Every header must be an
+// importable field, at most once, and the natural key must be among them.
+func (imp importer[T]) columns(header []string) ([]query.Field, error) {
+    fields := make([]query.Field, len(header))
+    seen := map[string]bool{}
+    for i, name := range header {
+        name = strings.ToLower(strings.TrimSpace(name))
+        f, ok := imp.schema.Field(name)
+        if !ok || name == imp.id || readOnly[name] {
+            return nil, fmt.Errorf("unknown column %q", name)
+        }
+        if seen[name] {
+            return nil, fmt.Errorf("duplicate column %q", name)
+        }
+        seen[name] = true
+        fields[i] = f
+    }
+    if !seen[imp.key] {
+        return nil, fmt.Errorf("missing %s column", imp.key)
+    }
+    return fields, nil
+}
+
+// row upserts one CSV record. Empty cells leave the field unchanged.
+func (imp importer[T]) row(ctx context.Context, line int, columns []query.Field, record []string, dryRun bool, pending map[string][]byte) importRow {
+    failed := func(format string, args ...interface{}) importRow {
+        return importRow{Row: line, Action: importFailed, Error: fmt.Sprintf(format, args...)}
+    }
+
+    patch := map[string]interface{}{}
+    for i, f := range columns {
+        cell := strings.TrimSpace(record[i])
+        if cell == "" {
+            continue
+        }
+        v, err := cellValue(f, cell)
+        if err != nil {
+            return failed("%s: %v", f.Name, err)
+        }
+        patch[f.Name] = v
+    }
+    key, _ := patch[imp.key].(string)
+    if key == "" {
+        return failed("%s is required", imp.key)
+    }
+    key = imp.canonical(key)
+    patch[imp.key] = key
+
+    action := importCreated
+    doc := []byte("{}")
+    existing, found, err := imp.find(ctx, key)
+    if err != nil {
+        log.Printf("Error looking up %s %s: %v", imp.key, key, err)
+        return failed("error looking up %s", imp.key)
+    }
+    if found {
+        action = importUpdated
+        if doc, err = json.Marshal(existing); err != nil {
+            return failed("%v", err)
+        }
+    }
+    if earlier, ok := pending[key]; ok {
+        action = importUpdated
+        doc = earlier
+    }
+
+    patchDoc, err := json.Marshal(patch)
+    if err != nil {
+        return failed("%v", err)
+    }
+    merged, err := mergepatch.Apply(doc, patchDoc)
+    if err != nil {
+        return failed("%v", err)
+    }
+    var v T
+    if err := json.Unmarshal(merged, &v); err != nil {
+        return failed("%v", err)
+    }
+    imp.normalize(&v)
+    if err := validation.ValidateStruct(v); err != nil {
+        return failed("Validation error: %v", err)
+    }
+
+    if dryRun {
+        pending[key] = merged
+        return importRow{Row: line, Action: action, ID: imp.idOf(v)}
+    }
+
+    write := imp.create
+    if found {
+        write = imp.update
+    }
+    if err := write(ctx, &v); err != nil {
+        if _, message, ok := errors.ClientError(err); ok {
+            return failed("%s", message)
+        }
+        log.Printf("Error importing row %d: %v", line, err)
+        return failed("error writing record")
+    }
+    return importRow{Row: line, Action: action, ID: imp.idOf(v)}
+}
+
+// find looks up the live record whose natural key is value.
+func (imp importer[T]) find(ctx context.Context, value string) (T, bool, error) {
+    var zero T
+    found, err := liveMatches(ctx, imp.list, imp.schema, imp.key, value, 1)
+    if err != nil || len(found) == 0 {
+        return zero, false, err
+    }
+    return found[0], true, nil
+}
+
+// cellValue converts a CSV cell to the JSON value of its field.
+func cellValue(f query.Field, cell string) (interface{}, error) {
+    switch f.Type {
+    case query.Int:
+        n, err := strconv.Atoi(cell)
+        if err != nil {
+            return nil, fmt.Errorf("%q is not an integer", cell)
+        }
+        return n, nil
+    case query.Bool:
+        b, err := strconv.ParseBool(cell)
+        if err != nil {
+            return nil, fmt.Errorf("%q is not a boolean", cell)
+        }
+        return b, nil
+    }
+    return cell, nil
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/alanowatson/LeadGenAPI/internal/errors"
+	"github.com/alanowatson/LeadGenAPI/internal/models"
+	"github.com/alanowatson/LeadGenAPI/internal/store"
+	"github.com/alanowatson/LeadGenAPI/internal/validation"
+	"github.com/alanowatson/LeadGenAPI/pkg/util"
+)
+
+// clockSkew is how far in the future a logged message's sent_at may lie.
+const clockSkew = time.Minute
+
+// LogPlaylistCampaignMessage records a message sent to or received from the
+// placement's curator. Outbound messages count towards the campaign's
+// max_messages and are refused with 409 once it is reached.
+func (h *Handler) LogPlaylistCampaignMessage(w http.ResponseWriter, r *http.Request) {
+    playlistID, campaignID, ok := placementKey(w, r)
+    if !ok {
+        return
+    }
+
+    var m models.Message
+    if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+        errors.HandleError(w, err, http.StatusBadRequest, "Invalid request payload")
+        return
+    }
+    defer r.Body.Close()
+    m.PlaylistID = playlistID
+    m.CampaignID = campaignID
+
+    if err := validation.ValidateStruct(m); err != nil {
+        errors.HandleError(w, err, http.StatusBadRequest, "Validation error")
+        return
+    }
+    if sentAt, err := store.SentAt(m); err == nil && sentAt.After(time.Now().Add(clockSkew)) {
+        util.RespondWithError(w, http.StatusBadRequest, "sent_at cannot be in the future")
+        return
+    }
+
+    if err := h.store.PlaylistCampaigns.LogMessage(r.Context(), &m); err != nil {
+        if err == store.ErrNotFound {
+            util.RespondWithError(w, http.StatusNotFound, "PlaylistCampaign not found")
+            return
+        }
+        errors.HandleStoreError(w, err, "Error logging message")
+        return
+    }
+
+    log.Printf("Logged %s %s message %d for placement %d/%d", m.Direction, m.Channel, m.ID, playlistID, campaignID)
+    util.RespondWithJSON(w, http.StatusCreated, m)
+}
+
+// GetPlaylistCampaignMessages lists a placement's messages, oldest first.
+func (h *Handler) GetPlaylistCampaignMessages(w http.ResponseWriter, r *http.Request) {
+    playlistID, campaignID, ok := placementKey(w, r)
+    if !ok {
+        return
+    }
+
+    messages, err := h.store.PlaylistCampaigns.Messages(r.Context(), playlistID, campaignID)
+    if err != nil {
+        if err == store.ErrNotFound {
+            util.RespondWithError(w, http.StatusNotFound, "PlaylistCampaign not found")
+            return
+        }
+        log.Printf("Error listing messages of placement %d/%d: %v", playlistID, campaignID, err)
+        util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving placement messages")
+        return
+    }
+    util.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+        "playlistid": playlistID,
+        "campaignid": campaignID,
+        "data":       messages,
+    })
+}
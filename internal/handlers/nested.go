@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/alanowatson/LeadGenAPI/internal/models"
+	"github.com/alanowatson/LeadGenAPI/internal/store"
+	"github.com/alanowatson/LeadGenAPI/pkg/util"
+	"github.com/gorilla/mux"
+)
+
+// parent identifies the resource a nested collection hangs off. deletedAt
+// loads the parent's DeletedAt, failing with store.ErrNotFound when it does
+// not exist.
+type parent struct {
+    name      string
+    deletedAt func(ctx context.Context, id int) (sql.NullString, error)
+}
+
+func (h *Handler) playlisterParent() parent {
+    return parent{"Playlister", func(ctx context.Context, id int) (sql.NullString, error) {
+        p, err := h.store.Playlisters.Get(ctx, id, "deleted_at")
+        return p.DeletedAt, err
+    }}
+}
+
+func (h *Handler) playlistParent() parent {
+    return parent{"Playlist", func(ctx context.Context, id int) (sql.NullString, error) {
+        p, err := h.store.Playlists.Get(ctx, id, "deleted_at")
+        return p.DeletedAt, err
+    }}
+}
+
+func (h *Handler) campaignParent() parent {
+    return parent{"Campaign", func(ctx context.Context, id int) (sql.NullString, error) {
+        p, err := h.store.Campaigns.Get(ctx, id, "deleted_at")
+        return p.DeletedAt, err
+    }}
+}
+
+// parentID parses the {id} route variable and confirms the parent exists and,
+// unless the request includes deleted records, is not in the trash. It
+// responds with 400 or 404 and returns false otherwise.
+func parentID(w http.ResponseWriter, r *http.Request, p parent) (int, bool) {
+    id, err := strconv.Atoi(mux.Vars(r)["id"])
+    if err != nil {
+        util.RespondWithError(w, http.StatusBadRequest, "Invalid "+p.name+" ID")
+        return 0, false
+    }
+
+    deletedAt, err := p.deletedAt(r.Context(), id)
+    if err == nil && hidden(r, deletedAt) {
+        err = store.ErrNotFound
+    }
+    if err != nil {
+        if err == store.ErrNotFound {
+            util.RespondWithError(w, http.StatusNotFound, p.name+" not found")
+            return 0, false
+        }
+        log.Printf("Error checking %s %d: %v", p.name, id, err)
+        util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving "+p.name)
+        return 0, false
+    }
+    return id, true
+}
+
+// GetPlaylisterPlaylists lists the playlists owned by a playlister.
+func (h *Handler) GetPlaylisterPlaylists(w http.ResponseWriter, r *http.Request) {
+    id, ok := parentID(w, r, h.playlisterParent())
+    if !ok {
+        return
+    }
+    opts, ok := listOptions(w, r, store.PlaylistSchema)
+    if !ok {
+        return
+    }
+    v, ok := parseView(w, r, store.PlaylistSchema, h.playlistRelations())
+    if !ok {
+        return
+    }
+    opts.Fields = v.load()
+    opts.Query.Filters = append(opts.Query.Filters, store.PlaylistSchema.Equals("playlisterid", id))
+
+    if exported(w, r, opts, v, playlistColumns, h.store.Playlists.Each) {
+        return
+    }
+
+    page, err := h.store.Playlists.List(r.Context(), opts)
+    if err != nil {
+        log.Printf("Error listing playlists for playlister %d: %v", id, err)
+        util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving playlists")
+        return
+    }
+    respondWithViewPage(w, r, page, opts.Page, v)
+}
+
+// GetPlaylisterPlacements lists the placements across a playlister's playlists.
+func (h *Handler) GetPlaylisterPlacements(w http.ResponseWriter, r *http.Request) {
+    h.listPlacements(w, r, h.playlisterParent(), "playlisterid")
+}
+
+// GetPlaylistPlacements lists the placements on a playlist.
+func (h *Handler) GetPlaylistPlacements(w http.ResponseWriter, r *http.Request) {
+    h.listPlacements(w, r, h.playlistParent(), "playlistid")
+}
+
+// GetCampaignPlacements lists the placements made for a campaign.
+func (h *Handler) GetCampaignPlacements(w http.ResponseWriter, r *http.Request) {
+    h.listPlacements(w, r, h.campaignParent(), "campaignid")
+}
+
+func (h *Handler) listPlacements(w http.ResponseWriter, r *http.Request, p parent, field string) {
+    if r.URL.Query().Has("q") {
+        util.RespondWithError(w, http.StatusBadRequest, "Search is not supported for playlist campaigns")
+        return
+    }
+    id, ok := parentID(w, r, p)
+    if !ok {
+        return
+    }
+    opts, ok := listOptions(w, r, store.PlaylistCampaignSchema)
+    if !ok {
+        return
+    }
+    v, ok := parseView(w, r, store.PlaylistCampaignSchema, h.placementRelations())
+    if !ok {
+        return
+    }
+    opts.Fields = v.load()
+    opts.Query.Filters = append(opts.Query.Filters, store.PlaylistCampaignSchema.Equals(field, id))
+
+    if exported(w, r, opts, v, placementColumns, h.store.PlaylistCampaigns.Each) {
+        return
+    }
+
+    page, err := h.store.PlaylistCampaigns.List(r.Context(), opts)
+    if err != nil {
+        log.Printf("Error listing placements for %s %d: %v", p.name, id, err)
+        util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving playlist campaigns")
+        return
+    }
+    respondWithViewPage(w, r, page, opts.Page, v)
+}
+
+// GetPlaylistCampaignsForPlaylist lists the campaigns placed on a playlist.
+func (h *Handler) GetPlaylistCampaignsForPlaylist(w http.ResponseWriter, r *http.Request) {
+    id, ok := parentID(w, r, h.playlistParent())
+    if !ok {
+        return
+    }
+    opts, ok := listOptions(w, r, store.CampaignSchema)
+    if !ok {
+        return
+    }
+    v, ok := parseView[models.Campaign](w, r, store.CampaignSchema, nil)
+    if !ok {
+        return
+    }
+    opts.Fields = v.load()
+    opts.ViaPlacement = &store.PlacementLink{Field: "playlistid", ID: id}
+
+    if exported(w, r, opts, v, campaignColumns, h.store.Campaigns.Each) {
+        return
+    }
+
+    page, err := h.store.Campaigns.List(r.Context(), opts)
+    if err != nil {
+        log.Printf("Error listing campaigns for playlist %d: %v", id, err)
+        util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving campaigns")
+        return
+    }
+    respondWithViewPage(w, r, page, opts.Page, v)
+}
+
+// GetCampaignPlaylists lists the playlists a campaign has been placed on.
+func (h *Handler) GetCampaignPlaylists(w http.ResponseWriter, r *http.Request) {
+    id, ok := parentID(w, r, h.campaignParent())
+    if !ok {
+        return
+    }
+    opts, ok := listOptions(w, r, store.PlaylistSchema)
+    if !ok {
+        return
+    }
+    v, ok := parseView(w, r, store.PlaylistSchema, h.playlistRelations())
+    if !ok {
+        return
+    }
+    opts.Fields = v.load()
+    opts.ViaPlacement = &store.PlacementLink{Field: "campaignid", ID: id}
+
+    if exported(w, r, opts, v, playlistColumns, h.store.Playlists.Each) {
+        return
+    }
+
+    page, err := h.store.Playlists.List(r.Context(), opts)
+    if err != nil {
+        log.Printf("Error listing playlists for campaign %d: %v", id, err)
+        util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving playlists")
+        return
+    }
+    respondWithViewPage(w, r, page, opts.Page, v)
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/alanowatson/LeadGenAPI/internal/mergepatch"
+	"github.com/alanowatson/LeadGenAPI/pkg/util"
+)
+
+// applyPatch reads a JSON Merge Patch from the request body and applies it to
+// current. It responds with 415 or 400 and returns false when the request
+// does not carry a usable patch.
+func applyPatch[T any](w http.ResponseWriter, r *http.Request, current T) (T, bool) {
+    var merged T
+
+    mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+    if mediaType != mergepatch.MediaType && mediaType != "application/json" {
+        util.RespondWithError(w, http.StatusUnsupportedMediaType, "Content-Type must be "+mergepatch.MediaType)
+        return merged, false
+    }
+
+    patch, err := io.ReadAll(r.Body)
+    defer r.Body.Close()
+    if err != nil {
+        util.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+        return merged, false
+    }
+
+    doc, err := json.Marshal(current)
+    if err != nil {
+        util.RespondWithError(w, http.StatusInternalServerError, "Error applying patch")
+        return merged, false
+    }
+
+    out, err := mergepatch.Apply(doc, patch)
+    if err != nil {
+        util.RespondWithError(w, http.StatusBadRequest, "Invalid merge patch: "+err.Error())
+        return merged, false
+    }
+
+    if err := json.Unmarshal(out, &merged); err != nil {
+        util.RespondWithError(w, http.StatusBadRequest, "Invalid request payload: "+err.Error())
+        return merged, false
+    }
+    return merged, true
+}
@@ -2,109 +2,49 @@ package handlers
 
 import (
 	"encoding/json"
-    "database/sql"
 	"log"
 	"net/http"
 	"strconv"
-	"sync"
 
-	"github.com/alanowatson/LeadGenAPI/internal/db"
 	"github.com/alanowatson/LeadGenAPI/internal/errors"
 	"github.com/alanowatson/LeadGenAPI/internal/models"
-	"github.com/alanowatson/LeadGenAPI/internal/pagination"
+	"github.com/alanowatson/LeadGenAPI/internal/store"
 	"github.com/alanowatson/LeadGenAPI/internal/validation"
 	"github.com/alanowatson/LeadGenAPI/pkg/util"
 	"github.com/gorilla/mux"
 )
 
-var (
-    playlists     = make(map[int]models.Playlist)
-    playlistID    = 1
-    playlistMutex sync.RWMutex
-)
-
-func GetPlaylists(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetPlaylists(w http.ResponseWriter, r *http.Request) {
     log.Println("GetPlaylists function called")
 
-    paginationParams := pagination.GetPaginationParams(r)
-    log.Printf("Pagination params: page=%d, per_page=%d", paginationParams.Page, paginationParams.PerPage)
-
-    var totalItems int
-    err := db.DB.QueryRow("SELECT COUNT(*) FROM playlists").Scan(&totalItems)
-    if err != nil {
-        log.Printf("Error getting total count: %v", err)
-        util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving playlists")
+    opts, ok := listOptions(w, r, store.PlaylistSchema)
+    if !ok {
         return
     }
-    log.Printf("Total playlists in database: %d", totalItems)
-
-    totalPages := (totalItems + paginationParams.PerPage - 1) / paginationParams.PerPage
-    log.Printf("Total pages: %d", totalPages)
-
-    if paginationParams.Page > totalPages && totalPages > 0 {
-        log.Printf("Requested page %d exceeds total pages %d", paginationParams.Page, totalPages)
-        util.RespondWithError(w, http.StatusNotFound, "Page not found")
+    v, ok := parseView(w, r, store.PlaylistSchema, h.playlistRelations())
+    if !ok {
         return
     }
+    opts.Fields = v.load()
+    log.Printf("Pagination params: page=%d, per_page=%d", opts.Page.Page, opts.Page.PerPage)
 
-    offset := (paginationParams.Page - 1) * paginationParams.PerPage
-
-    query := `
-        SELECT playlistid, playlisterid, playlistspotifyid, numberoffollowers, current_playlist_name, lastfollowercountdate, last_exposed
-        FROM playlists
-        ORDER BY playlistid
-        LIMIT $1 OFFSET $2
-    `
-    log.Printf("Executing query: %s", query)
-    rows, err := db.DB.Query(query, paginationParams.PerPage, offset)
-    if err != nil {
-        log.Printf("Error querying playlists: %v", err)
-        util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving playlists")
+    if exported(w, r, opts, v, playlistColumns, h.store.Playlists.Each) {
         return
     }
-    defer rows.Close()
-
-       var playlists []models.Playlist
-    for rows.Next() {
-        var p models.Playlist
-        err := rows.Scan(
-            &p.ID,
-            &p.PlaylisterId,
-            &p.PlaylistSpotifyId,
-            &p.NumberOfFollowers,
-            &p.CurrentPlaylistName,
-            &p.LastFollowerCountDate,
-            &p.LastExposed,
-        )
-        if err != nil {
-            log.Printf("Error scanning playlist row: %v", err)
-            util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving playlists")
-            return
-        }
-        playlists = append(playlists, p)
-    }
 
-    if err = rows.Err(); err != nil {
-        log.Printf("Error after scanning all rows: %v", err)
+    page, err := h.store.Playlists.List(r.Context(), opts)
+    if err != nil {
+        log.Printf("Error listing playlists: %v", err)
         util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving playlists")
         return
     }
 
-    log.Printf("Number of playlists retrieved: %d", len(playlists))
-
-    response := map[string]interface{}{
-        "data":        playlists,
-        "page":        paginationParams.Page,
-        "per_page":    paginationParams.PerPage,
-        "total_items": totalItems,
-        "total_pages": totalPages,
-    }
-
-    util.RespondWithJSON(w, http.StatusOK, response)
+    log.Printf("Number of playlists retrieved: %d", len(page.Items))
+    respondWithViewPage(w, r, page, opts.Page, v)
     log.Println("GetPlaylists function completed")
 }
 
-func GetPlaylist(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetPlaylist(w http.ResponseWriter, r *http.Request) {
     log.Println("GetPlaylist function called")
 
     vars := mux.Vars(r)
@@ -114,29 +54,19 @@ func GetPlaylist(w http.ResponseWriter, r *http.Request) {
         util.RespondWithError(w, http.StatusBadRequest, "Invalid playlist ID")
         return
     }
-
     log.Printf("Looking up playlist with ID: %d", id)
 
-    query := `
-        SELECT playlistid, playlisterid, playlistspotifyid, numberoffollowers,
-               current_playlist_name, lastfollowercountdate, last_exposed
-        FROM playlists
-        WHERE playlistid = $1
-    `
-
-    var p models.Playlist
-    err = db.DB.QueryRow(query, id).Scan(
-        &p.ID,
-        &p.PlaylisterId,
-        &p.PlaylistSpotifyId,
-        &p.NumberOfFollowers,
-        &p.CurrentPlaylistName,
-        &p.LastFollowerCountDate,
-        &p.LastExposed,
-    )
+    v, ok := parseView(w, r, store.PlaylistSchema, h.playlistRelations())
+    if !ok {
+        return
+    }
 
+    p, err := h.store.Playlists.Get(r.Context(), id, v.load()...)
+    if err == nil && hidden(r, p.DeletedAt) {
+        err = store.ErrNotFound
+    }
     if err != nil {
-        if err == sql.ErrNoRows {
+        if err == store.ErrNotFound {
             log.Printf("Playlist not found with ID: %d", id)
             util.RespondWithError(w, http.StatusNotFound, "Playlist not found")
             return
@@ -147,11 +77,14 @@ func GetPlaylist(w http.ResponseWriter, r *http.Request) {
     }
 
     log.Printf("Successfully retrieved playlist with ID: %d", id)
-    util.RespondWithJSON(w, http.StatusOK, p)
+    if notModified(w, r, p.Version) {
+        return
+    }
+    respondWithView(w, r, http.StatusOK, p, v)
     log.Println("GetPlaylist function completed")
 }
 
-func CreatePlaylist(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) CreatePlaylist(w http.ResponseWriter, r *http.Request) {
     var playlist models.Playlist
     decoder := json.NewDecoder(r.Body)
     if err := decoder.Decode(&playlist); err != nil {
@@ -160,27 +93,33 @@ func CreatePlaylist(w http.ResponseWriter, r *http.Request) {
     }
     defer r.Body.Close()
 
+    playlist.Normalize()
     if err := validation.ValidateStruct(playlist); err != nil {
         errors.HandleError(w, err, http.StatusBadRequest, "Validation error")
         return
     }
 
-    playlistMutex.Lock()
-    playlist.ID = playlistID
-    playlists[playlistID] = playlist
-    playlistID++
-    playlistMutex.Unlock()
+    if err := h.store.Playlists.Create(r.Context(), &playlist); err != nil {
+        errors.HandleStoreError(w, err, "Error creating playlist")
+        return
+    }
 
+    log.Printf("Created playlist with ID: %d", playlist.ID)
+    setETag(w, playlist.Version)
     util.RespondWithJSON(w, http.StatusCreated, playlist)
 }
 
-func UpdatePlaylist(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) UpdatePlaylist(w http.ResponseWriter, r *http.Request) {
     vars := mux.Vars(r)
     id, err := strconv.Atoi(vars["id"])
     if err != nil {
         errors.HandleError(w, err, http.StatusBadRequest, "Invalid playlist ID")
         return
     }
+    version, ok := ifMatch(w, r)
+    if !ok {
+        return
+    }
 
     var playlist models.Playlist
     decoder := json.NewDecoder(r.Body)
@@ -190,42 +129,108 @@ func UpdatePlaylist(w http.ResponseWriter, r *http.Request) {
     }
     defer r.Body.Close()
 
+    playlist.Normalize()
     if err := validation.ValidateStruct(playlist); err != nil {
         errors.HandleError(w, err, http.StatusBadRequest, "Validation error")
         return
     }
 
-    playlistMutex.Lock()
-    defer playlistMutex.Unlock()
+    playlist.ID = id
+    playlist.Version = version
+    if err := h.store.Playlists.Update(r.Context(), &playlist); err != nil {
+        if err == store.ErrNotFound {
+            util.RespondWithError(w, http.StatusNotFound, "Playlist not found")
+            return
+        }
+        errors.HandleStoreError(w, err, "Error updating playlist")
+        return
+    }
 
-    if _, found := playlists[id]; !found {
-        util.RespondWithError(w, http.StatusNotFound, "Playlist not found")
+    setETag(w, playlist.Version)
+    util.RespondWithJSON(w, http.StatusOK, playlist)
+}
+
+// PatchPlaylist applies a JSON Merge Patch to a playlist. Keys missing from the
+// patch keep their current value and null clears a nullable column; only the
+// merged result is validated.
+func (h *Handler) PatchPlaylist(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    id, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        errors.HandleError(w, err, http.StatusBadRequest, "Invalid playlist ID")
+        return
+    }
+    version, ok := ifMatch(w, r)
+    if !ok {
         return
     }
 
-    playlist.ID = id
-    playlists[id] = playlist
+    current, err := h.store.Playlists.Get(r.Context(), id)
+    if err == nil && current.DeletedAt.Valid {
+        err = store.ErrNotFound
+    }
+    if err != nil {
+        if err == store.ErrNotFound {
+            util.RespondWithError(w, http.StatusNotFound, "Playlist not found")
+            return
+        }
+        errors.HandleStoreError(w, err, "Error retrieving playlist")
+        return
+    }
+    if !checkVersion(w, version, current.Version) {
+        return
+    }
+
+    playlist, ok := applyPatch(w, r, current)
+    if !ok {
+        return
+    }
+    // The update is conditional on the version the patch was applied to.
+    playlist.Version = current.Version
+    if playlist.ID != id {
+        util.RespondWithError(w, http.StatusBadRequest, "Playlist ID in URL must match payload")
+        return
+    }
 
+    playlist.Normalize()
+    if err := validation.ValidateStruct(playlist); err != nil {
+        errors.HandleError(w, err, http.StatusBadRequest, "Validation error")
+        return
+    }
+
+    if err := h.store.Playlists.Update(r.Context(), &playlist); err != nil {
+        if err == store.ErrNotFound {
+            util.RespondWithError(w, http.StatusNotFound, "Playlist not found")
+            return
+        }
+        errors.HandleStoreError(w, err, "Error updating playlist")
+        return
+    }
+
+    setETag(w, playlist.Version)
     util.RespondWithJSON(w, http.StatusOK, playlist)
 }
 
-func DeletePlaylist(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) DeletePlaylist(w http.ResponseWriter, r *http.Request) {
     vars := mux.Vars(r)
     id, err := strconv.Atoi(vars["id"])
     if err != nil {
         util.RespondWithError(w, http.StatusBadRequest, "Invalid playlist ID")
         return
     }
-
-    playlistMutex.Lock()
-    defer playlistMutex.Unlock()
-
-    if _, found := playlists[id]; !found {
-        util.RespondWithError(w, http.StatusNotFound, "Playlist not found")
+    version, ok := ifMatch(w, r)
+    if !ok {
         return
     }
 
-    delete(playlists, id)
+    if err := h.store.Playlists.Delete(r.Context(), id, version); err != nil {
+        if err == store.ErrNotFound {
+            util.RespondWithError(w, http.StatusNotFound, "Playlist not found")
+            return
+        }
+        errors.HandleStoreError(w, err, "Error deleting playlist")
+        return
+    }
 
     util.RespondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
 }
@@ -1,153 +1,75 @@
 package handlers
 
 import (
-	"database/sql"
 	"encoding/json"
-	"fmt"
 	"log"
 	"net/http"
 	"strconv"
-	"sync"
 
-	"github.com/alanowatson/LeadGenAPI/internal/db"
 	"github.com/alanowatson/LeadGenAPI/internal/errors"
 	"github.com/alanowatson/LeadGenAPI/internal/models"
-	"github.com/alanowatson/LeadGenAPI/internal/pagination"
+	"github.com/alanowatson/LeadGenAPI/internal/store"
 	"github.com/alanowatson/LeadGenAPI/internal/validation"
 	"github.com/alanowatson/LeadGenAPI/pkg/util"
 	"github.com/gorilla/mux"
 )
 
-var (
-    playlistCampaigns     = make(map[string]models.PlaylistCampaign)
-    playlistCampaignMutex sync.RWMutex
-)
-
-const MaxAllowedMessages = 3
-
-
-func GetPlaylistCampaigns(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetPlaylistCampaigns(w http.ResponseWriter, r *http.Request) {
     log.Println("GetPlaylistCampaigns function called")
 
-    paginationParams := pagination.GetPaginationParams(r)
-    log.Printf("Pagination params: page=%d, per_page=%d", paginationParams.Page, paginationParams.PerPage)
-
-    var totalItems int
-    err := db.DB.QueryRow("SELECT COUNT(*) FROM playlistcampaigns").Scan(&totalItems)
-    if err != nil {
-        log.Printf("Error getting total count: %v", err)
-        util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving playlist campaigns")
+    if r.URL.Query().Has("q") {
+        util.RespondWithError(w, http.StatusBadRequest, "Search is not supported for playlist campaigns")
         return
     }
-    log.Printf("Total playlist campaigns in database: %d", totalItems)
-
-    totalPages := (totalItems + paginationParams.PerPage - 1) / paginationParams.PerPage
-    log.Printf("Total pages: %d", totalPages)
 
-    if paginationParams.Page > totalPages && totalPages > 0 {
-        log.Printf("Requested page %d exceeds total pages %d", paginationParams.Page, totalPages)
-        util.RespondWithError(w, http.StatusNotFound, "Page not found")
+    opts, ok := listOptions(w, r, store.PlaylistCampaignSchema)
+    if !ok {
         return
     }
-
-    offset := (paginationParams.Page - 1) * paginationParams.PerPage
-
-    query := `
-        SELECT playlistid, campaignid, playlisterid, referenceartists, placementstatus, numberofmessages, purchased
-        FROM playlistcampaigns
-        ORDER BY playlistid, campaignid
-        LIMIT $1 OFFSET $2
-    `
-    log.Printf("Executing query: %s", query)
-    rows, err := db.DB.Query(query, paginationParams.PerPage, offset)
-    if err != nil {
-        log.Printf("Error querying playlist campaigns: %v", err)
-        util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving playlist campaigns")
+    v, ok := parseView(w, r, store.PlaylistCampaignSchema, h.placementRelations())
+    if !ok {
         return
     }
-    defer rows.Close()
-
-    var playlistCampaigns []models.PlaylistCampaign
-    for rows.Next() {
-        var pc models.PlaylistCampaign
-        err := rows.Scan(
-            &pc.PlaylistID,
-            &pc.CampaignID,
-            &pc.PlaylisterId,
-            &pc.ReferenceArtists,
-            &pc.PlacementStatus,
-            &pc.NumberOfMessages,
-            &pc.Purchased,
-        )
-        if err != nil {
-            log.Printf("Error scanning playlist campaign row: %v", err)
-            util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving playlist campaigns")
-            return
-        }
-        playlistCampaigns = append(playlistCampaigns, pc)
-    }
+    opts.Fields = v.load()
+    log.Printf("Pagination params: page=%d, per_page=%d", opts.Page.Page, opts.Page.PerPage)
 
-    if err = rows.Err(); err != nil {
-        log.Printf("Error after scanning all rows: %v", err)
-        util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving playlist campaigns")
+    if exported(w, r, opts, v, placementColumns, h.store.PlaylistCampaigns.Each) {
         return
     }
 
-    log.Printf("Number of playlist campaigns retrieved: %d", len(playlistCampaigns))
-
-    response := map[string]interface{}{
-        "data":        playlistCampaigns,
-        "page":        paginationParams.Page,
-        "per_page":    paginationParams.PerPage,
-        "total_items": totalItems,
-        "total_pages": totalPages,
+    page, err := h.store.PlaylistCampaigns.List(r.Context(), opts)
+    if err != nil {
+        log.Printf("Error listing playlist campaigns: %v", err)
+        util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving playlist campaigns")
+        return
     }
 
-    log.Printf("Response prepared with %d items", len(playlistCampaigns))
-    util.RespondWithJSON(w, http.StatusOK, response)
+    log.Printf("Number of playlist campaigns retrieved: %d", len(page.Items))
+    respondWithViewPage(w, r, page, opts.Page, v)
     log.Println("GetPlaylistCampaigns function completed")
 }
 
-func GetPlaylistCampaign(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetPlaylistCampaign(w http.ResponseWriter, r *http.Request) {
     log.Println("GetPlaylistCampaign function called")
 
-    vars := mux.Vars(r)
-    playlistID, err := strconv.Atoi(vars["playlistId"])
-    if err != nil {
-        log.Printf("Invalid playlist ID: %v", err)
-        util.RespondWithError(w, http.StatusBadRequest, "Invalid playlist ID")
-        return
-    }
-
-    campaignID, err := strconv.Atoi(vars["campaignId"])
-    if err != nil {
-        log.Printf("Invalid campaign ID: %v", err)
-        util.RespondWithError(w, http.StatusBadRequest, "Invalid campaign ID")
+    playlistID, campaignID, ok := placementKey(w, r)
+    if !ok {
         return
     }
 
     log.Printf("Looking up playlist campaign with PlaylistID: %d and CampaignID: %d", playlistID, campaignID)
 
-    query := `
-        SELECT playlistid, campaignid, playlisterid, referenceartists,
-               placementstatus, numberofmessages, purchased
-        FROM playlistcampaigns
-        WHERE playlistid = $1 AND campaignid = $2
-    `
-
-    var pc models.PlaylistCampaign
-    err = db.DB.QueryRow(query, playlistID, campaignID).Scan(
-        &pc.PlaylistID,
-        &pc.CampaignID,
-        &pc.PlaylisterId,
-        &pc.ReferenceArtists,
-        &pc.PlacementStatus,
-        &pc.NumberOfMessages,
-        &pc.Purchased,
-    )
+    v, ok := parseView(w, r, store.PlaylistCampaignSchema, h.placementRelations())
+    if !ok {
+        return
+    }
 
+    pc, err := h.store.PlaylistCampaigns.Get(r.Context(), playlistID, campaignID, v.load()...)
+    if err == nil && hidden(r, pc.DeletedAt) {
+        err = store.ErrNotFound
+    }
     if err != nil {
-        if err == sql.ErrNoRows {
+        if err == store.ErrNotFound {
             log.Printf("PlaylistCampaign not found with PlaylistID: %d and CampaignID: %d", playlistID, campaignID)
             util.RespondWithError(w, http.StatusNotFound, "PlaylistCampaign not found")
             return
@@ -158,11 +80,14 @@ func GetPlaylistCampaign(w http.ResponseWriter, r *http.Request) {
     }
 
     log.Printf("Successfully retrieved playlist campaign with PlaylistID: %d and CampaignID: %d", playlistID, campaignID)
-    util.RespondWithJSON(w, http.StatusOK, pc)
+    if notModified(w, r, pc.Version) {
+        return
+    }
+    respondWithView(w, r, http.StatusOK, pc, v)
     log.Println("GetPlaylistCampaign function completed")
 }
 
-func CreatePlaylistCampaign(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) CreatePlaylistCampaign(w http.ResponseWriter, r *http.Request) {
     var pc models.PlaylistCampaign
     decoder := json.NewDecoder(r.Body)
     if err := decoder.Decode(&pc); err != nil {
@@ -176,106 +101,153 @@ func CreatePlaylistCampaign(w http.ResponseWriter, r *http.Request) {
         return
     }
 
-    if pc.NumberOfMessages > MaxAllowedMessages {
-        util.RespondWithError(w, http.StatusBadRequest, "Exceeds maximum allowed messages")
+    if err := h.store.PlaylistCampaigns.Create(r.Context(), &pc); err != nil {
+        errors.HandleStoreError(w, err, "Error creating PlaylistCampaign")
         return
     }
 
-    playlistExists, err := db.PlaylistExists(pc.PlaylistID)
-    if err != nil {
-        util.RespondWithError(w, http.StatusInternalServerError, "Error checking playlist existence")
+    setETag(w, pc.Version)
+    util.RespondWithJSON(w, http.StatusCreated, pc)
+}
+
+func (h *Handler) UpdatePlaylistCampaign(w http.ResponseWriter, r *http.Request) {
+    playlistID, campaignID, ok := placementKey(w, r)
+    if !ok {
         return
     }
-    if !playlistExists {
-        util.RespondWithError(w, http.StatusBadRequest, "Referenced Playlist does not exist")
+    version, ok := ifMatch(w, r)
+    if !ok {
         return
     }
 
-    campaignExists, err := db.CampaignExists(pc.CampaignID)
-    if err != nil {
-        util.RespondWithError(w, http.StatusInternalServerError, "Error checking campaign existence")
-        return
-    }
-    if !campaignExists {
-        util.RespondWithError(w, http.StatusBadRequest, "Referenced Campaign does not exist")
+    var pc models.PlaylistCampaign
+    decoder := json.NewDecoder(r.Body)
+    if err := decoder.Decode(&pc); err != nil {
+        errors.HandleError(w, err, http.StatusBadRequest, "Invalid request payload")
         return
     }
+    defer r.Body.Close()
 
-    tx, err := db.BeginTx()
-    if err != nil {
-        util.RespondWithError(w, http.StatusInternalServerError, "Error starting transaction")
+    if err := validation.ValidateStruct(pc); err != nil {
+        errors.HandleError(w, err, http.StatusBadRequest, "Validation error")
         return
     }
-    defer tx.Rollback()
 
-    if err := db.CreatePlaylistCampaignTx(tx, pc); err != nil {
-        util.RespondWithError(w, http.StatusInternalServerError, "Error creating PlaylistCampaign")
+    // Ensure the IDs in the URL match the IDs in the payload
+    if pc.PlaylistID != playlistID || pc.CampaignID != campaignID {
+        util.RespondWithError(w, http.StatusBadRequest, "Playlist ID and Campaign ID in URL must match payload")
         return
     }
 
-    if err := tx.Commit(); err != nil {
-        util.RespondWithError(w, http.StatusInternalServerError, "Error committing transaction")
+    pc.Version = version
+    if err := h.store.PlaylistCampaigns.Update(r.Context(), &pc); err != nil {
+        if err == store.ErrNotFound {
+            util.RespondWithError(w, http.StatusNotFound, "PlaylistCampaign not found")
+            return
+        }
+        errors.HandleStoreError(w, err, "Error updating PlaylistCampaign")
         return
     }
 
-    util.RespondWithJSON(w, http.StatusCreated, pc)
+    setETag(w, pc.Version)
+    util.RespondWithJSON(w, http.StatusOK, pc)
 }
 
-func UpdatePlaylistCampaign(w http.ResponseWriter, r *http.Request) {
-    vars := mux.Vars(r)
-    playlistID, _ := strconv.Atoi(vars["playlistId"])
-    campaignID, _ := strconv.Atoi(vars["campaignId"])
-
-    key := fmt.Sprintf("%d_%d", playlistID, campaignID)
-
-    var pc models.PlaylistCampaign
-    decoder := json.NewDecoder(r.Body)
-    if err := decoder.Decode(&pc); err != nil {
-        errors.HandleError(w, err, http.StatusBadRequest, "Invalid request payload")
+// PatchPlaylistCampaign applies a JSON Merge Patch to a placement. Keys
+// missing from the patch keep their current value and null clears a nullable
+// column; only the merged result is validated.
+func (h *Handler) PatchPlaylistCampaign(w http.ResponseWriter, r *http.Request) {
+    playlistID, campaignID, ok := placementKey(w, r)
+    if !ok {
         return
     }
-    defer r.Body.Close()
-
-    if err := validation.ValidateStruct(pc); err != nil {
-        errors.HandleError(w, err, http.StatusBadRequest, "Validation error")
+    version, ok := ifMatch(w, r)
+    if !ok {
         return
     }
 
-    playlistCampaignMutex.Lock()
-    defer playlistCampaignMutex.Unlock()
-
-    if _, found := playlistCampaigns[key]; !found {
-        util.RespondWithError(w, http.StatusNotFound, "PlaylistCampaign not found")
+    current, err := h.store.PlaylistCampaigns.Get(r.Context(), playlistID, campaignID)
+    if err == nil && current.DeletedAt.Valid {
+        err = store.ErrNotFound
+    }
+    if err != nil {
+        if err == store.ErrNotFound {
+            util.RespondWithError(w, http.StatusNotFound, "PlaylistCampaign not found")
+            return
+        }
+        errors.HandleStoreError(w, err, "Error retrieving PlaylistCampaign")
+        return
+    }
+    if !checkVersion(w, version, current.Version) {
         return
     }
 
-    // Ensure the IDs in the URL match the IDs in the payload
+    pc, ok := applyPatch(w, r, current)
+    if !ok {
+        return
+    }
+    // The update is conditional on the version the patch was applied to.
+    pc.Version = current.Version
     if pc.PlaylistID != playlistID || pc.CampaignID != campaignID {
         util.RespondWithError(w, http.StatusBadRequest, "Playlist ID and Campaign ID in URL must match payload")
         return
     }
 
-    playlistCampaigns[key] = pc
+    if err := validation.ValidateStruct(pc); err != nil {
+        errors.HandleError(w, err, http.StatusBadRequest, "Validation error")
+        return
+    }
 
+    if err := h.store.PlaylistCampaigns.Update(r.Context(), &pc); err != nil {
+        if err == store.ErrNotFound {
+            util.RespondWithError(w, http.StatusNotFound, "PlaylistCampaign not found")
+            return
+        }
+        errors.HandleStoreError(w, err, "Error updating PlaylistCampaign")
+        return
+    }
+
+    setETag(w, pc.Version)
     util.RespondWithJSON(w, http.StatusOK, pc)
 }
 
-func DeletePlaylistCampaign(w http.ResponseWriter, r *http.Request) {
-    vars := mux.Vars(r)
-    playlistID, _ := strconv.Atoi(vars["playlistId"])
-    campaignID, _ := strconv.Atoi(vars["campaignId"])
+func (h *Handler) DeletePlaylistCampaign(w http.ResponseWriter, r *http.Request) {
+    playlistID, campaignID, ok := placementKey(w, r)
+    if !ok {
+        return
+    }
+    version, ok := ifMatch(w, r)
+    if !ok {
+        return
+    }
 
-    key := fmt.Sprintf("%d_%d", playlistID, campaignID)
+    if err := h.store.PlaylistCampaigns.Delete(r.Context(), playlistID, campaignID, version); err != nil {
+        if err == store.ErrNotFound {
+            util.RespondWithError(w, http.StatusNotFound, "PlaylistCampaign not found")
+            return
+        }
+        errors.HandleStoreError(w, err, "Error deleting PlaylistCampaign")
+        return
+    }
 
-    playlistCampaignMutex.Lock()
-    defer playlistCampaignMutex.Unlock()
+    util.RespondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
 
-    if _, found := playlistCampaigns[key]; !found {
-        util.RespondWithError(w, http.StatusNotFound, "PlaylistCampaign not found")
-        return
+// placementKey parses the playlist and campaign IDs from the route,
+// responding with 400 and returning false when either is malformed.
+func placementKey(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+    vars := mux.Vars(r)
+    playlistID, err := strconv.Atoi(vars["playlistId"])
+    if err != nil {
+        util.RespondWithError(w, http.StatusBadRequest, "Invalid playlist ID")
+        return 0, 0, false
     }
 
-    delete(playlistCampaigns, key)
+    campaignID, err := strconv.Atoi(vars["campaignId"])
+    if err != nil {
+        util.RespondWithError(w, http.StatusBadRequest, "Invalid campaign ID")
+        return 0, 0, false
+    }
 
-    util.RespondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+    return playlistID, campaignID, true
 }
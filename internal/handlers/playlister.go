@@ -1,116 +1,51 @@
 package handlers
 
 import (
-	"database/sql"
 	"encoding/json"
 	"log"
 	"net/http"
 	"strconv"
-	"sync"
 
-	"github.com/alanowatson/LeadGenAPI/internal/db"
+	"github.com/alanowatson/LeadGenAPI/internal/contact"
 	"github.com/alanowatson/LeadGenAPI/internal/errors"
 	"github.com/alanowatson/LeadGenAPI/internal/models"
-	"github.com/alanowatson/LeadGenAPI/internal/pagination"
+	"github.com/alanowatson/LeadGenAPI/internal/store"
 	"github.com/alanowatson/LeadGenAPI/internal/validation"
 	"github.com/alanowatson/LeadGenAPI/pkg/util"
 	"github.com/gorilla/mux"
 )
 
-var (
-    playlisters = make(map[int]models.Playlister)
-    playlisterID = 1
-    playlisterMutex sync.RWMutex
-)
-
-func GetPlaylisters(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetPlaylisters(w http.ResponseWriter, r *http.Request) {
    log.Println("GetPlaylisters function called")
 
-   paginationParams := pagination.GetPaginationParams(r)
-   log.Printf("Pagination params: page=%d, per_page=%d", paginationParams.Page, paginationParams.PerPage)
-
-   var totalItems int
-   err := db.DB.QueryRow("SELECT COUNT(*) FROM playlisters").Scan(&totalItems)
-   if err != nil {
-       log.Printf("Error getting total count: %v", err)
-       util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving playlisters")
+   opts, ok := listOptions(w, r, store.PlaylisterSchema)
+   if !ok {
        return
    }
-   log.Printf("Total playlisters in database: %d", totalItems)
-
-   totalPages := (totalItems + paginationParams.PerPage - 1) / paginationParams.PerPage
-   log.Printf("Total pages: %d", totalPages)
-
-   if paginationParams.Page > totalPages && totalPages > 0 {
-       log.Printf("Requested page %d exceeds total pages %d", paginationParams.Page, totalPages)
-       util.RespondWithError(w, http.StatusNotFound, "Page not found")
+   v, ok := parseView[models.Playlister](w, r, store.PlaylisterSchema, nil)
+   if !ok {
        return
    }
+   opts.Fields = v.load()
+   log.Printf("Pagination params: page=%d, per_page=%d", opts.Page.Page, opts.Page.PerPage)
 
-   offset := (paginationParams.Page - 1) * paginationParams.PerPage
-
-   query := `
-       SELECT playlisterid, spotifyuserid, curatorfullname, email,
-              instagram, facebook, whatsapp, lastcontacted,
-              preferredlanguage, followupstatus
-       FROM playlisters
-       ORDER BY playlisterid
-       LIMIT $1 OFFSET $2
-   `
-   log.Printf("Executing query: %s", query)
-   rows, err := db.DB.Query(query, paginationParams.PerPage, offset)
-   if err != nil {
-       log.Printf("Error querying playlisters: %v", err)
-       util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving playlisters")
+   if exported(w, r, opts, v, playlisterColumns, h.store.Playlisters.Each) {
        return
    }
-   defer rows.Close()
-
-   var playlisters []models.Playlister
-   for rows.Next() {
-       var p models.Playlister
-       err := rows.Scan(
-           &p.ID,
-           &p.SpotifyUserID,
-           &p.CuratorFullName,
-           &p.Email,
-           &p.Instagram,
-           &p.Facebook,
-           &p.Whatsapp,
-           &p.LastContacted,
-           &p.PreferredLanguage,
-           &p.FollowupStatus,
-       )
-       if err != nil {
-           log.Printf("Error scanning playlister row: %v", err)
-           util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving playlisters")
-           return
-       }
-       playlisters = append(playlisters, p)
-   }
 
-   if err = rows.Err(); err != nil {
-       log.Printf("Error after scanning all rows: %v", err)
+   page, err := h.store.Playlisters.List(r.Context(), opts)
+   if err != nil {
+       log.Printf("Error listing playlisters: %v", err)
        util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving playlisters")
        return
    }
 
-   log.Printf("Number of playlisters retrieved: %d", len(playlisters))
-
-   response := map[string]interface{}{
-       "data":        playlisters,
-       "page":        paginationParams.Page,
-       "per_page":    paginationParams.PerPage,
-       "total_items": totalItems,
-       "total_pages": totalPages,
-   }
-
-   log.Printf("Response prepared with %d items", len(playlisters))
-   util.RespondWithJSON(w, http.StatusOK, response)
+   log.Printf("Number of playlisters retrieved: %d", len(page.Items))
+   respondWithViewPage(w, r, page, opts.Page, v)
    log.Println("GetPlaylisters function completed")
 }
 
-func GetPlaylister(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetPlaylister(w http.ResponseWriter, r *http.Request) {
     log.Println("GetPlaylister function called")
 
     vars := mux.Vars(r)
@@ -122,30 +57,17 @@ func GetPlaylister(w http.ResponseWriter, r *http.Request) {
     }
     log.Printf("Looking up playlister with ID: %d", id)
 
-    query := `
-        SELECT playlisterid, spotifyuserid, curatorfullname, email,
-               instagram, facebook, whatsapp, lastcontacted,
-               preferredlanguage, followupstatus
-        FROM playlisters
-        WHERE playlisterid = $1
-    `
-
-    var p models.Playlister
-    err = db.DB.QueryRow(query, id).Scan(
-        &p.ID,
-        &p.SpotifyUserID,
-        &p.CuratorFullName,
-        &p.Email,
-        &p.Instagram,
-        &p.Facebook,
-        &p.Whatsapp,
-        &p.LastContacted,
-        &p.PreferredLanguage,
-        &p.FollowupStatus,
-    )
+    v, ok := parseView[models.Playlister](w, r, store.PlaylisterSchema, nil)
+    if !ok {
+        return
+    }
 
+    p, err := h.store.Playlisters.Get(r.Context(), id, v.load()...)
+    if err == nil && hidden(r, p.DeletedAt) {
+        err = store.ErrNotFound
+    }
     if err != nil {
-        if err == sql.ErrNoRows {
+        if err == store.ErrNotFound {
             log.Printf("Playlister not found with ID: %d", id)
             util.RespondWithError(w, http.StatusNotFound, "Playlister not found")
             return
@@ -156,11 +78,14 @@ func GetPlaylister(w http.ResponseWriter, r *http.Request) {
     }
 
     log.Printf("Successfully retrieved playlister with ID: %d", id)
-    util.RespondWithJSON(w, http.StatusOK, p)
+    if notModified(w, r, p.Version) {
+        return
+    }
+    respondWithView(w, r, http.StatusOK, p, v)
     log.Println("GetPlaylister function completed")
 }
 
-func CreatePlaylister(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) CreatePlaylister(w http.ResponseWriter, r *http.Request) {
     var playlister models.Playlister
     decoder := json.NewDecoder(r.Body)
     if err := decoder.Decode(&playlister); err != nil {
@@ -169,27 +94,33 @@ func CreatePlaylister(w http.ResponseWriter, r *http.Request) {
     }
     defer r.Body.Close()
 
+    playlister.Normalize(contact.DefaultRegion())
     if err := validation.ValidateStruct(playlister); err != nil {
         errors.HandleError(w, err, http.StatusBadRequest, "Validation error")
         return
     }
 
-    playlisterMutex.Lock()
-    playlister.ID = playlisterID
-    playlisters[playlisterID] = playlister
-    playlisterID++
-    playlisterMutex.Unlock()
+    if err := h.store.Playlisters.Create(r.Context(), &playlister); err != nil {
+        errors.HandleStoreError(w, err, "Error creating playlister")
+        return
+    }
 
+    log.Printf("Created playlister with ID: %d", playlister.ID)
+    setETag(w, playlister.Version)
     util.RespondWithJSON(w, http.StatusCreated, playlister)
 }
 
-func UpdatePlaylister(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) UpdatePlaylister(w http.ResponseWriter, r *http.Request) {
     vars := mux.Vars(r)
     id, err := strconv.Atoi(vars["id"])
     if err != nil {
         errors.HandleError(w, err, http.StatusBadRequest, "Invalid playlister ID")
         return
     }
+    version, ok := ifMatch(w, r)
+    if !ok {
+        return
+    }
 
     var playlister models.Playlister
     decoder := json.NewDecoder(r.Body)
@@ -199,42 +130,109 @@ func UpdatePlaylister(w http.ResponseWriter, r *http.Request) {
     }
     defer r.Body.Close()
 
+    playlister.Normalize(contact.DefaultRegion())
     // Validate the updated playlister data
     if err := validation.ValidateStruct(playlister); err != nil {
         errors.HandleError(w, err, http.StatusBadRequest, "Validation error")
         return
     }
 
-    playlisterMutex.Lock()
-    defer playlisterMutex.Unlock()
+    playlister.ID = id
+    playlister.Version = version
+    if err := h.store.Playlisters.Update(r.Context(), &playlister); err != nil {
+        if err == store.ErrNotFound {
+            util.RespondWithError(w, http.StatusNotFound, "Playlister not found")
+            return
+        }
+        errors.HandleStoreError(w, err, "Error updating playlister")
+        return
+    }
+
+    setETag(w, playlister.Version)
+    util.RespondWithJSON(w, http.StatusOK, playlister)
+}
+
+// PatchPlaylister applies a JSON Merge Patch to a playlister. Keys missing from the
+// patch keep their current value and null clears a nullable column; only the
+// merged result is validated.
+func (h *Handler) PatchPlaylister(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    id, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        errors.HandleError(w, err, http.StatusBadRequest, "Invalid playlister ID")
+        return
+    }
+    version, ok := ifMatch(w, r)
+    if !ok {
+        return
+    }
+
+    current, err := h.store.Playlisters.Get(r.Context(), id)
+    if err == nil && current.DeletedAt.Valid {
+        err = store.ErrNotFound
+    }
+    if err != nil {
+        if err == store.ErrNotFound {
+            util.RespondWithError(w, http.StatusNotFound, "Playlister not found")
+            return
+        }
+        errors.HandleStoreError(w, err, "Error retrieving playlister")
+        return
+    }
+    if !checkVersion(w, version, current.Version) {
+        return
+    }
 
-    if _, found := playlisters[id]; !found {
-        util.RespondWithError(w, http.StatusNotFound, "Playlister not found")
+    playlister, ok := applyPatch(w, r, current)
+    if !ok {
+        return
+    }
+    // The update is conditional on the version the patch was applied to.
+    playlister.Version = current.Version
+    if playlister.ID != id {
+        util.RespondWithError(w, http.StatusBadRequest, "Playlister ID in URL must match payload")
         return
     }
 
-    playlister.ID = id
-    playlisters[id] = playlister
+    playlister.Normalize(contact.DefaultRegion())
+    if err := validation.ValidateStruct(playlister); err != nil {
+        errors.HandleError(w, err, http.StatusBadRequest, "Validation error")
+        return
+    }
+
+    if err := h.store.Playlisters.Update(r.Context(), &playlister); err != nil {
+        if err == store.ErrNotFound {
+            util.RespondWithError(w, http.StatusNotFound, "Playlister not found")
+            return
+        }
+        errors.HandleStoreError(w, err, "Error updating playlister")
+        return
+    }
 
+    setETag(w, playlister.Version)
     util.RespondWithJSON(w, http.StatusOK, playlister)
 }
-func DeletePlaylister(w http.ResponseWriter, r *http.Request) {
+
+func (h *Handler) DeletePlaylister(w http.ResponseWriter, r *http.Request) {
     vars := mux.Vars(r)
     id, err := strconv.Atoi(vars["id"])
     if err != nil {
         util.RespondWithError(w, http.StatusBadRequest, "Invalid playlister ID")
         return
     }
-
-    playlisterMutex.Lock()
-    defer playlisterMutex.Unlock()
-
-    if _, found := playlisters[id]; !found {
-        util.RespondWithError(w, http.StatusNotFound, "Playlister not found")
+    version, ok := ifMatch(w, r)
+    if !ok {
         return
     }
 
-    delete(playlisters, id)
+    if err := h.store.Playlisters.Delete(r.Context(), id, version); err != nil {
+        if err == store.ErrNotFound {
+            util.RespondWithError(w, http.StatusNotFound, "Playlister not found")
+            return
+        }
+        errors.HandleStoreError(w, err, "Error deleting playlister")
+        return
+    }
 
     util.RespondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
 }
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/alanowatson/LeadGenAPI/internal/pagination"
+	"github.com/alanowatson/LeadGenAPI/internal/query"
+	"github.com/alanowatson/LeadGenAPI/internal/spotify"
+	"github.com/alanowatson/LeadGenAPI/internal/store"
+	"github.com/alanowatson/LeadGenAPI/pkg/util"
+)
+
+// maxResolvedCampaigns caps how many campaigns sharing one Spotify link a
+// lookup returns.
+const maxResolvedCampaigns = 100
+
+// resolved is one live record a Spotify identifier points at.
+type resolved struct {
+    Type string      `json:"type"`
+    ID   int         `json:"id"`
+    Data interface{} `json:"data"`
+}
+
+// Resolve finds the live playlists, playlisters and campaigns a pasted
+// Spotify link, URI or bare ID refers to. Playlist links match on
+// playlistspotifyid, user links on spotifyuserid and any typed link on a
+// campaign's spotify_link; a bare ID is tried as a playlist and as a user.
+func (h *Handler) Resolve(w http.ResponseWriter, r *http.Request) {
+    raw := r.URL.Query().Get("url")
+    if raw == "" {
+        util.RespondWithError(w, http.StatusBadRequest, "Query parameter url is required")
+        return
+    }
+    id, err := spotify.Parse(raw)
+    if err != nil {
+        util.RespondWithError(w, http.StatusBadRequest, err.Error())
+        return
+    }
+
+    matches, err := h.resolve(r.Context(), id)
+    if err != nil {
+        log.Printf("Error resolving %q: %v", raw, err)
+        util.RespondWithError(w, http.StatusInternalServerError, "Error resolving Spotify link")
+        return
+    }
+    if len(matches) == 0 {
+        util.RespondWithError(w, http.StatusNotFound, "No record matches this Spotify link")
+        return
+    }
+    util.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+        "spotify": id,
+        "matches": matches,
+    })
+}
+
+func (h *Handler) resolve(ctx context.Context, id spotify.ID) ([]resolved, error) {
+    matches := []resolved{}
+
+    if id.Type == spotify.Playlist || id.Type == "" {
+        if playlistID, err := spotify.ParseAs(id.ID, spotify.Playlist); err == nil {
+            playlists, err := liveMatches(ctx, h.store.Playlists.List, store.PlaylistSchema, "playlistspotifyid", playlistID.ID, 1)
+            if err != nil {
+                return nil, err
+            }
+            for _, p := range playlists {
+                matches = append(matches, resolved{Type: store.TypePlaylist, ID: p.ID, Data: p})
+            }
+        }
+    }
+    if id.Type == spotify.User || id.Type == "" {
+        if userID, err := spotify.ParseAs(id.ID, spotify.User); err == nil {
+            playlisters, err := liveMatches(ctx, h.store.Playlisters.List, store.PlaylisterSchema, "spotifyuserid", userID.ID, 1)
+            if err != nil {
+                return nil, err
+            }
+            for _, p := range playlisters {
+                matches = append(matches, resolved{Type: store.TypePlaylister, ID: p.ID, Data: p})
+            }
+        }
+    }
+    if id.Type != "" {
+        campaigns, err := liveMatches(ctx, h.store.Campaigns.List, store.CampaignSchema, "spotify_link", id.URL(), maxResolvedCampaigns)
+        if err != nil {
+            return nil, err
+        }
+        for _, c := range campaigns {
+            matches = append(matches, resolved{Type: store.TypeCampaign, ID: c.ID, Data: c})
+        }
+    }
+    return matches, nil
+}
+
+// liveMatches lists up to limit live records whose field equals value.
+func liveMatches[T any](ctx context.Context, list func(context.Context, store.ListOptions) (store.Page[T], error),
+    schema query.Schema, field, value string, limit int) ([]T, error) {
+    page, err := list(ctx, store.ListOptions{
+        Query: query.Spec{Filters: []query.Filter{schema.Equals(field, value)}},
+        Page:  pagination.PaginationParams{Page: 1, PerPage: limit, SkipCount: true},
+    })
+    return page.Items, err
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+    "log"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "github.com/alanowatson/LeadGenAPI/internal/search"
+    "github.com/alanowatson/LeadGenAPI/internal/store"
+    "github.com/alanowatson/LeadGenAPI/pkg/util"
+)
+
+const (
+    defaultSearchLimit = 20
+    maxSearchLimit     = 100
+)
+
+var searchTypes = []string{store.TypePlaylister, store.TypePlaylist, store.TypeCampaign}
+
+// Search ranks playlisters, playlists and campaigns against the q parameter.
+// The optional type parameter narrows the resources searched, e.g.
+// type=playlister,playlist.
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+    log.Println("Search function called")
+
+    q := r.URL.Query().Get("q")
+    terms := search.Terms(q)
+    if len(terms) == 0 {
+        util.RespondWithError(w, http.StatusBadRequest, "Query parameter q is required")
+        return
+    }
+
+    types := searchTypes
+    if raw := r.URL.Query().Get("type"); raw != "" {
+        types = nil
+        for _, t := range strings.Split(raw, ",") {
+            t = strings.TrimSpace(t)
+            if !isSearchType(t) {
+                util.RespondWithError(w, http.StatusBadRequest, "Unknown search type: "+t)
+                return
+            }
+            types = append(types, t)
+        }
+    }
+
+    limit := defaultSearchLimit
+    if raw := r.URL.Query().Get("limit"); raw != "" {
+        n, err := strconv.Atoi(raw)
+        if err != nil || n < 1 {
+            util.RespondWithError(w, http.StatusBadRequest, "Invalid limit")
+            return
+        }
+        if n > maxSearchLimit {
+            n = maxSearchLimit
+        }
+        limit = n
+    }
+
+    results, err := h.store.Search.Search(r.Context(), terms, types, limit)
+    if err != nil {
+        log.Printf("Error searching: %v", err)
+        util.RespondWithError(w, http.StatusInternalServerError, "Error searching")
+        return
+    }
+    if results == nil {
+        results = []store.SearchResult{}
+    }
+
+    log.Printf("Search for %q returned %d results", q, len(results))
+    util.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+        "query": q,
+        "data":  results,
+    })
+}
+
+func isSearchType(t string) bool {
+    for _, known := range searchTypes {
+        if t == known {
+            return true
+        }
+    }
+    return false
+}
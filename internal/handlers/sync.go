@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/alanowatson/LeadGenAPI/internal/errors"
+	"github.com/alanowatson/LeadGenAPI/internal/playlistsync"
+	"github.com/alanowatson/LeadGenAPI/internal/spotify"
+	"github.com/alanowatson/LeadGenAPI/internal/store"
+	"github.com/alanowatson/LeadGenAPI/pkg/util"
+	"github.com/gorilla/mux"
+)
+
+// SyncPlaylist refreshes a playlist's name and follower count from Spotify
+// right away, or marks it as deleted there, and returns the updated playlist.
+// While Spotify is rate limiting it answers 503 with a Retry-After header.
+func (h *Handler) SyncPlaylist(w http.ResponseWriter, r *http.Request) {
+    id, err := strconv.Atoi(mux.Vars(r)["id"])
+    if err != nil {
+        util.RespondWithError(w, http.StatusBadRequest, "Invalid playlist ID")
+        return
+    }
+    if h.syncer == nil {
+        util.RespondWithError(w, http.StatusServiceUnavailable, "Spotify sync is not configured")
+        return
+    }
+
+    p, err := h.store.Playlists.Get(r.Context(), id)
+    if err == nil && p.DeletedAt.Valid {
+        err = store.ErrNotFound
+    }
+    if err == nil {
+        err = h.syncer.Sync(r.Context(), p)
+    }
+
+    if err == store.ErrNotFound {
+        util.RespondWithError(w, http.StatusNotFound, "Playlist not found")
+        return
+    }
+    if err != nil {
+        handleSyncError(w, err, "Error syncing playlist")
+        return
+    }
+
+    p, err = h.store.Playlists.Get(r.Context(), id)
+    if err != nil {
+        errors.HandleStoreError(w, err, "Error retrieving playlist")
+        return
+    }
+    setETag(w, p.Version)
+    util.RespondWithJSON(w, http.StatusOK, p)
+}
+
+// handleSyncError answers a failed sync or verification: 503 with a
+// Retry-After header while Spotify is rate limiting, 502 when Spotify could
+// not be reached and otherwise as a store error.
+func handleSyncError(w http.ResponseWriter, err error, message string) {
+    var limited *spotify.RateLimitError
+    switch {
+    case stderrors.As(err, &limited):
+        w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(limited.RetryAfter.Seconds()))))
+        util.RespondWithError(w, http.StatusServiceUnavailable, "Spotify is rate limiting requests, try again later")
+    case stderrors.Is(err, playlistsync.ErrSpotify):
+        log.Printf("%s: %v", message, err)
+        util.RespondWithError(w, http.StatusBadGateway, message+" with Spotify")
+    default:
+        errors.HandleStoreError(w, err, message)
+    }
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/alanowatson/LeadGenAPI/internal/errors"
+	"github.com/alanowatson/LeadGenAPI/internal/models"
+	"github.com/alanowatson/LeadGenAPI/internal/store"
+	"github.com/alanowatson/LeadGenAPI/pkg/util"
+)
+
+// maxTransitionNote caps the note a transition can carry.
+const maxTransitionNote = 1000
+
+// TransitionPlaylistCampaign moves a placement to the status in the body,
+// {"status": "Replied", "note": "..."}, if the state machine allows it from
+// the current one, and returns the placement. If-Match guards it like an
+// update.
+func (h *Handler) TransitionPlaylistCampaign(w http.ResponseWriter, r *http.Request) {
+    playlistID, campaignID, ok := placementKey(w, r)
+    if !ok {
+        return
+    }
+    version, ok := ifMatch(w, r)
+    if !ok {
+        return
+    }
+
+    var body struct {
+        Status string `json:"status"`
+        Note   string `json:"note"`
+    }
+    if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+        errors.HandleError(w, err, http.StatusBadRequest, "Invalid request payload")
+        return
+    }
+    defer r.Body.Close()
+    if !models.ValidPlacementStatus(body.Status) {
+        util.RespondWithError(w, http.StatusBadRequest, "status must be one of "+strings.Join(models.PlacementStatuses, ", "))
+        return
+    }
+    body.Note = strings.TrimSpace(body.Note)
+    if len(body.Note) > maxTransitionNote {
+        util.RespondWithError(w, http.StatusBadRequest, "note must be at most 1000 characters")
+        return
+    }
+
+    err := h.store.PlaylistCampaigns.Transition(r.Context(), playlistID, campaignID, body.Status, body.Note, version)
+    if err != nil {
+        if err == store.ErrNotFound {
+            util.RespondWithError(w, http.StatusNotFound, "PlaylistCampaign not found")
+            return
+        }
+        errors.HandleStoreError(w, err, "Error updating placement status")
+        return
+    }
+
+    pc, err := h.store.PlaylistCampaigns.Get(r.Context(), playlistID, campaignID)
+    if err != nil {
+        errors.HandleStoreError(w, err, "Error retrieving playlist campaign")
+        return
+    }
+    log.Printf("Moved placement %d/%d to %s", playlistID, campaignID, body.Status)
+    setETag(w, pc.Version)
+    util.RespondWithJSON(w, http.StatusOK, pc)
+}
+
+// GetPlaylistCampaignTransitions lists a placement's status changes, oldest
+// first, with who made each and when.
+func (h *Handler) GetPlaylistCampaignTransitions(w http.ResponseWriter, r *http.Request) {
+    playlistID, campaignID, ok := placementKey(w, r)
+    if !ok {
+        return
+    }
+
+    transitions, err := h.store.PlaylistCampaigns.Transitions(r.Context(), playlistID, campaignID)
+    if err != nil {
+        if err == store.ErrNotFound {
+            util.RespondWithError(w, http.StatusNotFound, "PlaylistCampaign not found")
+            return
+        }
+        log.Printf("Error listing transitions of placement %d/%d: %v", playlistID, campaignID, err)
+        util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving placement transitions")
+        return
+    }
+    util.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+        "playlistid": playlistID,
+        "campaignid": campaignID,
+        "data":       transitions,
+    })
+}
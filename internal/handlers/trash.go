@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/alanowatson/LeadGenAPI/internal/errors"
+	"github.com/alanowatson/LeadGenAPI/internal/store"
+	"github.com/alanowatson/LeadGenAPI/pkg/util"
+	"github.com/gorilla/mux"
+)
+
+// hidden reports whether a record with the given DeletedAt is in the trash
+// and the request did not ask for include_deleted=true.
+func hidden(r *http.Request, deletedAt sql.NullString) bool {
+    if !deletedAt.Valid {
+        return false
+    }
+    include, _ := strconv.ParseBool(r.URL.Query().Get("include_deleted"))
+    return !include
+}
+
+// trashID parses the {id} route variable of a restore or purge route,
+// responding with 400 and returning false when it is malformed.
+func trashID(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+    id, err := strconv.Atoi(mux.Vars(r)["id"])
+    if err != nil {
+        util.RespondWithError(w, http.StatusBadRequest, "Invalid "+name+" ID")
+        return 0, false
+    }
+    return id, true
+}
+
+// trashWrite runs a restore or purge, responding with 404, 409 or 422 and
+// returning false when the store rejects it.
+func trashWrite(w http.ResponseWriter, name, message string, write func() error) bool {
+    if err := write(); err != nil {
+        if err == store.ErrNotFound {
+            util.RespondWithError(w, http.StatusNotFound, name+" not found")
+            return false
+        }
+        errors.HandleStoreError(w, err, message)
+        return false
+    }
+    return true
+}
+
+// RestorePlaylister takes a playlister out of the trash.
+func (h *Handler) RestorePlaylister(w http.ResponseWriter, r *http.Request) {
+    id, ok := trashID(w, r, "playlister")
+    if !ok {
+        return
+    }
+    if !trashWrite(w, "Playlister", "Error restoring playlister", func() error {
+        return h.store.Playlisters.Restore(r.Context(), id)
+    }) {
+        return
+    }
+
+    p, err := h.store.Playlisters.Get(r.Context(), id)
+    if err != nil {
+        errors.HandleStoreError(w, err, "Error retrieving playlister")
+        return
+    }
+    log.Printf("Restored playlister with ID: %d", id)
+    setETag(w, p.Version)
+    util.RespondWithJSON(w, http.StatusOK, p)
+}
+
+// RestorePlaylist takes a playlist out of the trash together with the
+// placements deleted along with it.
+func (h *Handler) RestorePlaylist(w http.ResponseWriter, r *http.Request) {
+    id, ok := trashID(w, r, "playlist")
+    if !ok {
+        return
+    }
+    if !trashWrite(w, "Playlist", "Error restoring playlist", func() error {
+        return h.store.Playlists.Restore(r.Context(), id)
+    }) {
+        return
+    }
+
+    p, err := h.store.Playlists.Get(r.Context(), id)
+    if err != nil {
+        errors.HandleStoreError(w, err, "Error retrieving playlist")
+        return
+    }
+    log.Printf("Restored playlist with ID: %d", id)
+    setETag(w, p.Version)
+    util.RespondWithJSON(w, http.StatusOK, p)
+}
+
+// RestoreCampaign takes a campaign out of the trash.
+func (h *Handler) RestoreCampaign(w http.ResponseWriter, r *http.Request) {
+    id, ok := trashID(w, r, "campaign")
+    if !ok {
+        return
+    }
+    if !trashWrite(w, "Campaign", "Error restoring campaign", func() error {
+        return h.store.Campaigns.Restore(r.Context(), id)
+    }) {
+        return
+    }
+
+    c, err := h.store.Campaigns.Get(r.Context(), id)
+    if err != nil {
+        errors.HandleStoreError(w, err, "Error retrieving campaign")
+        return
+    }
+    log.Printf("Restored campaign with ID: %d", id)
+    setETag(w, c.Version)
+    util.RespondWithJSON(w, http.StatusOK, c)
+}
+
+// RestorePlaylistCampaign takes a placement out of the trash.
+func (h *Handler) RestorePlaylistCampaign(w http.ResponseWriter, r *http.Request) {
+    playlistID, campaignID, ok := placementKey(w, r)
+    if !ok {
+        return
+    }
+    if !trashWrite(w, "PlaylistCampaign", "Error restoring PlaylistCampaign", func() error {
+        return h.store.PlaylistCampaigns.Restore(r.Context(), playlistID, campaignID)
+    }) {
+        return
+    }
+
+    pc, err := h.store.PlaylistCampaigns.Get(r.Context(), playlistID, campaignID)
+    if err != nil {
+        errors.HandleStoreError(w, err, "Error retrieving PlaylistCampaign")
+        return
+    }
+    log.Printf("Restored playlist campaign with PlaylistID: %d and CampaignID: %d", playlistID, campaignID)
+    setETag(w, pc.Version)
+    util.RespondWithJSON(w, http.StatusOK, pc)
+}
+
+// PurgePlaylister permanently removes a trashed playlister.
+func (h *Handler) PurgePlaylister(w http.ResponseWriter, r *http.Request) {
+    id, ok := trashID(w, r, "playlister")
+    if !ok {
+        return
+    }
+    if !trashWrite(w, "Playlister", "Error purging playlister", func() error {
+        return h.store.Playlisters.Purge(r.Context(), id)
+    }) {
+        return
+    }
+
+    log.Printf("Purged playlister with ID: %d", id)
+    util.RespondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
+
+// PurgePlaylist permanently removes a trashed playlist and its placements.
+func (h *Handler) PurgePlaylist(w http.ResponseWriter, r *http.Request) {
+    id, ok := trashID(w, r, "playlist")
+    if !ok {
+        return
+    }
+    if !trashWrite(w, "Playlist", "Error purging playlist", func() error {
+        return h.store.Playlists.Purge(r.Context(), id)
+    }) {
+        return
+    }
+
+    log.Printf("Purged playlist with ID: %d", id)
+    util.RespondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
+
+// PurgeCampaign permanently removes a trashed campaign.
+func (h *Handler) PurgeCampaign(w http.ResponseWriter, r *http.Request) {
+    id, ok := trashID(w, r, "campaign")
+    if !ok {
+        return
+    }
+    if !trashWrite(w, "Campaign", "Error purging campaign", func() error {
+        return h.store.Campaigns.Purge(r.Context(), id)
+    }) {
+        return
+    }
+
+    log.Printf("Purged campaign with ID: %d", id)
+    util.RespondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
+
+// PurgePlaylistCampaign permanently removes a trashed placement.
+func (h *Handler) PurgePlaylistCampaign(w http.ResponseWriter, r *http.Request) {
+    playlistID, campaignID, ok := placementKey(w, r)
+    if !ok {
+        return
+    }
+    if !trashWrite(w, "PlaylistCampaign", "Error purging PlaylistCampaign", func() error {
+        return h.store.PlaylistCampaigns.Purge(r.Context(), playlistID, campaignID)
+    }) {
+        return
+    }
+
+    log.Printf("Purged playlist campaign with PlaylistID: %d and CampaignID: %d", playlistID, campaignID)
+    util.RespondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
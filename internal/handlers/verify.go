@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/alanowatson/LeadGenAPI/internal/errors"
+	"github.com/alanowatson/LeadGenAPI/internal/models"
+	"github.com/alanowatson/LeadGenAPI/internal/playlistsync"
+	"github.com/alanowatson/LeadGenAPI/internal/query"
+	"github.com/alanowatson/LeadGenAPI/internal/store"
+	"github.com/alanowatson/LeadGenAPI/pkg/util"
+	"github.com/gorilla/mux"
+)
+
+// verificationSummary counts a campaign's live placements by what their
+// last verification found.
+type verificationSummary struct {
+    Placements int `json:"placements"`
+    Placed     int `json:"placed"`
+    Verified   int `json:"verified"`
+    Present    int `json:"present"`
+    Missing    int `json:"missing"`
+    Removed    int `json:"removed"`
+    Flagged    int `json:"flagged"`
+}
+
+func (s *verificationSummary) add(pc models.PlaylistCampaign) {
+    s.Placements++
+    if pc.PlacementStatus.String == models.PlacementPlaced {
+        s.Placed++
+    }
+    if pc.TrackPresent.Valid {
+        s.Verified++
+        if pc.TrackPresent.Bool {
+            s.Present++
+        } else {
+            s.Missing++
+        }
+    }
+    if pc.TrackRemovedAt.Valid {
+        s.Removed++
+    }
+    if pc.Flagged() {
+        s.Flagged++
+    }
+}
+
+// VerifyPlacement checks the placement's playlist for its campaign's track
+// right away and returns the placement with the result.
+func (h *Handler) VerifyPlacement(w http.ResponseWriter, r *http.Request) {
+    playlistID, campaignID, ok := placementKey(w, r)
+    if !ok {
+        return
+    }
+    if h.syncer == nil {
+        util.RespondWithError(w, http.StatusServiceUnavailable, "Spotify sync is not configured")
+        return
+    }
+
+    pc, err := h.store.PlaylistCampaigns.Get(r.Context(), playlistID, campaignID)
+    if err == nil && pc.DeletedAt.Valid {
+        err = store.ErrNotFound
+    }
+    if err == store.ErrNotFound {
+        util.RespondWithError(w, http.StatusNotFound, "PlaylistCampaign not found")
+        return
+    }
+    if err != nil {
+        errors.HandleStoreError(w, err, "Error retrieving playlist campaign")
+        return
+    }
+    if _, ok := h.promotedTrack(w, r.Context(), campaignID); !ok {
+        return
+    }
+
+    if err := h.syncer.Verify(r.Context(), []models.PlaylistCampaign{pc}); err != nil {
+        handleSyncError(w, err, "Error verifying placement")
+        return
+    }
+
+    pc, err = h.store.PlaylistCampaigns.Get(r.Context(), playlistID, campaignID)
+    if err != nil {
+        errors.HandleStoreError(w, err, "Error retrieving playlist campaign")
+        return
+    }
+    setETag(w, pc.Version)
+    util.RespondWithJSON(w, http.StatusOK, pc)
+}
+
+// VerifyCampaign checks every live placement of a campaign right away and
+// returns the campaign's verification report.
+func (h *Handler) VerifyCampaign(w http.ResponseWriter, r *http.Request) {
+    if h.syncer == nil {
+        util.RespondWithError(w, http.StatusServiceUnavailable, "Spotify sync is not configured")
+        return
+    }
+    h.campaignVerification(w, r, true)
+}
+
+// GetCampaignVerification reports what the last verification of each live
+// placement of a campaign found, with totals: how many placements were
+// verified, carry the track, miss it, lost it after it was seen, and are
+// flagged as marked Placed without the track.
+func (h *Handler) GetCampaignVerification(w http.ResponseWriter, r *http.Request) {
+    h.campaignVerification(w, r, false)
+}
+
+func (h *Handler) campaignVerification(w http.ResponseWriter, r *http.Request, verify bool) {
+    id, err := strconv.Atoi(mux.Vars(r)["id"])
+    if err != nil {
+        util.RespondWithError(w, http.StatusBadRequest, "Invalid campaign ID")
+        return
+    }
+    track, ok := h.promotedTrack(w, r.Context(), id)
+    if !ok {
+        return
+    }
+
+    if verify {
+        placements, err := h.campaignPlacements(r.Context(), id)
+        if err != nil {
+            log.Printf("Error listing placements for campaign %d: %v", id, err)
+            util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving playlist campaigns")
+            return
+        }
+        if err := h.syncer.Verify(r.Context(), placements); err != nil {
+            handleSyncError(w, err, "Error verifying placements")
+            return
+        }
+    }
+
+    placements, err := h.campaignPlacements(r.Context(), id)
+    if err != nil {
+        log.Printf("Error listing placements for campaign %d: %v", id, err)
+        util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving playlist campaigns")
+        return
+    }
+    var summary verificationSummary
+    for _, pc := range placements {
+        summary.add(pc)
+    }
+    util.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+        "campaignid": id,
+        "track":      track,
+        "summary":    summary,
+        "data":       placements,
+    })
+}
+
+// promotedTrack loads the live campaign id and returns the track it
+// promotes, responding with 404 or 422 and returning false when there is
+// none.
+func (h *Handler) promotedTrack(w http.ResponseWriter, ctx context.Context, id int) (string, bool) {
+    c, err := h.store.Campaigns.Get(ctx, id, "spotify_link")
+    if err == nil && c.DeletedAt.Valid {
+        err = store.ErrNotFound
+    }
+    if err == store.ErrNotFound {
+        util.RespondWithError(w, http.StatusNotFound, "Campaign not found")
+        return "", false
+    }
+    if err != nil {
+        errors.HandleStoreError(w, err, "Error retrieving campaign")
+        return "", false
+    }
+    track, err := playlistsync.PromotedTrack(c)
+    if err != nil {
+        util.RespondWithError(w, http.StatusUnprocessableEntity, "Campaign spotify_link must be a Spotify track to verify placements")
+        return "", false
+    }
+    return track, true
+}
+
+// campaignPlacements lists every live placement of a campaign by playlist.
+func (h *Handler) campaignPlacements(ctx context.Context, id int) ([]models.PlaylistCampaign, error) {
+    placements := []models.PlaylistCampaign{}
+    opts := store.ListOptions{
+        Query: query.Spec{Filters: []query.Filter{store.PlaylistCampaignSchema.Equals("campaignid", id)}},
+    }
+    err := h.store.PlaylistCampaigns.Each(ctx, opts, func(pc models.PlaylistCampaign) error {
+        placements = append(placements, pc)
+        return nil
+    })
+    sort.Slice(placements, func(i, j int) bool { return placements[i].PlaylistID < placements[j].PlaylistID })
+    return placements, err
+}
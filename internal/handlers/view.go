@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/alanowatson/LeadGenAPI/internal/models"
+	"github.com/alanowatson/LeadGenAPI/internal/pagination"
+	"github.com/alanowatson/LeadGenAPI/internal/query"
+	"github.com/alanowatson/LeadGenAPI/internal/store"
+	"github.com/alanowatson/LeadGenAPI/pkg/util"
+)
+
+// relation is a related resource of T that can be embedded with include=.
+type relation[T any] struct {
+    name string
+    // field is T's schema field holding the related record's ID.
+    field string
+    // id returns the related record's ID for one item.
+    id func(T) int
+    // load fetches the related records for a batch of IDs.
+    load func(ctx context.Context, ids []int) (map[int]interface{}, error)
+}
+
+// loader adapts a repository's GetMany to relation.load.
+func loader[V any](getMany func(context.Context, []int) (map[int]V, error)) func(context.Context, []int) (map[int]interface{}, error) {
+    return func(ctx context.Context, ids []int) (map[int]interface{}, error) {
+        found, err := getMany(ctx, ids)
+        if err != nil {
+            return nil, err
+        }
+        loaded := make(map[int]interface{}, len(found))
+        for id, v := range found {
+            loaded[id] = v
+        }
+        return loaded, nil
+    }
+}
+
+func (h *Handler) playlistRelations() []relation[models.Playlist] {
+    return []relation[models.Playlist]{
+        {"playlister", "playlisterid", func(p models.Playlist) int { return p.PlaylisterId }, loader(h.store.Playlisters.GetMany)},
+    }
+}
+
+func (h *Handler) placementRelations() []relation[models.PlaylistCampaign] {
+    return []relation[models.PlaylistCampaign]{
+        {"playlist", "playlistid", func(pc models.PlaylistCampaign) int { return pc.PlaylistID }, loader(h.store.Playlists.GetMany)},
+        {"campaign", "campaignid", func(pc models.PlaylistCampaign) int { return pc.CampaignID }, loader(h.store.Campaigns.GetMany)},
+        {"playlister", "playlisterid", func(pc models.PlaylistCampaign) int { return pc.PlaylisterId }, loader(h.store.Playlisters.GetMany)},
+    }
+}
+
+// view is how a read endpoint renders its items: which of their own fields
+// are kept and which relations are embedded.
+type view[T any] struct {
+    // fields lists the schema fields to keep; nil keeps them all.
+    fields []string
+    rels   []relation[T]
+}
+
+// parseView reads the fields and include parameters, responding with 400 and
+// returning false when either names something the resource does not have.
+func parseView[T any](w http.ResponseWriter, r *http.Request, schema query.Schema, available []relation[T]) (view[T], bool) {
+    var v view[T]
+    params := r.URL.Query()
+
+    if params.Has("fields") {
+        v.fields = []string{}
+        for _, name := range splitList(params.Get("fields")) {
+            if _, ok := schema.Field(name); !ok {
+                util.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Unknown field %q in fields (supported: %s)", name, strings.Join(schema.FieldNames(), ", ")))
+                return view[T]{}, false
+            }
+            v.fields = append(v.fields, name)
+        }
+        if len(v.fields) == 0 {
+            util.RespondWithError(w, http.StatusBadRequest, "fields must name at least one field")
+            return view[T]{}, false
+        }
+    }
+
+    for _, name := range splitList(params.Get("include")) {
+        found := false
+        for _, rel := range available {
+            if rel.name == name {
+                v.rels = append(v.rels, rel)
+                found = true
+                break
+            }
+        }
+        if !found {
+            names := make([]string, len(available))
+            for i, rel := range available {
+                names[i] = rel.name
+            }
+            sort.Strings(names)
+            util.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Unsupported include path %q (supported: %s)", name, strings.Join(names, ", ")))
+            return view[T]{}, false
+        }
+    }
+    return v, true
+}
+
+// splitList splits a comma separated parameter, dropping blanks and repeats.
+func splitList(raw string) []string {
+    var items []string
+    seen := make(map[string]bool)
+    for _, item := range strings.Split(raw, ",") {
+        item = strings.TrimSpace(item)
+        if item == "" || seen[item] {
+            continue
+        }
+        seen[item] = true
+        items = append(items, item)
+    }
+    return items
+}
+
+// load returns the fields the repository has to load to render the view, or
+// nil for all of them.
+func (v view[T]) load() []string {
+    if v.fields == nil {
+        return nil
+    }
+    fields := append([]string(nil), v.fields...)
+    for _, rel := range v.rels {
+        fields = append(fields, rel.field)
+    }
+    return fields
+}
+
+// plain reports whether items can be written as they are.
+func (v view[T]) plain() bool {
+    return v.fields == nil && len(v.rels) == 0
+}
+
+// document is a resource rendered as a JSON object, so that fields can be
+// dropped and related resources embedded next to the rest.
+type document map[string]interface{}
+
+// render turns items into documents holding the view's fields, with each
+// relation embedded under its name. Every relation is loaded with one batched
+// call; a related record that no longer exists is embedded as null.
+func render[T any](ctx context.Context, items []T, v view[T]) ([]document, error) {
+    docs := make([]document, len(items))
+    for i, item := range items {
+        raw, err := json.Marshal(item)
+        if err != nil {
+            return nil, err
+        }
+        var fields map[string]json.RawMessage
+        if err := json.Unmarshal(raw, &fields); err != nil {
+            return nil, err
+        }
+        docs[i] = make(document, len(fields)+len(v.rels))
+        if v.fields == nil {
+            for k, f := range fields {
+                docs[i][k] = f
+            }
+        } else {
+            for _, k := range v.fields {
+                docs[i][k] = fields[k]
+            }
+        }
+    }
+
+    for _, rel := range v.rels {
+        seen := make(map[int]bool)
+        var ids []int
+        for _, item := range items {
+            if id := rel.id(item); !seen[id] {
+                seen[id] = true
+                ids = append(ids, id)
+            }
+        }
+
+        loaded, err := rel.load(ctx, ids)
+        if err != nil {
+            return nil, fmt.Errorf("error loading %s: %w", rel.name, err)
+        }
+        for i, item := range items {
+            docs[i][rel.name] = loaded[rel.id(item)]
+        }
+    }
+    return docs, nil
+}
+
+// respondWithView writes a single item rendered through v.
+func respondWithView[T any](w http.ResponseWriter, r *http.Request, code int, item T, v view[T]) {
+    if v.plain() {
+        util.RespondWithJSON(w, code, item)
+        return
+    }
+
+    docs, err := render(r.Context(), []T{item}, v)
+    if err != nil {
+        log.Printf("Error rendering response: %v", err)
+        util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving included resources")
+        return
+    }
+    util.RespondWithJSON(w, code, docs[0])
+}
+
+// respondWithViewPage is respondWithPage with every item rendered through v.
+func respondWithViewPage[T any](w http.ResponseWriter, r *http.Request, page store.Page[T], params pagination.PaginationParams, v view[T]) {
+    if v.plain() {
+        respondWithPage(w, r, page, params)
+        return
+    }
+
+    docs, err := render(r.Context(), page.Items, v)
+    if err != nil {
+        log.Printf("Error rendering response: %v", err)
+        util.RespondWithError(w, http.StatusInternalServerError, "Error retrieving included resources")
+        return
+    }
+    respondWithPage(w, r, store.Page[document]{Items: docs, TotalItems: page.TotalItems, NextCursor: page.NextCursor}, params)
+}
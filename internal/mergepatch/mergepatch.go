@@ -0,0 +1,53 @@
+// Package mergepatch implements JSON Merge Patch as defined by RFC 7396.
+package mergepatch
+
+import (
+    "encoding/json"
+    "errors"
+)
+
+// MediaType is the content type of a merge patch document.
+const MediaType = "application/merge-patch+json"
+
+// ErrNotObject is returned when a patch is valid JSON but not an object.
+// RFC 7396 allows such patches, but they replace the whole target, which no
+// resource endpoint accepts.
+var ErrNotObject = errors.New("merge patch must be a JSON object")
+
+// Apply merges patch into the JSON object doc and returns the result. Keys
+// set to null in the patch are removed, nested objects are merged
+// recursively and every other value replaces the target's.
+func Apply(doc, patch []byte) ([]byte, error) {
+    var p interface{}
+    if err := json.Unmarshal(patch, &p); err != nil {
+        return nil, err
+    }
+    patchObj, ok := p.(map[string]interface{})
+    if !ok {
+        return nil, ErrNotObject
+    }
+
+    var target map[string]interface{}
+    if err := json.Unmarshal(doc, &target); err != nil {
+        return nil, err
+    }
+    return json.Marshal(merge(target, patchObj))
+}
+
+func merge(target interface{}, patch map[string]interface{}) map[string]interface{} {
+    obj, ok := target.(map[string]interface{})
+    if !ok || obj == nil {
+        obj = make(map[string]interface{})
+    }
+    for key, value := range patch {
+        switch v := value.(type) {
+        case nil:
+            delete(obj, key)
+        case map[string]interface{}:
+            obj[key] = merge(obj[key], v)
+        default:
+            obj[key] = v
+        }
+    }
+    return obj
+}
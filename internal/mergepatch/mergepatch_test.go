@@ -0,0 +1,61 @@
+package mergepatch
+
+import (
+    "encoding/json"
+    "errors"
+    "reflect"
+    "testing"
+)
+
+func TestApply(t *testing.T) {
+    tests := []struct {
+        name    string
+        doc     string
+        patch   string
+        want    string
+        wantErr error
+    }{
+        {"replace", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`, nil},
+        {"add", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`, nil},
+        {"remove", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`, nil},
+        {"remove missing", `{"a":"b"}`, `{"c":null}`, `{"a":"b"}`, nil},
+        {"replace array", `{"a":[1,2]}`, `{"a":[3]}`, `{"a":[3]}`, nil},
+        {"merge nested", `{"a":{"b":1,"c":2}}`, `{"a":{"b":null,"d":3}}`, `{"a":{"c":2,"d":3}}`, nil},
+        {"object over scalar", `{"a":"b"}`, `{"a":{"c":null,"d":1}}`, `{"a":{"d":1}}`, nil},
+        {"scalar over object", `{"a":{"b":1}}`, `{"a":1}`, `{"a":1}`, nil},
+        {"empty patch", `{"a":"b"}`, `{}`, `{"a":"b"}`, nil},
+        {"null document", `null`, `{"a":1}`, `{"a":1}`, nil},
+        {"array patch", `{"a":"b"}`, `["c"]`, ``, ErrNotObject},
+        {"null patch", `{"a":"b"}`, `null`, ``, ErrNotObject},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := Apply([]byte(tt.doc), []byte(tt.patch))
+            if tt.wantErr != nil {
+                if !errors.Is(err, tt.wantErr) {
+                    t.Fatalf("Apply(%s, %s) error = %v, want %v", tt.doc, tt.patch, err, tt.wantErr)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("Apply(%s, %s) error = %v", tt.doc, tt.patch, err)
+            }
+            var gotValue, wantValue interface{}
+            if err := json.Unmarshal(got, &gotValue); err != nil {
+                t.Fatal(err)
+            }
+            if err := json.Unmarshal([]byte(tt.want), &wantValue); err != nil {
+                t.Fatal(err)
+            }
+            if !reflect.DeepEqual(gotValue, wantValue) {
+                t.Errorf("Apply(%s, %s) = %s, want %s", tt.doc, tt.patch, got, tt.want)
+            }
+        })
+    }
+
+    for _, invalid := range [][2]string{{`{"a":`, `{}`}, {`{}`, `{"a":`}} {
+        if _, err := Apply([]byte(invalid[0]), []byte(invalid[1])); err == nil {
+            t.Errorf("Apply(%s, %s) succeeded, want a syntax error", invalid[0], invalid[1])
+        }
+    }
+}
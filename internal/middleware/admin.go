@@ -0,0 +1,36 @@
+package middleware
+
+import (
+    "net/http"
+    "os"
+    "strings"
+
+    "github.com/alanowatson/LeadGenAPI/internal/audit"
+    "github.com/alanowatson/LeadGenAPI/pkg/util"
+)
+
+// AdminMiddleware restricts a route to the users named in the comma-separated
+// ADMIN_USERNAMES variable, "admin" by default. It identifies the user through
+// the actor AuthMiddleware stores, so it must be wrapped inside it.
+func AdminMiddleware(next http.HandlerFunc) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if !isAdmin(audit.Actor(r.Context())) {
+            util.RespondWithError(w, http.StatusForbidden, "Admin access required")
+            return
+        }
+        next.ServeHTTP(w, r)
+    }
+}
+
+func isAdmin(username string) bool {
+    admins := os.Getenv("ADMIN_USERNAMES")
+    if admins == "" {
+        admins = "admin"
+    }
+    for _, name := range strings.Split(admins, ",") {
+        if strings.TrimSpace(name) == username {
+            return true
+        }
+    }
+    return false
+}
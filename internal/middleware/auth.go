@@ -9,6 +9,7 @@ import (
     "github.com/joho/godotenv"
     "os"
 
+	"github.com/alanowatson/LeadGenAPI/internal/audit"
 	"github.com/alanowatson/LeadGenAPI/pkg/util"
 	"github.com/dgrijalva/jwt-go"
 )
@@ -56,6 +57,14 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
         }
 
         log.Printf("Token is valid")
+
+        // Writes made while serving the request are attributed to the user
+        // the token was issued to.
+        if claims, ok := token.Claims.(jwt.MapClaims); ok {
+            if username, ok := claims["username"].(string); ok {
+                r = r.WithContext(audit.WithActor(r.Context(), username))
+            }
+        }
         next.ServeHTTP(w, r)
     }
 }
@@ -0,0 +1,293 @@
+// Package migrations applies the versioned SQL schema embedded in the binary.
+//
+// Each version is a pair of files under sql/ named NNNN_description.up.sql
+// and NNNN_description.down.sql. Applied versions are recorded in the
+// schema_migrations table, and every step runs in its own transaction.
+package migrations
+
+import (
+    "context"
+    "database/sql"
+    "embed"
+    "fmt"
+    "io/fs"
+    "path"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// lockID is the advisory lock key that serializes concurrent migrators.
+const lockID = 7231604
+
+type Migration struct {
+    Version int
+    Name    string
+    Up      string
+    Down    string
+}
+
+// Status describes one known migration and whether it has been applied.
+type Status struct {
+    Version   int
+    Name      string
+    AppliedAt *time.Time
+}
+
+type Migrator struct {
+    db         *sql.DB
+    migrations []Migration
+}
+
+// New loads the embedded migrations. It does not touch the database.
+func New(db *sql.DB) (*Migrator, error) {
+    migrations, err := load()
+    if err != nil {
+        return nil, err
+    }
+    return &Migrator{db: db, migrations: migrations}, nil
+}
+
+func load() ([]Migration, error) {
+    entries, err := fs.ReadDir(files, "sql")
+    if err != nil {
+        return nil, fmt.Errorf("error reading embedded migrations: %w", err)
+    }
+
+    byVersion := make(map[int]*Migration)
+    for _, entry := range entries {
+        name := entry.Name()
+        var direction string
+        switch {
+        case strings.HasSuffix(name, ".up.sql"):
+            direction = "up"
+        case strings.HasSuffix(name, ".down.sql"):
+            direction = "down"
+        default:
+            return nil, fmt.Errorf("unexpected migration file %s", name)
+        }
+
+        base := strings.TrimSuffix(name, "."+direction+".sql")
+        prefix, description, found := strings.Cut(base, "_")
+        if !found {
+            return nil, fmt.Errorf("migration file %s has no description", name)
+        }
+        version, err := strconv.Atoi(prefix)
+        if err != nil || version <= 0 {
+            return nil, fmt.Errorf("migration file %s has an invalid version", name)
+        }
+
+        body, err := files.ReadFile(path.Join("sql", name))
+        if err != nil {
+            return nil, fmt.Errorf("error reading migration %s: %w", name, err)
+        }
+
+        m, ok := byVersion[version]
+        if !ok {
+            m = &Migration{Version: version, Name: description}
+            byVersion[version] = m
+        }
+        if direction == "up" {
+            m.Up = string(body)
+        } else {
+            m.Down = string(body)
+        }
+    }
+
+    migrations := make([]Migration, 0, len(byVersion))
+    for _, m := range byVersion {
+        if m.Up == "" || m.Down == "" {
+            return nil, fmt.Errorf("migration %04d is missing its up or down file", m.Version)
+        }
+        migrations = append(migrations, *m)
+    }
+    sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+    for i, m := range migrations {
+        if m.Version != i+1 {
+            return nil, fmt.Errorf("migration versions must be contiguous from 1; found %04d at position %d", m.Version, i+1)
+        }
+    }
+    return migrations, nil
+}
+
+// Latest returns the highest version embedded in this build.
+func (m *Migrator) Latest() int {
+    return len(m.migrations)
+}
+
+// Current returns the highest applied version, or 0 for an empty database.
+func (m *Migrator) Current(ctx context.Context) (int, error) {
+    if err := m.ensureTable(ctx, m.db); err != nil {
+        return 0, err
+    }
+    var version int
+    err := m.db.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+    if err != nil {
+        return 0, fmt.Errorf("error reading schema version: %w", err)
+    }
+    return version, nil
+}
+
+// Status lists every embedded migration alongside when it was applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+    if err := m.ensureTable(ctx, m.db); err != nil {
+        return nil, err
+    }
+
+    rows, err := m.db.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+    if err != nil {
+        return nil, fmt.Errorf("error reading schema_migrations: %w", err)
+    }
+    defer rows.Close()
+
+    applied := make(map[int]time.Time)
+    for rows.Next() {
+        var version int
+        var appliedAt time.Time
+        if err := rows.Scan(&version, &appliedAt); err != nil {
+            return nil, fmt.Errorf("error scanning schema_migrations: %w", err)
+        }
+        applied[version] = appliedAt
+    }
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("error reading schema_migrations: %w", err)
+    }
+
+    statuses := make([]Status, 0, len(m.migrations))
+    for _, migration := range m.migrations {
+        s := Status{Version: migration.Version, Name: migration.Name}
+        if at, ok := applied[migration.Version]; ok {
+            s.AppliedAt = &at
+        }
+        statuses = append(statuses, s)
+    }
+    return statuses, nil
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up(ctx context.Context) error {
+    return m.To(ctx, m.Latest())
+}
+
+// Down reverts the given number of applied migrations, newest first.
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+    current, err := m.Current(ctx)
+    if err != nil {
+        return err
+    }
+    target := current - steps
+    if target < 0 {
+        target = 0
+    }
+    return m.To(ctx, target)
+}
+
+// To migrates up or down until the schema is exactly at version.
+func (m *Migrator) To(ctx context.Context, version int) error {
+    if version < 0 || version > m.Latest() {
+        return fmt.Errorf("unknown schema version %d (latest is %d)", version, m.Latest())
+    }
+
+    conn, err := m.db.Conn(ctx)
+    if err != nil {
+        return fmt.Errorf("error acquiring connection: %w", err)
+    }
+    defer conn.Close()
+
+    if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+        return fmt.Errorf("error acquiring migration lock: %w", err)
+    }
+    defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockID)
+
+    if err := m.ensureTable(ctx, conn); err != nil {
+        return err
+    }
+
+    var current int
+    err = conn.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current)
+    if err != nil {
+        return fmt.Errorf("error reading schema version: %w", err)
+    }
+    if current > m.Latest() {
+        return fmt.Errorf("database is at version %d, newer than this build's latest %d", current, m.Latest())
+    }
+
+    for current < version {
+        next := m.migrations[current]
+        if err := apply(ctx, conn, next.Up, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", next.Version, next.Name); err != nil {
+            return fmt.Errorf("error applying migration %04d_%s: %w", next.Version, next.Name, err)
+        }
+        current++
+    }
+
+    for current > version {
+        prev := m.migrations[current-1]
+        if err := apply(ctx, conn, prev.Down, "DELETE FROM schema_migrations WHERE version = $1 AND name = $2", prev.Version, prev.Name); err != nil {
+            return fmt.Errorf("error reverting migration %04d_%s: %w", prev.Version, prev.Name, err)
+        }
+        current--
+    }
+    return nil
+}
+
+// ErrSchemaBehind is returned by Check when migrations are pending.
+type ErrSchemaBehind struct {
+    Current int
+    Latest  int
+}
+
+func (e *ErrSchemaBehind) Error() string {
+    return fmt.Sprintf("database schema is at version %d but this build requires %d", e.Current, e.Latest)
+}
+
+// Check returns *ErrSchemaBehind when the database has pending migrations.
+func (m *Migrator) Check(ctx context.Context) error {
+    current, err := m.Current(ctx)
+    if err != nil {
+        return err
+    }
+    if current < m.Latest() {
+        return &ErrSchemaBehind{Current: current, Latest: m.Latest()}
+    }
+    return nil
+}
+
+// execer is satisfied by *sql.DB and *sql.Conn.
+type execer interface {
+    ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func (m *Migrator) ensureTable(ctx context.Context, db execer) error {
+    _, err := db.ExecContext(ctx, `
+        CREATE TABLE IF NOT EXISTS schema_migrations (
+            version    INTEGER PRIMARY KEY,
+            name       TEXT NOT NULL,
+            applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+        )`)
+    if err != nil {
+        return fmt.Errorf("error creating schema_migrations: %w", err)
+    }
+    return nil
+}
+
+// apply runs one migration script and its bookkeeping statement atomically.
+func apply(ctx context.Context, conn *sql.Conn, script, bookkeeping string, version int, name string) error {
+    tx, err := conn.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    if _, err := tx.ExecContext(ctx, script); err != nil {
+        return err
+    }
+    if _, err := tx.ExecContext(ctx, bookkeeping, version, name); err != nil {
+        return err
+    }
+    return tx.Commit()
+}
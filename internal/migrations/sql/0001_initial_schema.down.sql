@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS playlistcampaigns;
+DROP TABLE IF EXISTS campaigns;
+DROP TABLE IF EXISTS playlists;
+DROP TABLE IF EXISTS playlisters;
@@ -0,0 +1,54 @@
+-- Baseline schema. IF NOT EXISTS lets databases created by hand before
+-- migrations existed adopt version 1 without losing data.
+
+CREATE TABLE IF NOT EXISTS playlisters (
+    playlisterid      SERIAL PRIMARY KEY,
+    spotifyuserid     VARCHAR(50)  NOT NULL,
+    curatorfullname   VARCHAR(100) NOT NULL,
+    email             VARCHAR(254) NOT NULL,
+    instagram         VARCHAR(30),
+    facebook          VARCHAR(50),
+    whatsapp          VARCHAR(20),
+    lastcontacted     DATE,
+    preferredlanguage CHAR(2)      NOT NULL,
+    followupstatus    VARCHAR(20)  NOT NULL DEFAULT 'Pending',
+    CONSTRAINT playlisters_spotifyuserid_key UNIQUE (spotifyuserid),
+    CONSTRAINT playlisters_email_key UNIQUE (email)
+);
+
+CREATE TABLE IF NOT EXISTS playlists (
+    playlistid            SERIAL PRIMARY KEY,
+    playlisterid          INTEGER      NOT NULL REFERENCES playlisters (playlisterid),
+    playlistspotifyid     VARCHAR(100) NOT NULL,
+    numberoffollowers     INTEGER      NOT NULL DEFAULT 0 CHECK (numberoffollowers >= 0),
+    current_playlist_name VARCHAR(200) NOT NULL,
+    lastfollowercountdate DATE,
+    last_exposed          DATE,
+    CONSTRAINT playlists_playlistspotifyid_key UNIQUE (playlistspotifyid)
+);
+
+CREATE INDEX IF NOT EXISTS playlists_playlisterid_idx ON playlists (playlisterid);
+
+CREATE TABLE IF NOT EXISTS campaigns (
+    campaignid       SERIAL PRIMARY KEY,
+    campaignname     VARCHAR(100) NOT NULL,
+    referenceartists TEXT         NOT NULL,
+    trello_link      TEXT,
+    spotify_link     TEXT,
+    launchdate       DATE         NOT NULL,
+    promoted_artist  VARCHAR(100) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS playlistcampaigns (
+    playlistid       INTEGER     NOT NULL REFERENCES playlists (playlistid),
+    campaignid       INTEGER     NOT NULL REFERENCES campaigns (campaignid),
+    playlisterid     INTEGER     NOT NULL REFERENCES playlisters (playlisterid),
+    referenceartists TEXT        NOT NULL,
+    placementstatus  VARCHAR(20) NOT NULL DEFAULT 'Pending',
+    numberofmessages INTEGER     NOT NULL DEFAULT 0 CHECK (numberofmessages >= 0),
+    purchased        BOOLEAN     NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (playlistid, campaignid)
+);
+
+CREATE INDEX IF NOT EXISTS playlistcampaigns_campaignid_idx ON playlistcampaigns (campaignid);
+CREATE INDEX IF NOT EXISTS playlistcampaigns_playlisterid_idx ON playlistcampaigns (playlisterid);
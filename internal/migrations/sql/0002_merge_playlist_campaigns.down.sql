@@ -0,0 +1,2 @@
+-- The stray table is not recreated; its rows now live in playlistcampaigns.
+SELECT 1;
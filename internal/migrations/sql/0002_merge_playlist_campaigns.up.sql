@@ -0,0 +1,18 @@
+-- Earlier builds wrote placements to a snake_case playlist_campaigns table
+-- while every reader used playlistcampaigns. Fold any such rows into the
+-- canonical table and drop the stray one.
+
+DO $$
+BEGIN
+    IF to_regclass('playlist_campaigns') IS NOT NULL THEN
+        INSERT INTO playlistcampaigns (playlistid, campaignid, playlisterid, referenceartists,
+                                       placementstatus, numberofmessages, purchased)
+        SELECT playlist_id, campaign_id, playlister_id, reference_artists,
+               placement_status, number_of_messages, purchased
+        FROM playlist_campaigns
+        ON CONFLICT (playlistid, campaignid) DO NOTHING;
+
+        DROP TABLE playlist_campaigns;
+    END IF;
+END
+$$;
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS campaigns_search_idx;
+ALTER TABLE campaigns DROP COLUMN IF EXISTS search_vector;
+
+DROP INDEX IF EXISTS playlists_search_idx;
+ALTER TABLE playlists DROP COLUMN IF EXISTS search_vector;
+
+DROP INDEX IF EXISTS playlisters_search_idx;
+ALTER TABLE playlisters DROP COLUMN IF EXISTS search_vector;
+
+DROP FUNCTION IF EXISTS immutable_unaccent(text);
+
+-- The unaccent extension is left installed; other objects may depend on it.
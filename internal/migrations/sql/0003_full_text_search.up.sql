@@ -0,0 +1,35 @@
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+-- unaccent() is only STABLE because its dictionary could change. Pinning the
+-- dictionary makes this wrapper safe for generated columns and indexes.
+CREATE OR REPLACE FUNCTION immutable_unaccent(text) RETURNS text
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT
+    AS $$ SELECT public.unaccent('public.unaccent'::regdictionary, $1) $$;
+
+-- Columns are weighted by significance: names first, contact handles and
+-- identifiers after. Keep in step with the store's *SearchText functions.
+
+ALTER TABLE playlisters ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', immutable_unaccent(coalesce(curatorfullname, ''))), 'A') ||
+    setweight(to_tsvector('simple', immutable_unaccent(coalesce(instagram, ''))), 'B') ||
+    setweight(to_tsvector('simple', immutable_unaccent(coalesce(email, ''))), 'B') ||
+    setweight(to_tsvector('simple', immutable_unaccent(coalesce(facebook, ''))), 'C') ||
+    setweight(to_tsvector('simple', coalesce(spotifyuserid, '')), 'C')
+) STORED;
+
+CREATE INDEX playlisters_search_idx ON playlisters USING GIN (search_vector);
+
+ALTER TABLE playlists ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', immutable_unaccent(coalesce(current_playlist_name, ''))), 'A') ||
+    setweight(to_tsvector('simple', coalesce(playlistspotifyid, '')), 'C')
+) STORED;
+
+CREATE INDEX playlists_search_idx ON playlists USING GIN (search_vector);
+
+ALTER TABLE campaigns ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', immutable_unaccent(coalesce(campaignname, ''))), 'A') ||
+    setweight(to_tsvector('simple', immutable_unaccent(coalesce(promoted_artist, ''))), 'A') ||
+    setweight(to_tsvector('simple', immutable_unaccent(coalesce(referenceartists, ''))), 'B')
+) STORED;
+
+CREATE INDEX campaigns_search_idx ON campaigns USING GIN (search_vector);
@@ -0,0 +1,4 @@
+ALTER TABLE playlistcampaigns DROP COLUMN IF EXISTS version;
+ALTER TABLE campaigns DROP COLUMN IF EXISTS version;
+ALTER TABLE playlists DROP COLUMN IF EXISTS version;
+ALTER TABLE playlisters DROP COLUMN IF EXISTS version;
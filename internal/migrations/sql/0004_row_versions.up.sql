@@ -0,0 +1,7 @@
+-- Every row carries a version that is bumped on each update. It backs the
+-- ETag header and conditional writes via If-Match.
+
+ALTER TABLE playlisters ADD COLUMN version integer NOT NULL DEFAULT 1;
+ALTER TABLE playlists ADD COLUMN version integer NOT NULL DEFAULT 1;
+ALTER TABLE campaigns ADD COLUMN version integer NOT NULL DEFAULT 1;
+ALTER TABLE playlistcampaigns ADD COLUMN version integer NOT NULL DEFAULT 1;
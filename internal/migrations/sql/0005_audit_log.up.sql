@@ -0,0 +1,16 @@
+-- One row per create, update or delete, written in the same transaction as
+-- the change it describes. changes maps each affected JSON field to its old
+-- and new value.
+
+CREATE TABLE audit_log (
+    id         bigserial PRIMARY KEY,
+    actor      text NOT NULL,
+    at         timestamptz NOT NULL DEFAULT now(),
+    entity     text NOT NULL,
+    entity_key text NOT NULL,
+    action     text NOT NULL CHECK (action IN ('create', 'update', 'delete')),
+    changes    jsonb NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX audit_log_entity_key_idx ON audit_log (entity, entity_key, id);
+CREATE INDEX audit_log_actor_idx ON audit_log (actor, id);
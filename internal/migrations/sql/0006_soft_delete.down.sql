@@ -0,0 +1,19 @@
+-- Trashed rows are purged: without deleted_at they would reappear as live.
+
+DELETE FROM playlistcampaigns WHERE deleted_at IS NOT NULL;
+DELETE FROM playlists WHERE deleted_at IS NOT NULL;
+DELETE FROM campaigns WHERE deleted_at IS NOT NULL;
+DELETE FROM playlisters WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE audit_log DROP CONSTRAINT audit_log_action_check;
+DELETE FROM audit_log WHERE action IN ('restore', 'purge');
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_action_check
+    CHECK (action IN ('create', 'update', 'delete'));
+
+DROP INDEX IF EXISTS playlistcampaigns_live_idx;
+DROP INDEX IF EXISTS playlists_live_idx;
+
+ALTER TABLE playlistcampaigns DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE campaigns DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE playlists DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE playlisters DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,15 @@
+-- Deletes only stamp deleted_at; rows stay in place until an admin purges
+-- them. Unique columns stay unique across trashed rows, so a trashed record
+-- has to be restored rather than created again.
+
+ALTER TABLE playlisters ADD COLUMN deleted_at timestamptz;
+ALTER TABLE playlists ADD COLUMN deleted_at timestamptz;
+ALTER TABLE campaigns ADD COLUMN deleted_at timestamptz;
+ALTER TABLE playlistcampaigns ADD COLUMN deleted_at timestamptz;
+
+CREATE INDEX playlists_live_idx ON playlists (playlistid) WHERE deleted_at IS NULL;
+CREATE INDEX playlistcampaigns_live_idx ON playlistcampaigns (playlistid, campaignid) WHERE deleted_at IS NULL;
+
+ALTER TABLE audit_log DROP CONSTRAINT audit_log_action_check;
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_action_check
+    CHECK (action IN ('create', 'update', 'delete', 'restore', 'purge'));
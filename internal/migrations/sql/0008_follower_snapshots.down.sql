@@ -0,0 +1,3 @@
+DROP FUNCTION IF EXISTS playlist_follower_growth_rate(integer, integer, integer);
+DROP FUNCTION IF EXISTS playlist_follower_baseline(integer, integer);
+DROP TABLE IF EXISTS playlist_follower_snapshots;
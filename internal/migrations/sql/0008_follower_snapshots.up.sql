@@ -0,0 +1,40 @@
+-- Follower counts over time. A row is written whenever a playlist is created
+-- or its numberoffollowers changes; growth over a window is measured against
+-- the last count recorded before the window began.
+
+CREATE TABLE playlist_follower_snapshots (
+    snapshotid  bigserial PRIMARY KEY,
+    playlistid  integer NOT NULL REFERENCES playlists (playlistid) ON DELETE CASCADE,
+    followers   integer NOT NULL CHECK (followers >= 0),
+    recorded_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX playlist_follower_snapshots_playlistid_idx
+    ON playlist_follower_snapshots (playlistid, recorded_at);
+
+-- Start every existing playlist's series with its current count, dated to
+-- the day it was counted when that is known.
+INSERT INTO playlist_follower_snapshots (playlistid, followers, recorded_at)
+SELECT playlistid, numberoffollowers, COALESCE(lastfollowercountdate::timestamptz, now())
+FROM playlists;
+
+-- playlist_follower_baseline is the follower count a playlist had p_days ago:
+-- the last snapshot recorded by then or, for a shorter history, the first.
+CREATE FUNCTION playlist_follower_baseline(p_playlistid integer, p_days integer)
+RETURNS integer LANGUAGE sql STABLE AS $$
+    SELECT COALESCE(
+        (SELECT followers FROM playlist_follower_snapshots
+         WHERE playlistid = p_playlistid AND recorded_at <= now() - make_interval(days => p_days)
+         ORDER BY recorded_at DESC, snapshotid DESC LIMIT 1),
+        (SELECT followers FROM playlist_follower_snapshots
+         WHERE playlistid = p_playlistid
+         ORDER BY recorded_at, snapshotid LIMIT 1))
+$$;
+
+-- playlist_follower_growth_rate is the percentage change from the baseline
+-- to p_followers, rounded to two decimals, or NULL for a baseline of zero.
+CREATE FUNCTION playlist_follower_growth_rate(p_playlistid integer, p_followers integer, p_days integer)
+RETURNS double precision LANGUAGE sql STABLE AS $$
+    SELECT round((p_followers - baseline) * 100.0 / NULLIF(baseline, 0), 2)::double precision
+    FROM (SELECT playlist_follower_baseline(p_playlistid, p_days) AS baseline) b
+$$;
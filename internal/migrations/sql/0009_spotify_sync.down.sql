@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS playlists_sync_queue_idx;
+ALTER TABLE playlists DROP COLUMN IF EXISTS spotify_deleted_at;
+ALTER TABLE playlists DROP COLUMN IF EXISTS last_synced_at;
@@ -0,0 +1,8 @@
+-- The Spotify sync stamps each playlist it refreshes with last_synced_at,
+-- and with spotify_deleted_at once Spotify reports the playlist gone.
+
+ALTER TABLE playlists ADD COLUMN last_synced_at timestamptz;
+ALTER TABLE playlists ADD COLUMN spotify_deleted_at timestamptz;
+
+CREATE INDEX playlists_sync_queue_idx ON playlists (last_synced_at NULLS FIRST, playlistid)
+    WHERE deleted_at IS NULL AND spotify_deleted_at IS NULL;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS playlistcampaigns_verify_queue_idx;
+ALTER TABLE playlistcampaigns DROP COLUMN IF EXISTS track_removed_at;
+ALTER TABLE playlistcampaigns DROP COLUMN IF EXISTS track_first_seen_at;
+ALTER TABLE playlistcampaigns DROP COLUMN IF EXISTS track_present;
+ALTER TABLE playlistcampaigns DROP COLUMN IF EXISTS verified_at;
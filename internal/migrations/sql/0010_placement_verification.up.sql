@@ -0,0 +1,11 @@
+-- Placements record what checks of their playlist's tracks found: when the
+-- last one ran, whether it saw the campaign's track, when the track was first
+-- seen and when it disappeared after being seen.
+
+ALTER TABLE playlistcampaigns ADD COLUMN verified_at timestamptz;
+ALTER TABLE playlistcampaigns ADD COLUMN track_present boolean;
+ALTER TABLE playlistcampaigns ADD COLUMN track_first_seen_at timestamptz;
+ALTER TABLE playlistcampaigns ADD COLUMN track_removed_at timestamptz;
+
+CREATE INDEX playlistcampaigns_verify_queue_idx ON playlistcampaigns (verified_at NULLS FIRST, playlistid, campaignid)
+    WHERE deleted_at IS NULL AND (placementstatus = 'Placed' OR track_present);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS placement_transitions;
+ALTER TABLE playlistcampaigns DROP COLUMN IF EXISTS status_changed_at;
+ALTER TABLE playlistcampaigns DROP CONSTRAINT IF EXISTS playlistcampaigns_placementstatus_check;
+ALTER TABLE playlistcampaigns ALTER COLUMN placementstatus SET DEFAULT 'Pending';
+
+UPDATE playlistcampaigns SET placementstatus = 'Pending'
+WHERE placementstatus NOT IN ('Placed', 'Rejected');
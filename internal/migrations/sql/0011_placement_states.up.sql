@@ -0,0 +1,33 @@
+-- Placements move through an explicit set of states instead of a free-form
+-- status. Pending pitches become Pitched; Placed and Rejected keep their
+-- names. Every status change is kept in placement_transitions.
+
+UPDATE playlistcampaigns SET placementstatus = 'Pitched' WHERE placementstatus = 'Pending';
+UPDATE playlistcampaigns SET placementstatus = 'Drafted'
+WHERE placementstatus NOT IN ('Pitched', 'Placed', 'Rejected');
+
+ALTER TABLE playlistcampaigns ALTER COLUMN placementstatus SET DEFAULT 'Drafted';
+ALTER TABLE playlistcampaigns ADD CONSTRAINT playlistcampaigns_placementstatus_check
+    CHECK (placementstatus IN ('Drafted', 'Pitched', 'Replied', 'Negotiating', 'Placed', 'Removed', 'Rejected', 'Expired'));
+
+ALTER TABLE playlistcampaigns ADD COLUMN status_changed_at timestamptz;
+UPDATE playlistcampaigns SET status_changed_at = now();
+
+CREATE TABLE placement_transitions (
+    transitionid bigserial   PRIMARY KEY,
+    playlistid   integer     NOT NULL,
+    campaignid   integer     NOT NULL,
+    from_status  varchar(20),
+    to_status    varchar(20) NOT NULL,
+    actor        text        NOT NULL,
+    at           timestamptz NOT NULL DEFAULT now(),
+    note         text,
+    FOREIGN KEY (playlistid, campaignid) REFERENCES playlistcampaigns (playlistid, campaignid) ON DELETE CASCADE
+);
+
+CREATE INDEX placement_transitions_placement_idx ON placement_transitions (playlistid, campaignid, at);
+
+-- Start every existing placement's history with its mapped status.
+INSERT INTO placement_transitions (playlistid, campaignid, to_status, actor, at, note)
+SELECT playlistid, campaignid, placementstatus, 'system', status_changed_at, 'migrated from the free-form status'
+FROM playlistcampaigns;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS messages;
+ALTER TABLE campaigns DROP COLUMN IF EXISTS max_messages;
@@ -0,0 +1,29 @@
+-- Every message sent to or received from a curator is logged against its
+-- placement. Campaigns cap the outbound messages of each placement.
+
+ALTER TABLE campaigns ADD COLUMN max_messages integer NOT NULL DEFAULT 3 CHECK (max_messages > 0);
+
+CREATE TABLE messages (
+    messageid   bigserial   PRIMARY KEY,
+    playlistid  integer     NOT NULL,
+    campaignid  integer     NOT NULL,
+    channel     varchar(20) NOT NULL CHECK (channel IN ('email', 'instagram', 'facebook', 'whatsapp', 'other')),
+    direction   varchar(10) NOT NULL CHECK (direction IN ('outbound', 'inbound')),
+    sent_at     timestamptz NOT NULL,
+    body        text,
+    external_id text,
+    actor       text        NOT NULL,
+    UNIQUE (channel, external_id),
+    FOREIGN KEY (playlistid, campaignid) REFERENCES playlistcampaigns (playlistid, campaignid) ON DELETE CASCADE
+);
+
+CREATE INDEX messages_placement_idx ON messages (playlistid, campaignid, sent_at);
+
+-- Stand in for the messages counted before the log existed, so the counts
+-- keep matching it. They are dated the curator's lastcontacted, when known.
+INSERT INTO messages (playlistid, campaignid, channel, direction, sent_at, actor)
+SELECT pc.playlistid, pc.campaignid, 'other', 'outbound',
+       COALESCE(p.lastcontacted::timestamptz, now()), 'system'
+FROM playlistcampaigns pc
+JOIN playlisters p ON p.playlisterid = pc.playlisterid
+CROSS JOIN generate_series(1, pc.numberofmessages);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS messages_outbound_idx;
+ALTER TABLE playlistcampaigns DROP COLUMN IF EXISTS assignee;
+ALTER TABLE campaigns DROP COLUMN IF EXISTS followup_sequence;
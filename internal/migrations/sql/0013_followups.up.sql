@@ -0,0 +1,9 @@
+-- Campaigns define the outreach sequence their placements follow, and each
+-- placement can be assigned to the user handling its outreach.
+
+ALTER TABLE campaigns ADD COLUMN followup_sequence jsonb NOT NULL DEFAULT '[]'
+    CHECK (jsonb_typeof(followup_sequence) = 'array');
+ALTER TABLE playlistcampaigns ADD COLUMN assignee varchar(100);
+
+CREATE INDEX playlistcampaigns_assignee_idx ON playlistcampaigns (assignee) WHERE deleted_at IS NULL;
+CREATE INDEX messages_outbound_idx ON messages (playlistid, campaignid, sent_at) WHERE direction = 'outbound';
@@ -0,0 +1,5 @@
+ALTER TABLE playlistcampaigns ADD COLUMN IF NOT EXISTS numberofmessages integer NOT NULL DEFAULT 0 CHECK (numberofmessages >= 0);
+UPDATE playlistcampaigns SET numberofmessages = placement_outbound_messages(playlistid, campaignid);
+UPDATE playlisters SET lastcontacted = playlister_last_contacted(playlisterid, lastcontacted);
+DROP FUNCTION IF EXISTS playlister_last_contacted(integer, date);
+DROP FUNCTION IF EXISTS placement_outbound_messages(integer, integer);
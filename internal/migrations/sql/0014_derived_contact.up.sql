@@ -0,0 +1,26 @@
+-- A placement's numberofmessages and a playlister's lastcontacted are read
+-- from the message log instead of being kept alongside it. The stored
+-- lastcontacted remains the day recorded before the log existed, and only
+-- stands in for curators with no messages logged.
+
+-- placement_outbound_messages counts the outbound messages logged for a
+-- placement.
+CREATE FUNCTION placement_outbound_messages(p_playlistid integer, p_campaignid integer)
+RETURNS integer LANGUAGE sql STABLE AS $$
+    SELECT count(*)::integer FROM messages
+    WHERE playlistid = p_playlistid AND campaignid = p_campaignid AND direction = 'outbound'
+$$;
+
+-- playlister_last_contacted is the UTC day of the latest message logged for
+-- any of the playlister's placements or, when there is none, p_recorded.
+CREATE FUNCTION playlister_last_contacted(p_playlisterid integer, p_recorded date)
+RETURNS date LANGUAGE sql STABLE AS $$
+    SELECT COALESCE(
+        (SELECT max(m.sent_at AT TIME ZONE 'UTC')::date
+         FROM messages m
+         JOIN playlistcampaigns pc ON pc.playlistid = m.playlistid AND pc.campaignid = m.campaignid
+         WHERE pc.playlisterid = p_playlisterid),
+        p_recorded)
+$$;
+
+ALTER TABLE playlistcampaigns DROP COLUMN numberofmessages;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS playlists_sync_queue_idx;
+ALTER TABLE playlists DROP COLUMN IF EXISTS sync_failed_at;
+CREATE INDEX playlists_sync_queue_idx ON playlists (last_synced_at NULLS FIRST, playlistid)
+    WHERE deleted_at IS NULL AND spotify_deleted_at IS NULL;
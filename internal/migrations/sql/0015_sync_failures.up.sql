@@ -0,0 +1,9 @@
+-- A failed Spotify refresh stamps sync_failed_at, and the sync queue orders
+-- playlists by the later of it and last_synced_at, so a playlist that keeps
+-- failing waits its turn like one that synced.
+
+ALTER TABLE playlists ADD COLUMN sync_failed_at timestamptz;
+
+DROP INDEX IF EXISTS playlists_sync_queue_idx;
+CREATE INDEX playlists_sync_queue_idx ON playlists (GREATEST(last_synced_at, sync_failed_at) NULLS FIRST, playlistid)
+    WHERE deleted_at IS NULL AND spotify_deleted_at IS NULL;
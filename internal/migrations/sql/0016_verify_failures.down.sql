@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS playlistcampaigns_verify_queue_idx;
+ALTER TABLE playlistcampaigns DROP COLUMN IF EXISTS verify_failed_at;
+CREATE INDEX playlistcampaigns_verify_queue_idx ON playlistcampaigns (verified_at NULLS FIRST, playlistid, campaignid)
+    WHERE deleted_at IS NULL AND (placementstatus = 'Placed' OR track_present);
@@ -0,0 +1,9 @@
+-- A failed check of a placement's playlist stamps verify_failed_at, and the
+-- verification queue orders placements by the later of it and verified_at,
+-- so a playlist that keeps failing does not hold up the others.
+
+ALTER TABLE playlistcampaigns ADD COLUMN verify_failed_at timestamptz;
+
+DROP INDEX IF EXISTS playlistcampaigns_verify_queue_idx;
+CREATE INDEX playlistcampaigns_verify_queue_idx ON playlistcampaigns (GREATEST(verified_at, verify_failed_at) NULLS FIRST, playlistid, campaignid)
+    WHERE deleted_at IS NULL AND (placementstatus = 'Placed' OR track_present);
@@ -3,6 +3,8 @@ package models
 import (
 	"database/sql"
 	"encoding/json"
+
+	"github.com/alanowatson/LeadGenAPI/internal/spotify"
 )
 
 type Campaign struct {
@@ -10,21 +12,47 @@ type Campaign struct {
     CampaignName     sql.NullString `json:"campaignname" validate:"required,min=1,max=100"`
     ReferenceArtists sql.NullString `json:"referenceartists" validate:"required"`
     TrelloLink       sql.NullString `json:"trello_link"`
-    SpotifyLink      sql.NullString `json:"spotify_link"`
+    SpotifyLink      sql.NullString `json:"spotify_link" validate:"omitempty,spotify_link"`
     LaunchDate       sql.NullString `json:"launch_date" validate:"required,datetime=2006-01-02"`
     PromotedArtist   sql.NullString `json:"promoted_artist" validate:"required,min=1,max=100"`
+    // MaxMessages caps the outbound messages logged per placement. Zero
+    // leaves it to the store: the default on create, unchanged on update.
+    MaxMessages      int            `json:"max_messages" validate:"omitempty,min=1,max=50"`
+    // FollowupSequence is the outreach each placement gets; empty for none.
+    FollowupSequence Sequence       `json:"followup_sequence" validate:"max=20,dive"`
+    // DeletedAt is set while the record is in the trash.
+    DeletedAt        sql.NullString `json:"deleted_at"`
+    // Version is bumped by every update and travels as the ETag header.
+    Version          int            `json:"-"`
+}
+
+// Normalize rewrites a pasted Spotify link or URI as its canonical
+// open.spotify.com link. It runs before validation.
+func (c *Campaign) Normalize() {
+    c.SpotifyLink = normalized(c.SpotifyLink, spotify.CanonicalLink)
+}
+
+// Steps returns the campaign's follow-up steps, never nil.
+func (c Campaign) Steps() Sequence {
+    if c.FollowupSequence == nil {
+        return Sequence{}
+    }
+    return c.FollowupSequence
 }
 
 // MarshalJSON implements a custom JSON marshaler for Campaign
 func (c Campaign) MarshalJSON() ([]byte, error) {
     return json.Marshal(struct {
-        ID               int    `json:"campaignid"`
-        CampaignName     string `json:"campaignname"`
-        ReferenceArtists string `json:"referenceartists"`
-        TrelloLink       string `json:"trello_link"`
-        SpotifyLink      string `json:"spotify_link"`
-        LaunchDate       string `json:"launch_date"`
-        PromotedArtist   string `json:"promoted_artist"`
+        ID               int      `json:"campaignid"`
+        CampaignName     string   `json:"campaignname"`
+        ReferenceArtists string   `json:"referenceartists"`
+        TrelloLink       string   `json:"trello_link"`
+        SpotifyLink      string   `json:"spotify_link"`
+        LaunchDate       string   `json:"launch_date"`
+        PromotedArtist   string   `json:"promoted_artist"`
+        MaxMessages      int      `json:"max_messages"`
+        FollowupSequence Sequence `json:"followup_sequence"`
+        DeletedAt        string   `json:"deleted_at"`
     }{
         ID:               c.ID,
         CampaignName:     stringOrEmpty(c.CampaignName),
@@ -33,5 +61,37 @@ func (c Campaign) MarshalJSON() ([]byte, error) {
         SpotifyLink:      stringOrEmpty(c.SpotifyLink),
         LaunchDate:       stringOrEmpty(c.LaunchDate),
         PromotedArtist:   stringOrEmpty(c.PromotedArtist),
+        MaxMessages:      c.MaxMessages,
+        FollowupSequence: c.Steps(),
+        DeletedAt:        stringOrEmpty(c.DeletedAt),
     })
 }
+
+// UnmarshalJSON implements a custom JSON unmarshaler for Campaign
+func (c *Campaign) UnmarshalJSON(data []byte) error {
+    var aux struct {
+        ID               int      `json:"campaignid"`
+        CampaignName     *string  `json:"campaignname"`
+        ReferenceArtists *string  `json:"referenceartists"`
+        TrelloLink       *string  `json:"trello_link"`
+        SpotifyLink      *string  `json:"spotify_link"`
+        LaunchDate       *string  `json:"launch_date"`
+        PromotedArtist   *string  `json:"promoted_artist"`
+        MaxMessages      int      `json:"max_messages"`
+        FollowupSequence Sequence `json:"followup_sequence"`
+    }
+    if err := json.Unmarshal(data, &aux); err != nil {
+        return err
+    }
+
+    c.ID = aux.ID
+    c.CampaignName = nullString(aux.CampaignName)
+    c.ReferenceArtists = nullString(aux.ReferenceArtists)
+    c.TrelloLink = nullString(aux.TrelloLink)
+    c.SpotifyLink = nullString(aux.SpotifyLink)
+    c.LaunchDate = nullString(aux.LaunchDate)
+    c.PromotedArtist = nullString(aux.PromotedArtist)
+    c.MaxMessages = aux.MaxMessages
+    c.FollowupSequence = aux.FollowupSequence
+    return nil
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+    "database/sql/driver"
+    "encoding/json"
+    "fmt"
+)
+
+// FollowupStep is one message of a campaign's outreach sequence: which
+// channel to send it on, and how many days after the previous message. The
+// first step, the pitch, counts from when the placement entered its status.
+type FollowupStep struct {
+    Channel   string `json:"channel" validate:"required,oneof=email instagram facebook whatsapp other"`
+    AfterDays int    `json:"after_days" validate:"min=0,max=365"`
+}
+
+// Sequence lists the steps of a campaign's outreach in order. Outreach stops
+// after the last step. It is stored as jsonb.
+type Sequence []FollowupStep
+
+// Value implements driver.Valuer.
+func (s Sequence) Value() (driver.Value, error) {
+    if s == nil {
+        return []byte("[]"), nil
+    }
+    return json.Marshal(s)
+}
+
+// Scan implements sql.Scanner.
+func (s *Sequence) Scan(src interface{}) error {
+    switch v := src.(type) {
+    case []byte:
+        return json.Unmarshal(v, s)
+    case string:
+        return json.Unmarshal([]byte(v), s)
+    case nil:
+        *s = nil
+        return nil
+    }
+    return fmt.Errorf("cannot scan %T into models.Sequence", src)
+}
+
+// FollowupStatuses are the states whose placements are still followed up.
+// Outreach stops once a placement is placed, rejected, removed or expired.
+var FollowupStatuses = []string{PlacementDrafted, PlacementPitched, PlacementReplied, PlacementNegotiating}
+
+// FollowsUp reports whether placements in status are still followed up.
+func FollowsUp(status string) bool {
+    for _, s := range FollowupStatuses {
+        if s == status {
+            return true
+        }
+    }
+    return false
+}
+
+// Followup is the next message due for a placement. Step counts from 1 and
+// Steps is how many the sequence has, less any past the campaign's cap.
+type Followup struct {
+    PlaylistID      int    `json:"playlistid"`
+    CampaignID      int    `json:"campaignid"`
+    PlaylisterID    int    `json:"playlisterid"`
+    Assignee        string `json:"assignee"`
+    PlacementStatus string `json:"placementstatus"`
+    Step            int    `json:"step"`
+    Steps           int    `json:"steps"`
+    Channel         string `json:"channel"`
+    DueAt           string `json:"due_at"`
+}
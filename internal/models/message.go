@@ -0,0 +1,39 @@
+package models
+
+// The directions of a message: sent to the curator or received from them.
+const (
+    MessageOutbound = "outbound"
+    MessageInbound  = "inbound"
+)
+
+// MessageChannels are the channels messages are sent on.
+var MessageChannels = []string{"email", "instagram", "facebook", "whatsapp", "other"}
+
+// ValidChannel reports whether channel is one of MessageChannels.
+func ValidChannel(channel string) bool {
+    for _, c := range MessageChannels {
+        if c == channel {
+            return true
+        }
+    }
+    return false
+}
+
+// DefaultMaxMessages is how many outbound messages a placement may have
+// when its campaign does not set MaxMessages.
+const DefaultMaxMessages = 3
+
+// Message is one message logged against a placement. ExternalID is the
+// channel's own ID for it, such as an email's Message-ID, and is unique per
+// channel. SentAt defaults to the time it is logged.
+type Message struct {
+    ID         int    `json:"messageid"`
+    PlaylistID int    `json:"playlistid"`
+    CampaignID int    `json:"campaignid"`
+    Channel    string `json:"channel" validate:"required,oneof=email instagram facebook whatsapp other"`
+    Direction  string `json:"direction" validate:"required,oneof=outbound inbound"`
+    SentAt     string `json:"sent_at" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+    Body       string `json:"body,omitempty" validate:"max=10000"`
+    ExternalID string `json:"external_id,omitempty" validate:"max=255"`
+    Actor      string `json:"actor"`
+}
@@ -0,0 +1,71 @@
+package models
+
+// The states a placement moves through, from the first draft of a pitch to
+// the track being placed and possibly removed again.
+const (
+    PlacementDrafted     = "Drafted"
+    PlacementPitched     = "Pitched"
+    PlacementReplied     = "Replied"
+    PlacementNegotiating = "Negotiating"
+    PlacementPlaced      = "Placed"
+    PlacementRemoved     = "Removed"
+    PlacementRejected    = "Rejected"
+    PlacementExpired     = "Expired"
+)
+
+// PlacementStatuses lists the placement states in the order they are met.
+var PlacementStatuses = []string{
+    PlacementDrafted, PlacementPitched, PlacementReplied, PlacementNegotiating,
+    PlacementPlaced, PlacementRemoved, PlacementRejected, PlacementExpired,
+}
+
+// placementTransitions lists the states each state can move to. The empty
+// state is that of a placement not created yet, which starts out drafted or
+// pitched. Rejected and expired pitches can be pitched again, and a removed
+// track placed again.
+var placementTransitions = map[string][]string{
+    "":                   {PlacementDrafted, PlacementPitched},
+    PlacementDrafted:     {PlacementPitched, PlacementExpired},
+    PlacementPitched:     {PlacementReplied, PlacementPlaced, PlacementRejected, PlacementExpired},
+    PlacementReplied:     {PlacementNegotiating, PlacementPlaced, PlacementRejected, PlacementExpired},
+    PlacementNegotiating: {PlacementPlaced, PlacementRejected, PlacementExpired},
+    PlacementPlaced:      {PlacementRemoved},
+    PlacementRemoved:     {PlacementPlaced},
+    PlacementRejected:    {PlacementPitched},
+    PlacementExpired:     {PlacementPitched},
+}
+
+// ValidPlacementStatus reports whether status is one of the placement states.
+func ValidPlacementStatus(status string) bool {
+    _, ok := placementTransitions[status]
+    return ok && status != ""
+}
+
+// NextPlacementStatuses returns the states a placement in status can move to,
+// or for "", the states a new placement can start in.
+func NextPlacementStatuses(status string) []string {
+    return placementTransitions[status]
+}
+
+// CanTransition reports whether a placement may move from one status to
+// another.
+func CanTransition(from, to string) bool {
+    for _, next := range placementTransitions[from] {
+        if next == to {
+            return true
+        }
+    }
+    return false
+}
+
+// PlacementTransition is one change of a placement's status. From is empty
+// for the status the placement was created with.
+type PlacementTransition struct {
+    PlaylistID int    `json:"playlistid"`
+    CampaignID int    `json:"campaignid"`
+    From       string `json:"from_status"`
+    To         string `json:"to_status"`
+    Actor      string `json:"actor"`
+    At         string `json:"at"`
+    Note       string `json:"note,omitempty"`
+}
@@ -3,16 +3,59 @@ package models
 import (
     "database/sql"
     "encoding/json"
+
+    "github.com/alanowatson/LeadGenAPI/internal/spotify"
 )
 
 type Playlist struct {
     ID                   int            `json:"playlistid"`
     PlaylisterId         int            `json:"playlisterid" validate:"required,min=1"`
-    PlaylistSpotifyId    sql.NullString `json:"playlistspotifyid" validate:"required,min=10,max=100"`
+    PlaylistSpotifyId    sql.NullString `json:"playlistspotifyid" validate:"required,spotify_playlist"`
     NumberOfFollowers    int            `json:"numberoffollowers" validate:"min=0"`
     CurrentPlaylistName  sql.NullString `json:"current_playlist_name" validate:"required,min=1,max=200"`
     LastFollowerCountDate sql.NullString `json:"lastfollowercountdate" validate:"omitempty,datetime=2006-01-02"`
     LastExposed          sql.NullString `json:"last_exposed" validate:"omitempty,datetime=2006-01-02"`
+    // DeletedAt is set while the record is in the trash.
+    DeletedAt            sql.NullString `json:"deleted_at"`
+    // LastSyncedAt is when the playlist was last refreshed from Spotify, and
+    // SpotifyDeletedAt when Spotify first reported it gone. The sync sets
+    // both; requests cannot.
+    LastSyncedAt         sql.NullString `json:"last_synced_at"`
+    SpotifyDeletedAt     sql.NullString `json:"spotify_deleted_at"`
+    // Version is bumped by every update and travels as the ETag header.
+    Version              int            `json:"-"`
+    // Growth holds the follower growth over the last 7, 30 and 90 days. It
+    // is derived from the follower snapshots when the playlist is read, and
+    // null in the JSON when it was not.
+    Growth               FollowerGrowth `json:"-"`
+}
+
+// FollowerGrowth is the change in followers over each growth window, and the
+// same change as a percentage of the count at the start of the window. A
+// value is NULL when there is no earlier count to compare with, or for a
+// rate, when that count was zero.
+type FollowerGrowth struct {
+    Days7  sql.NullInt64
+    Days30 sql.NullInt64
+    Days90 sql.NullInt64
+    Rate7  sql.NullFloat64
+    Rate30 sql.NullFloat64
+    Rate90 sql.NullFloat64
+}
+
+// FollowerSnapshot is the follower count of a playlist at one point in time.
+type FollowerSnapshot struct {
+    PlaylistID int    `json:"playlistid"`
+    Followers  int    `json:"followers"`
+    RecordedAt string `json:"recorded_at"`
+}
+
+// Normalize reduces a pasted Spotify playlist link or URI to the bare
+// playlist ID. It runs before validation.
+func (p *Playlist) Normalize() {
+    p.PlaylistSpotifyId = normalized(p.PlaylistSpotifyId, func(s string) string {
+        return spotify.Canonical(s, spotify.Playlist)
+    })
 }
 
 // MarshalJSON implements a custom JSON marshaler for Playlist
@@ -25,6 +68,15 @@ func (p Playlist) MarshalJSON() ([]byte, error) {
         CurrentPlaylistName  string `json:"current_playlist_name"`
         LastFollowerCountDate string `json:"lastfollowercountdate"`
         LastExposed          string `json:"last_exposed"`
+        DeletedAt            string `json:"deleted_at"`
+        LastSyncedAt         string `json:"last_synced_at"`
+        SpotifyDeletedAt     string `json:"spotify_deleted_at"`
+        Growth7d             *int64   `json:"growth_7d"`
+        Growth30d            *int64   `json:"growth_30d"`
+        Growth90d            *int64   `json:"growth_90d"`
+        GrowthRate7d         *float64 `json:"growth_rate_7d"`
+        GrowthRate30d        *float64 `json:"growth_rate_30d"`
+        GrowthRate90d        *float64 `json:"growth_rate_90d"`
     }{
         ID:                   p.ID,
         PlaylisterId:         p.PlaylisterId,
@@ -33,7 +85,55 @@ func (p Playlist) MarshalJSON() ([]byte, error) {
         CurrentPlaylistName:  stringOrEmpty(p.CurrentPlaylistName),
         LastFollowerCountDate: stringOrEmpty(p.LastFollowerCountDate),
         LastExposed:          stringOrEmpty(p.LastExposed),
+        DeletedAt:            stringOrEmpty(p.DeletedAt),
+        LastSyncedAt:         stringOrEmpty(p.LastSyncedAt),
+        SpotifyDeletedAt:     stringOrEmpty(p.SpotifyDeletedAt),
+        Growth7d:             intOrNull(p.Growth.Days7),
+        Growth30d:            intOrNull(p.Growth.Days30),
+        Growth90d:            intOrNull(p.Growth.Days90),
+        GrowthRate7d:         floatOrNull(p.Growth.Rate7),
+        GrowthRate30d:        floatOrNull(p.Growth.Rate30),
+        GrowthRate90d:        floatOrNull(p.Growth.Rate90),
     })
 }
 
+// UnmarshalJSON implements a custom JSON unmarshaler for Playlist
+func (p *Playlist) UnmarshalJSON(data []byte) error {
+    var aux struct {
+        ID                    int     `json:"playlistid"`
+        PlaylisterId          int     `json:"playlisterid"`
+        PlaylistSpotifyId     *string `json:"playlistspotifyid"`
+        NumberOfFollowers     int     `json:"numberoffollowers"`
+        CurrentPlaylistName   *string `json:"current_playlist_name"`
+        LastFollowerCountDate *string `json:"lastfollowercountdate"`
+        LastExposed           *string `json:"last_exposed"`
+    }
+    if err := json.Unmarshal(data, &aux); err != nil {
+        return err
+    }
+
+    p.ID = aux.ID
+    p.PlaylisterId = aux.PlaylisterId
+    p.PlaylistSpotifyId = nullString(aux.PlaylistSpotifyId)
+    p.NumberOfFollowers = aux.NumberOfFollowers
+    p.CurrentPlaylistName = nullString(aux.CurrentPlaylistName)
+    p.LastFollowerCountDate = nullString(aux.LastFollowerCountDate)
+    p.LastExposed = nullString(aux.LastExposed)
+    return nil
+}
+
+// intOrNull renders a nullable integer as a JSON number or null.
+func intOrNull(n sql.NullInt64) *int64 {
+    if !n.Valid {
+        return nil
+    }
+    return &n.Int64
+}
 
+// floatOrNull renders a nullable float as a JSON number or null.
+func floatOrNull(f sql.NullFloat64) *float64 {
+    if !f.Valid {
+        return nil
+    }
+    return &f.Float64
+}
@@ -10,9 +10,28 @@ type PlaylistCampaign struct {
     CampaignID       int            `json:"campaignid" validate:"required,min=1"`
     PlaylisterId     int            `json:"playlisterid" validate:"required,min=1"`
     ReferenceArtists sql.NullString `json:"referenceartists" validate:"required"`
-    PlacementStatus  sql.NullString `json:"placementstatus" validate:"required,oneof=Pending Placed Rejected"`
-    NumberOfMessages int            `json:"numberofmessages" validate:"min=0"`
+    PlacementStatus  sql.NullString `json:"placementstatus" validate:"required,oneof=Drafted Pitched Replied Negotiating Placed Removed Rejected Expired"`
+    // NumberOfMessages counts the outbound messages logged for the
+    // placement. The store reads it from the messages.
+    NumberOfMessages int            `json:"numberofmessages"`
     Purchased        bool           `json:"purchased"`
+    // Assignee is the user handling outreach for the placement.
+    Assignee         sql.NullString `json:"assignee" validate:"omitempty,max=100"`
+    // StatusChangedAt is when PlacementStatus last changed, or the placement
+    // was created. The store sets it.
+    StatusChangedAt  sql.NullString `json:"status_changed_at"`
+    // DeletedAt is set while the record is in the trash.
+    DeletedAt        sql.NullString `json:"deleted_at"`
+    // The verification fields record what checks of the playlist's tracks
+    // found: when one last ran, whether it saw the campaign's track, when a
+    // check first saw it and when a later one found it gone. Only the
+    // verifier sets them.
+    VerifiedAt       sql.NullString `json:"verified_at"`
+    TrackPresent     sql.NullBool   `json:"track_present"`
+    TrackFirstSeenAt sql.NullString `json:"track_first_seen_at"`
+    TrackRemovedAt   sql.NullString `json:"track_removed_at"`
+    // Version is bumped by every update and travels as the ETag header.
+    Version          int            `json:"-"`
 }
 
 func (pc PlaylistCampaign) MarshalJSON() ([]byte, error) {
@@ -24,6 +43,14 @@ func (pc PlaylistCampaign) MarshalJSON() ([]byte, error) {
         PlacementStatus  string `json:"placementstatus"`
         NumberOfMessages int    `json:"numberofmessages"`
         Purchased        bool   `json:"purchased"`
+        Assignee         string `json:"assignee"`
+        StatusChangedAt  string `json:"status_changed_at"`
+        DeletedAt        string `json:"deleted_at"`
+        VerifiedAt       string `json:"verified_at"`
+        TrackPresent     *bool  `json:"track_present"`
+        TrackFirstSeenAt string `json:"track_first_seen_at"`
+        TrackRemovedAt   string `json:"track_removed_at"`
+        Flagged          bool   `json:"flagged"`
     }{
         PlaylistID:       pc.PlaylistID,
         CampaignID:       pc.CampaignID,
@@ -32,5 +59,51 @@ func (pc PlaylistCampaign) MarshalJSON() ([]byte, error) {
         PlacementStatus:  pc.PlacementStatus.String,
         NumberOfMessages: pc.NumberOfMessages,
         Purchased:        pc.Purchased,
+        Assignee:         pc.Assignee.String,
+        StatusChangedAt:  pc.StatusChangedAt.String,
+        DeletedAt:        pc.DeletedAt.String,
+        VerifiedAt:       pc.VerifiedAt.String,
+        TrackPresent:     boolOrNull(pc.TrackPresent),
+        TrackFirstSeenAt: pc.TrackFirstSeenAt.String,
+        TrackRemovedAt:   pc.TrackRemovedAt.String,
+        Flagged:          pc.Flagged(),
     })
 }
+
+// Flagged reports a placement marked Placed whose track the last
+// verification did not find on the playlist.
+func (pc PlaylistCampaign) Flagged() bool {
+    return pc.PlacementStatus.String == PlacementPlaced && pc.TrackPresent.Valid && !pc.TrackPresent.Bool
+}
+
+// boolOrNull renders a nullable boolean as a JSON boolean or null.
+func boolOrNull(b sql.NullBool) *bool {
+    if !b.Valid {
+        return nil
+    }
+    return &b.Bool
+}
+
+func (pc *PlaylistCampaign) UnmarshalJSON(data []byte) error {
+    var aux struct {
+        PlaylistID       int     `json:"playlistid"`
+        CampaignID       int     `json:"campaignid"`
+        PlaylisterId     int     `json:"playlisterid"`
+        ReferenceArtists *string `json:"referenceartists"`
+        PlacementStatus  *string `json:"placementstatus"`
+        Purchased        bool    `json:"purchased"`
+        Assignee         *string `json:"assignee"`
+    }
+    if err := json.Unmarshal(data, &aux); err != nil {
+        return err
+    }
+
+    pc.PlaylistID = aux.PlaylistID
+    pc.CampaignID = aux.CampaignID
+    pc.PlaylisterId = aux.PlaylisterId
+    pc.ReferenceArtists = nullString(aux.ReferenceArtists)
+    pc.PlacementStatus = nullString(aux.PlacementStatus)
+    pc.Purchased = aux.Purchased
+    pc.Assignee = nullString(aux.Assignee)
+    return nil
+}
@@ -3,19 +3,28 @@ package models
 import (
 	"database/sql"
 	"encoding/json"
+
+	"github.com/alanowatson/LeadGenAPI/internal/contact"
+	"github.com/alanowatson/LeadGenAPI/internal/spotify"
 )
 
 type Playlister struct {
     ID                int            `json:"playlisterid"`
-    SpotifyUserID     sql.NullString `json:"spotifyuserid" validate:"required,min=5,max=50"`
+    SpotifyUserID     sql.NullString `json:"spotifyuserid" validate:"required,min=5,max=50,spotify_user"`
     CuratorFullName   sql.NullString `json:"curatorfullname" validate:"required,min=2,max=100"`
     Email             sql.NullString `json:"email" validate:"required,email"`
     Instagram         sql.NullString `json:"instagram" validate:"omitempty,min=3,max=30"`
     Facebook          sql.NullString `json:"facebook" validate:"omitempty,min=5,max=50"`
     Whatsapp          sql.NullString `json:"whatsapp" validate:"omitempty,e164"`
-    LastContacted     sql.NullString `json:"lastcontacted" validate:"omitempty,datetime=2006-01-02"`
+    // LastContacted is the day of the latest message logged with the
+    // curator, in either direction. The store reads it from the messages.
+    LastContacted     sql.NullString `json:"lastcontacted"`
     PreferredLanguage sql.NullString `json:"preferredlanguage" validate:"required,iso639_1"`
     FollowupStatus    sql.NullString `json:"followupstatus" validate:"required,oneof=Pending InProgress Completed"`
+    // DeletedAt is set while the record is in the trash.
+    DeletedAt         sql.NullString `json:"deleted_at"`
+    // Version is bumped by every update and travels as the ETag header.
+    Version           int            `json:"-"`
 }
 
 // MarshalJSON implements a custom JSON marshaler for Playlister
@@ -31,6 +40,10 @@ func (p Playlister) MarshalJSON() ([]byte, error) {
         LastContacted     string `json:"lastcontacted"`
         PreferredLanguage string `json:"preferredlanguage"`
         FollowupStatus    string `json:"followupstatus"`
+        DeletedAt         string `json:"deleted_at"`
+        InstagramURL      string `json:"instagram_url,omitempty"`
+        FacebookURL       string `json:"facebook_url,omitempty"`
+        WhatsappURL       string `json:"whatsapp_url,omitempty"`
     }{
         ID:                p.ID,
         SpotifyUserID:     stringOrEmpty(p.SpotifyUserID),
@@ -42,9 +55,68 @@ func (p Playlister) MarshalJSON() ([]byte, error) {
         LastContacted:     stringOrEmpty(p.LastContacted),
         PreferredLanguage: stringOrEmpty(p.PreferredLanguage),
         FollowupStatus:    stringOrEmpty(p.FollowupStatus),
+        DeletedAt:         stringOrEmpty(p.DeletedAt),
+        InstagramURL:      contact.InstagramURL(stringOrEmpty(p.Instagram)),
+        FacebookURL:       contact.FacebookURL(stringOrEmpty(p.Facebook)),
+        WhatsappURL:       contact.WhatsappURL(stringOrEmpty(p.Whatsapp)),
+    })
+}
+
+// UnmarshalJSON implements a custom JSON unmarshaler for Playlister
+func (p *Playlister) UnmarshalJSON(data []byte) error {
+    var aux struct {
+        ID                int     `json:"playlisterid"`
+        SpotifyUserID     *string `json:"spotifyuserid"`
+        CuratorFullName   *string `json:"curatorfullname"`
+        Email             *string `json:"email"`
+        Instagram         *string `json:"instagram"`
+        Facebook          *string `json:"facebook"`
+        Whatsapp          *string `json:"whatsapp"`
+        PreferredLanguage *string `json:"preferredlanguage"`
+        FollowupStatus    *string `json:"followupstatus"`
+    }
+    if err := json.Unmarshal(data, &aux); err != nil {
+        return err
+    }
+
+    p.ID = aux.ID
+    p.SpotifyUserID = nullString(aux.SpotifyUserID)
+    p.CuratorFullName = nullString(aux.CuratorFullName)
+    p.Email = nullString(aux.Email)
+    p.Instagram = nullString(aux.Instagram)
+    p.Facebook = nullString(aux.Facebook)
+    p.Whatsapp = nullString(aux.Whatsapp)
+    p.PreferredLanguage = nullString(aux.PreferredLanguage)
+    p.FollowupStatus = nullString(aux.FollowupStatus)
+    return nil
+}
+
+// Normalize rewrites the Spotify user ID and contact details to their stored
+// form: the bare user ID, lowercase email, bare Instagram and Facebook
+// handles and an E.164 WhatsApp number, read in region when it has no country
+// code. It runs before validation.
+func (p *Playlister) Normalize(region string) {
+    p.SpotifyUserID = normalized(p.SpotifyUserID, func(s string) string {
+        return spotify.Canonical(s, spotify.User)
+    })
+    p.Email = normalized(p.Email, contact.Email)
+    p.Instagram = normalized(p.Instagram, contact.Instagram)
+    p.Facebook = normalized(p.Facebook, contact.Facebook)
+    p.Whatsapp = normalized(p.Whatsapp, func(number string) string {
+        return contact.Whatsapp(number, region)
     })
 }
 
+// normalized applies normalize to a non-NULL value, which becomes NULL when
+// nothing is left of it.
+func normalized(s sql.NullString, normalize func(string) string) sql.NullString {
+    if !s.Valid {
+        return s
+    }
+    v := normalize(s.String)
+    return nullString(&v)
+}
+
 // Helper function to handle NULL strings
 func stringOrEmpty(s sql.NullString) string {
     if !s.Valid || s.String == "NULL" {
@@ -52,3 +124,12 @@ func stringOrEmpty(s sql.NullString) string {
     }
     return s.String
 }
+
+// nullString converts an optional JSON string into a nullable column value.
+// Empty strings become NULL, mirroring stringOrEmpty on the way out.
+func nullString(s *string) sql.NullString {
+    if s == nil || *s == "" {
+        return sql.NullString{}
+    }
+    return sql.NullString{String: *s, Valid: true}
+}
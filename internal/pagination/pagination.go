@@ -11,9 +11,14 @@ const (
     maxPerPage     = 100
 )
 
+// PaginationParams selects a page either by number (Page) or, when Cursor is
+// set, by keyset: the page starts just after the row the cursor points at.
 type PaginationParams struct {
     Page    int
     PerPage int
+    Cursor  string
+    // SkipCount disables the total-count query (count=false).
+    SkipCount bool
 }
 
 func GetPaginationParams(r *http.Request) PaginationParams {
@@ -37,17 +42,33 @@ func GetPaginationParams(r *http.Request) PaginationParams {
         }
     }
 
+    if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+        params.Cursor = cursor
+        params.Page = defaultPage
+    }
+
+    if count := r.URL.Query().Get("count"); count != "" {
+        if include, err := strconv.ParseBool(count); err == nil {
+            params.SkipCount = !include
+        }
+    }
+
     return params
 }
 
-func PaginateSlice(slice interface{}, params PaginationParams) interface{} {
-    // Implementation depends on the reflect package to handle different slice types
-    // For simplicity, we'll assume we're working with []interface{} for now
-    items := slice.([]interface{})
+// Offset returns the number of rows to skip. Keyset pages never skip rows.
+func (p PaginationParams) Offset() int {
+    if p.Cursor != "" {
+        return 0
+    }
+    return (p.Page - 1) * p.PerPage
+}
 
-    start := (params.Page - 1) * params.PerPage
+// PaginateSlice returns the page of items selected by params' page number.
+func PaginateSlice[T any](items []T, params PaginationParams) []T {
+    start := params.Offset()
     if start > len(items) {
-        return []interface{}{}
+        return []T{}
     }
 
     end := start + params.PerPage
@@ -0,0 +1,168 @@
+// Package playlistsync keeps playlists in step with Spotify: it refreshes
+// their follower counts and names, and verifies that placed campaigns' tracks
+// are on them, in the background and on demand.
+package playlistsync
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "os"
+    "strconv"
+    "time"
+
+    "github.com/alanowatson/LeadGenAPI/internal/audit"
+    "github.com/alanowatson/LeadGenAPI/internal/models"
+    "github.com/alanowatson/LeadGenAPI/internal/spotify"
+    "github.com/alanowatson/LeadGenAPI/internal/store"
+)
+
+// actor is who background syncs are attributed to in the audit log.
+const actor = "spotify-sync"
+
+// Options tunes the background sync. Every Interval it refreshes up to
+// BatchSize playlists that have not been synced for StaleAfter, and verifies
+// up to BatchSize placements that have not been verified for as long.
+type Options struct {
+    Interval   time.Duration
+    StaleAfter time.Duration
+    BatchSize  int
+}
+
+// OptionsFromEnv reads SPOTIFY_SYNC_INTERVAL and SPOTIFY_SYNC_STALE_AFTER, as
+// Go durations, and SPOTIFY_SYNC_BATCH_SIZE. Unset or invalid values fall
+// back to a batch of 50 every minute, syncing each playlist once a day.
+func OptionsFromEnv() Options {
+    opts := Options{Interval: time.Minute, StaleAfter: 24 * time.Hour, BatchSize: 50}
+    if d, err := time.ParseDuration(os.Getenv("SPOTIFY_SYNC_INTERVAL")); err == nil && d > 0 {
+        opts.Interval = d
+    }
+    if d, err := time.ParseDuration(os.Getenv("SPOTIFY_SYNC_STALE_AFTER")); err == nil && d > 0 {
+        opts.StaleAfter = d
+    }
+    if n, err := strconv.Atoi(os.Getenv("SPOTIFY_SYNC_BATCH_SIZE")); err == nil && n > 0 {
+        opts.BatchSize = n
+    }
+    return opts
+}
+
+// ErrSpotify wraps the errors of Spotify calls other than rate limiting, to
+// tell them apart from the store's.
+var ErrSpotify = errors.New("Spotify request failed")
+
+// Syncer refreshes playlists from the Spotify Web API.
+type Syncer struct {
+    store  *store.Store
+    client *spotify.Client
+}
+
+func New(s *store.Store, client *spotify.Client) *Syncer {
+    return &Syncer{store: s, client: client}
+}
+
+// Sync refreshes one playlist. A playlist Spotify no longer has is marked as
+// deleted rather than failing. While Spotify is rate limiting, Sync fails
+// with a *spotify.RateLimitError without touching the playlist; other
+// failures to reach Spotify wrap ErrSpotify.
+func (s *Syncer) Sync(ctx context.Context, p models.Playlist) error {
+    result := store.PlaylistSync{At: time.Now()}
+    info, err := s.client.Playlist(ctx, p.PlaylistSpotifyId.String)
+    switch {
+    case err == nil:
+        result.Found = true
+        result.Name = info.Name
+        result.Followers = info.Followers
+    case errors.Is(err, spotify.ErrNotFound):
+        log.Printf("Spotify reports playlist %d (%s) as deleted", p.ID, p.PlaylistSpotifyId.String)
+    default:
+        return spotifyError(err)
+    }
+    return s.store.Playlists.Sync(ctx, p.ID, result)
+}
+
+// spotifyError wraps a failed Spotify call in ErrSpotify, passing rate
+// limiting through as is.
+func spotifyError(err error) error {
+    var limited *spotify.RateLimitError
+    if errors.As(err, &limited) {
+        return err
+    }
+    return fmt.Errorf("%w: %v", ErrSpotify, err)
+}
+
+// Run syncs a batch of stale playlists and verifies a batch of placements
+// every opts.Interval until ctx is done. A rate-limited batch waits out the
+// Retry-After and carries on where it was turned away.
+func (s *Syncer) Run(ctx context.Context, opts Options) {
+    ctx = audit.WithActor(ctx, actor)
+    ticker := time.NewTicker(opts.Interval)
+    defer ticker.Stop()
+
+    for {
+        s.syncBatch(ctx, opts)
+        s.verifyBatch(ctx, opts)
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+func (s *Syncer) syncBatch(ctx context.Context, opts Options) {
+    playlists, err := s.store.Playlists.Stale(ctx, time.Now().Add(-opts.StaleAfter), opts.BatchSize)
+    if err != nil {
+        log.Printf("Error listing playlists to sync: %v", err)
+        return
+    }
+
+    synced := 0
+    for _, p := range playlists {
+        err := retrying(ctx, func() error { return s.Sync(ctx, p) })
+        if ctx.Err() != nil {
+            return
+        }
+        if err != nil {
+            log.Printf("Error syncing playlist %d: %v", p.ID, err)
+            // Move it to the back of the queue rather than retrying it first
+            // on every run.
+            if err := s.store.Playlists.SyncFailed(ctx, p.ID, time.Now()); err != nil {
+                log.Printf("Error noting failed sync of playlist %d: %v", p.ID, err)
+            }
+            continue
+        }
+        synced++
+    }
+    if len(playlists) > 0 {
+        log.Printf("Synced %d of %d stale playlists with Spotify", synced, len(playlists))
+    }
+}
+
+// retrying calls fn until Spotify stops rate limiting it, waiting out each
+// Retry-After, and returns its last error, or ctx's if ctx ends first.
+func retrying(ctx context.Context, fn func() error) error {
+    for {
+        err := fn()
+        var limited *spotify.RateLimitError
+        if !errors.As(err, &limited) {
+            return err
+        }
+        log.Printf("Spotify sync rate limited, pausing for %s", limited.RetryAfter)
+        if !sleep(ctx, limited.RetryAfter) {
+            return ctx.Err()
+        }
+    }
+}
+
+// sleep waits for d, returning false if ctx ends first.
+func sleep(ctx context.Context, d time.Duration) bool {
+    timer := time.NewTimer(d)
+    defer timer.Stop()
+    select {
+    case <-ctx.Done():
+        return false
+    case <-timer.C:
+        return true
+    }
+}
@@ -0,0 +1,154 @@
+package playlistsync
+
+import (
+    "context"
+    "errors"
+    "log"
+    "time"
+
+    "github.com/alanowatson/LeadGenAPI/internal/models"
+    "github.com/alanowatson/LeadGenAPI/internal/spotify"
+    "github.com/alanowatson/LeadGenAPI/internal/store"
+)
+
+// ErrNoTrack is returned for a campaign whose spotify_link is not a track,
+// so there is nothing to look for on its playlists.
+var ErrNoTrack = errors.New("campaign spotify_link is not a Spotify track")
+
+// PromotedTrack returns the bare ID of the track a campaign promotes.
+func PromotedTrack(c models.Campaign) (string, error) {
+    id, err := spotify.ParseAs(c.SpotifyLink.String, spotify.Track)
+    if !c.SpotifyLink.Valid || err != nil {
+        return "", ErrNoTrack
+    }
+    return id.ID, nil
+}
+
+// check is the placements on one playlist, verified against a single read
+// of its tracks.
+type check struct {
+    playlist   models.Playlist
+    placements []models.PlaylistCampaign
+    // tracks maps each placement's campaign to the track it promotes.
+    tracks map[int]string
+}
+
+// Verify looks for each placement's campaign track on its playlist and
+// records what it found, reading each playlist's tracks once. A playlist
+// Spotify no longer has carries no tracks. Placements on campaigns that do
+// not promote a track are skipped. Errors are as for Sync.
+func (s *Syncer) Verify(ctx context.Context, placements []models.PlaylistCampaign) error {
+    checks, err := s.checks(ctx, placements)
+    if err != nil {
+        return err
+    }
+    for _, ch := range checks {
+        if err := s.verify(ctx, ch); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// checks groups placements by playlist, in the order they are given.
+func (s *Syncer) checks(ctx context.Context, placements []models.PlaylistCampaign) ([]check, error) {
+    var playlistIDs, campaignIDs []int
+    for _, pc := range placements {
+        playlistIDs = append(playlistIDs, pc.PlaylistID)
+        campaignIDs = append(campaignIDs, pc.CampaignID)
+    }
+    playlists, err := s.store.Playlists.GetMany(ctx, playlistIDs)
+    if err != nil {
+        return nil, err
+    }
+    campaigns, err := s.store.Campaigns.GetMany(ctx, campaignIDs)
+    if err != nil {
+        return nil, err
+    }
+
+    var checks []check
+    index := map[int]int{}
+    for _, pc := range placements {
+        p, found := playlists[pc.PlaylistID]
+        if !found {
+            continue
+        }
+        track, err := PromotedTrack(campaigns[pc.CampaignID])
+        if err != nil {
+            continue
+        }
+        i, seen := index[pc.PlaylistID]
+        if !seen {
+            i = len(checks)
+            index[pc.PlaylistID] = i
+            checks = append(checks, check{playlist: p, tracks: map[int]string{}})
+        }
+        checks[i].placements = append(checks[i].placements, pc)
+        checks[i].tracks[pc.CampaignID] = track
+    }
+    return checks, nil
+}
+
+func (s *Syncer) verify(ctx context.Context, ch check) error {
+    onPlaylist := map[string]bool{}
+    tracks, err := s.client.PlaylistTracks(ctx, ch.playlist.PlaylistSpotifyId.String)
+    switch {
+    case err == nil:
+        for _, id := range tracks {
+            onPlaylist[id] = true
+        }
+    case errors.Is(err, spotify.ErrNotFound):
+        log.Printf("Spotify reports playlist %d (%s) as deleted", ch.playlist.ID, ch.playlist.PlaylistSpotifyId.String)
+    default:
+        return spotifyError(err)
+    }
+
+    at := time.Now()
+    for _, pc := range ch.placements {
+        result := store.PlacementCheck{Present: onPlaylist[ch.tracks[pc.CampaignID]], At: at}
+        if err := s.store.PlaylistCampaigns.Verify(ctx, pc.PlaylistID, pc.CampaignID, result); err != nil {
+            return err
+        }
+        if !result.Present && pc.PlacementStatus.String == models.PlacementPlaced {
+            log.Printf("Track of campaign %d not found on playlist %d", pc.CampaignID, pc.PlaylistID)
+        }
+    }
+    return nil
+}
+
+func (s *Syncer) verifyBatch(ctx context.Context, opts Options) {
+    placements, err := s.store.PlaylistCampaigns.Unverified(ctx, time.Now().Add(-opts.StaleAfter), opts.BatchSize)
+    if err != nil {
+        log.Printf("Error listing placements to verify: %v", err)
+        return
+    }
+    checks, err := s.checks(ctx, placements)
+    if err != nil {
+        log.Printf("Error loading placements to verify: %v", err)
+        return
+    }
+
+    verified := 0
+    for _, ch := range checks {
+        err := retrying(ctx, func() error { return s.verify(ctx, ch) })
+        if ctx.Err() != nil {
+            return
+        }
+        if err != nil {
+            log.Printf("Error verifying placements on playlist %d: %v", ch.playlist.ID, err)
+            // Move them to the back of the queue rather than retrying them
+            // first on every run.
+            at := time.Now()
+            for _, pc := range ch.placements {
+                if err := s.store.PlaylistCampaigns.VerifyFailed(ctx, pc.PlaylistID, pc.CampaignID, at); err != nil {
+                    log.Printf("Error noting failed check of placement %d/%d: %v", pc.PlaylistID, pc.CampaignID, err)
+                }
+            }
+            continue
+        }
+        verified += len(ch.placements)
+    }
+    if len(placements) > 0 {
+        log.Printf("Verified %d of %d placements with Spotify", verified, len(placements))
+    }
+}
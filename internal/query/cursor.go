@@ -0,0 +1,128 @@
+package query
+
+import (
+    "encoding/base64"
+    "encoding/json"
+    "fmt"
+    "strings"
+)
+
+// cursorPayload is the decoded form of an opaque pagination cursor. It
+// records the sort it was issued for and the sort-key values of the last row
+// on the page.
+type cursorPayload struct {
+    Sort   string        `json:"s"`
+    Values []interface{} `json:"v"`
+}
+
+// signature identifies a sort order so cursors cannot be replayed against a
+// different one.
+func (s Spec) signature() string {
+    parts := make([]string, len(s.Sort))
+    for i, k := range s.Sort {
+        parts[i] = k.Field.Name
+        if k.Desc {
+            parts[i] = "-" + parts[i]
+        }
+    }
+    return strings.Join(parts, ",")
+}
+
+// EncodeCursor returns an opaque token pointing just past r in this sort order.
+func (s Spec) EncodeCursor(r Record) string {
+    payload := cursorPayload{Sort: s.signature(), Values: make([]interface{}, len(s.Sort))}
+    for i, k := range s.Sort {
+        payload.Values[i] = r[k.Field.Name]
+    }
+    data, _ := json.Marshal(payload)
+    return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor validates token against this sort order and returns the
+// record it points past. Only the sort-key fields are populated.
+func (s Spec) DecodeCursor(token string) (Record, error) {
+    data, err := base64.RawURLEncoding.DecodeString(token)
+    if err != nil {
+        return nil, errorf("invalid cursor")
+    }
+    var payload cursorPayload
+    if err := json.Unmarshal(data, &payload); err != nil {
+        return nil, errorf("invalid cursor")
+    }
+    if payload.Sort != s.signature() || len(payload.Values) != len(s.Sort) {
+        return nil, errorf("cursor does not match the requested sort")
+    }
+
+    r := make(Record, len(s.Sort))
+    for i, k := range s.Sort {
+        v, err := cursorValue(k.Field, payload.Values[i])
+        if err != nil {
+            return nil, err
+        }
+        r[k.Field.Name] = v
+    }
+    return r, nil
+}
+
+// cursorValue restores the Go type JSON decoding loses (numbers arrive as
+// float64) and rejects values of the wrong type.
+func cursorValue(field Field, v interface{}) (interface{}, error) {
+    if v == nil {
+        return nil, nil
+    }
+    switch field.Type {
+    case Int:
+        if f, ok := v.(float64); ok && f == float64(int(f)) {
+            return int(f), nil
+        }
+    case Float:
+        if f, ok := v.(float64); ok {
+            return f, nil
+        }
+    case Bool:
+        if b, ok := v.(bool); ok {
+            return b, nil
+        }
+    default:
+        if s, ok := v.(string); ok {
+            return s, nil
+        }
+    }
+    return nil, errorf("invalid cursor")
+}
+
+// After compiles a keyset condition selecting rows that sort strictly after
+// r, honoring each key's direction and Postgres' default NULL placement
+// (NULLS LAST ascending, NULLS FIRST descending).
+func (s Spec) After(r Record, args *Args) string {
+    var branches []string
+    for i, k := range s.Sort {
+        var terms []string
+        for _, prev := range s.Sort[:i] {
+            terms = append(terms, equalTo(prev.Field.Column, r[prev.Field.Name], args))
+        }
+        terms = append(terms, beyond(k, r[k.Field.Name], args))
+        branches = append(branches, "("+strings.Join(terms, " AND ")+")")
+    }
+    return "(" + strings.Join(branches, " OR ") + ")"
+}
+
+func equalTo(column string, v interface{}, args *Args) string {
+    if v == nil {
+        return column + " IS NULL"
+    }
+    return fmt.Sprintf("%s = %s", column, args.Add(v))
+}
+
+func beyond(k Sort, v interface{}, args *Args) string {
+    column := k.Field.Column
+    switch {
+    case v == nil && k.Desc:
+        return column + " IS NOT NULL"
+    case v == nil:
+        return "FALSE"
+    case k.Desc:
+        return fmt.Sprintf("%s < %s", column, args.Add(v))
+    }
+    return fmt.Sprintf("(%s > %s OR %s IS NULL)", column, args.Add(v), column)
+}
@@ -0,0 +1,60 @@
+package query
+
+import (
+    "errors"
+    "net/url"
+    "reflect"
+    "testing"
+)
+
+func TestCursor(t *testing.T) {
+    spec, err := Parse(url.Values{"sort": {"-rate,name"}}, testSchema)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    tests := []struct {
+        name   string
+        record Record
+        want   Record
+    }{
+        {"values", Record{"id": 3, "name": "Ann", "rate": 0.25, "active": true}, Record{"id": 3, "name": "Ann", "rate": 0.25}},
+        {"null", Record{"id": 4, "name": "Bob", "rate": nil}, Record{"id": 4, "name": "Bob", "rate": nil}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := spec.DecodeCursor(spec.EncodeCursor(tt.record))
+            if err != nil {
+                t.Fatal(err)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("DecodeCursor(EncodeCursor(%v)) = %v, want %v", tt.record, got, tt.want)
+            }
+            if spec.Less(tt.want, tt.record) || spec.Less(tt.record, tt.want) {
+                t.Errorf("decoded cursor %v does not sort level with %v", got, tt.record)
+            }
+        })
+    }
+
+    other, err := Parse(url.Values{"sort": {"rate,name"}}, testSchema)
+    if err != nil {
+        t.Fatal(err)
+    }
+    invalid := []struct {
+        name  string
+        token string
+    }{
+        {"not base64", "***"},
+        {"not json", "bm90IGpzb24"},
+        {"another sort", other.EncodeCursor(Record{"id": 1, "name": "Ann", "rate": 0.5})},
+        {"wrong type", spec.EncodeCursor(Record{"id": 1.5, "name": "Ann", "rate": 0.5})},
+    }
+    for _, tt := range invalid {
+        t.Run(tt.name, func(t *testing.T) {
+            var qerr *Error
+            if _, err := spec.DecodeCursor(tt.token); !errors.As(err, &qerr) {
+                t.Errorf("DecodeCursor(%q) error = %v, want a *query.Error", tt.token, err)
+            }
+        })
+    }
+}
@@ -0,0 +1,119 @@
+package query
+
+import "strings"
+
+// Record exposes a resource's field values by public name for in-memory
+// evaluation. A nil value represents SQL NULL.
+type Record map[string]interface{}
+
+// Match reports whether r satisfies every filter, following SQL semantics:
+// comparisons against NULL are false.
+func (s Spec) Match(r Record) bool {
+    for _, f := range s.Filters {
+        if !f.match(r[f.Field.Name]) {
+            return false
+        }
+    }
+    return true
+}
+
+func (f Filter) match(v interface{}) bool {
+    switch f.Op {
+    case IsNull:
+        return (v == nil) == f.Values[0].(bool)
+    case Ne:
+        return v == nil || compare(v, f.Values[0]) != 0
+    }
+    if v == nil {
+        return false
+    }
+
+    switch f.Op {
+    case Eq:
+        return compare(v, f.Values[0]) == 0
+    case Gt:
+        return compare(v, f.Values[0]) > 0
+    case Gte:
+        return compare(v, f.Values[0]) >= 0
+    case Lt:
+        return compare(v, f.Values[0]) < 0
+    case Lte:
+        return compare(v, f.Values[0]) <= 0
+    case In:
+        for _, candidate := range f.Values {
+            if compare(v, candidate) == 0 {
+                return true
+            }
+        }
+        return false
+    case Contains:
+        s, _ := v.(string)
+        return strings.Contains(strings.ToLower(s), strings.ToLower(f.Values[0].(string)))
+    }
+    return false
+}
+
+// Less orders two records by the sort keys. NULLs sort after every value in
+// ascending order and before them in descending order, as in Postgres.
+func (s Spec) Less(a, b Record) bool {
+    for _, k := range s.Sort {
+        c := compareNullable(a[k.Field.Name], b[k.Field.Name])
+        if c == 0 {
+            continue
+        }
+        if k.Desc {
+            return c > 0
+        }
+        return c < 0
+    }
+    return false
+}
+
+func compareNullable(a, b interface{}) int {
+    switch {
+    case a == nil && b == nil:
+        return 0
+    case a == nil:
+        return 1
+    case b == nil:
+        return -1
+    }
+    return compare(a, b)
+}
+
+// compare orders two non-nil values of the same field type. Dates are
+// YYYY-MM-DD strings and so compare correctly as text.
+func compare(a, b interface{}) int {
+    switch av := a.(type) {
+    case int:
+        bv := b.(int)
+        switch {
+        case av < bv:
+            return -1
+        case av > bv:
+            return 1
+        }
+        return 0
+    case float64:
+        bv := b.(float64)
+        switch {
+        case av < bv:
+            return -1
+        case av > bv:
+            return 1
+        }
+        return 0
+    case bool:
+        bv := b.(bool)
+        switch {
+        case av == bv:
+            return 0
+        case !av:
+            return -1
+        }
+        return 1
+    case string:
+        return strings.Compare(av, b.(string))
+    }
+    return 0
+}
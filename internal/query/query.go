@@ -0,0 +1,302 @@
+// Package query parses the filter and sort parameters accepted by list
+// endpoints and compiles them to parameterized SQL.
+//
+// Filters take the form field=value or field[op]=value, for example
+// followupstatus=Pending, numberoffollowers[gte]=10000 or
+// placementstatus[in]=Pitched,Placed. Sorting takes a comma separated list of
+// fields, each optionally prefixed with "-" for descending order:
+// sort=-numberoffollowers,playlistid.
+package query
+
+import (
+    "fmt"
+    "math"
+    "net/url"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+type FieldType int
+
+const (
+    String FieldType = iota
+    Int
+    Date
+    Bool
+    Float
+)
+
+// Field is a filterable and sortable attribute of a resource. Name is the
+// public (JSON) name and Column is the SQL expression it maps to.
+type Field struct {
+    Name   string
+    Column string
+    Type   FieldType
+}
+
+// Schema whitelists the fields of one resource.
+type Schema struct {
+    fields map[string]Field
+    // names lists the field names in the order they were declared.
+    names []string
+    // key is the primary key, appended to every sort so ordering is total.
+    key []Field
+}
+
+// NewSchema builds a schema from its fields; keyFields name the primary key.
+func NewSchema(fields []Field, keyFields ...string) Schema {
+    s := Schema{fields: make(map[string]Field, len(fields))}
+    for _, f := range fields {
+        s.fields[f.Name] = f
+        s.names = append(s.names, f.Name)
+    }
+    for _, name := range keyFields {
+        f, ok := s.fields[name]
+        if !ok {
+            panic("query: key field " + name + " is not in the schema")
+        }
+        s.key = append(s.key, f)
+    }
+    return s
+}
+
+// Field looks up a field by its public name.
+func (s Schema) Field(name string) (Field, bool) {
+    f, ok := s.fields[name]
+    return f, ok
+}
+
+// Names returns the public field names in the order the schema declares
+// them, which is the order the resource's JSON has them in.
+func (s Schema) Names() []string {
+    return append([]string(nil), s.names...)
+}
+
+// Equals builds an equality filter on the named field. It panics when the
+// field is not in the schema, since callers pass compile-time constants.
+func (s Schema) Equals(name string, v interface{}) Filter {
+    f, ok := s.fields[name]
+    if !ok {
+        panic("query: unknown field " + name)
+    }
+    return Filter{Field: f, Op: Eq, Values: []interface{}{v}}
+}
+
+// FieldNames returns the public field names in alphabetical order.
+func (s Schema) FieldNames() []string {
+    names := make([]string, 0, len(s.fields))
+    for name := range s.fields {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+type Operator string
+
+const (
+    Eq       Operator = "eq"
+    Ne       Operator = "ne"
+    Gt       Operator = "gt"
+    Gte      Operator = "gte"
+    Lt       Operator = "lt"
+    Lte      Operator = "lte"
+    In       Operator = "in"
+    Contains Operator = "contains"
+    IsNull   Operator = "null"
+)
+
+type Filter struct {
+    Field  Field
+    Op     Operator
+    Values []interface{}
+}
+
+type Sort struct {
+    Field Field
+    Desc  bool
+}
+
+// Spec is a parsed, validated set of filters and sort keys.
+type Spec struct {
+    Filters []Filter
+    Sort    []Sort
+}
+
+// Error reports a malformed or disallowed query parameter.
+type Error struct {
+    Message string
+}
+
+func (e *Error) Error() string {
+    return e.Message
+}
+
+func errorf(format string, args ...interface{}) error {
+    return &Error{Message: fmt.Sprintf(format, args...)}
+}
+
+// reserved parameters belong to other features and are never filters.
+var reserved = map[string]bool{
+    "page":     true,
+    "per_page": true,
+    "cursor":   true,
+    "count":    true,
+    "q":        true,
+    "sort":     true,
+    "include":  true,
+    "fields":   true,
+
+    "include_deleted": true,
+    "format":          true,
+}
+
+var filterKey = regexp.MustCompile(`^([a-z][a-z0-9_]*)(?:\[([a-z]+)\])?$`)
+
+// Parse validates params against schema. Unknown fields, unsupported
+// operators and values of the wrong type yield an *Error.
+func Parse(params url.Values, schema Schema) (Spec, error) {
+    var spec Spec
+
+    keys := make([]string, 0, len(params))
+    for key := range params {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+
+    for _, key := range keys {
+        if reserved[key] {
+            continue
+        }
+        match := filterKey.FindStringSubmatch(key)
+        if match == nil {
+            return Spec{}, errorf("invalid query parameter %q", key)
+        }
+        field, ok := schema.Field(match[1])
+        if !ok {
+            return Spec{}, errorf("unknown filter field %q", match[1])
+        }
+        op := Eq
+        if match[2] != "" {
+            op = Operator(match[2])
+        }
+        for _, raw := range params[key] {
+            filter, err := parseFilter(field, op, raw)
+            if err != nil {
+                return Spec{}, err
+            }
+            spec.Filters = append(spec.Filters, filter)
+        }
+    }
+
+    sortKeys, err := parseSort(params.Get("sort"), schema)
+    if err != nil {
+        return Spec{}, err
+    }
+    spec.Sort = sortKeys
+    return spec, nil
+}
+
+func parseFilter(field Field, op Operator, raw string) (Filter, error) {
+    switch op {
+    case Eq, Ne:
+    case Gt, Gte, Lt, Lte:
+        if field.Type == Bool {
+            return Filter{}, errorf("operator %s is not supported for %s", op, field.Name)
+        }
+    case Contains:
+        if field.Type != String {
+            return Filter{}, errorf("operator %s is only supported for text fields", op)
+        }
+    case In:
+        var values []interface{}
+        for _, part := range strings.Split(raw, ",") {
+            v, err := parseValue(field, part)
+            if err != nil {
+                return Filter{}, err
+            }
+            values = append(values, v)
+        }
+        return Filter{Field: field, Op: op, Values: values}, nil
+    case IsNull:
+        b, err := strconv.ParseBool(raw)
+        if err != nil {
+            return Filter{}, errorf("%s[null] expects true or false", field.Name)
+        }
+        return Filter{Field: field, Op: op, Values: []interface{}{b}}, nil
+    default:
+        return Filter{}, errorf("unknown operator %q", op)
+    }
+
+    v, err := parseValue(field, raw)
+    if err != nil {
+        return Filter{}, err
+    }
+    return Filter{Field: field, Op: op, Values: []interface{}{v}}, nil
+}
+
+// parseValue converts raw into the Go type used for field: string for
+// String and Date, int for Int, float64 for Float and bool for Bool.
+func parseValue(field Field, raw string) (interface{}, error) {
+    raw = strings.TrimSpace(raw)
+    switch field.Type {
+    case Int:
+        n, err := strconv.Atoi(raw)
+        if err != nil {
+            return nil, errorf("%s expects an integer, got %q", field.Name, raw)
+        }
+        return n, nil
+    case Float:
+        f, err := strconv.ParseFloat(raw, 64)
+        if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+            return nil, errorf("%s expects a number, got %q", field.Name, raw)
+        }
+        return f, nil
+    case Date:
+        if _, err := time.Parse("2006-01-02", raw); err != nil {
+            return nil, errorf("%s expects a date in YYYY-MM-DD format, got %q", field.Name, raw)
+        }
+        return raw, nil
+    case Bool:
+        b, err := strconv.ParseBool(raw)
+        if err != nil {
+            return nil, errorf("%s expects true or false, got %q", field.Name, raw)
+        }
+        return b, nil
+    }
+    return raw, nil
+}
+
+// parseSort reads a sort expression and appends any primary key fields not
+// already present so that ordering is deterministic.
+func parseSort(raw string, schema Schema) ([]Sort, error) {
+    var keys []Sort
+    seen := make(map[string]bool)
+
+    if raw != "" {
+        for _, part := range strings.Split(raw, ",") {
+            part = strings.TrimSpace(part)
+            desc := strings.HasPrefix(part, "-")
+            name := strings.TrimPrefix(part, "-")
+            field, ok := schema.Field(name)
+            if !ok {
+                return nil, errorf("unknown sort field %q", name)
+            }
+            if seen[name] {
+                return nil, errorf("sort field %q appears more than once", name)
+            }
+            seen[name] = true
+            keys = append(keys, Sort{Field: field, Desc: desc})
+        }
+    }
+
+    for _, f := range schema.key {
+        if !seen[f.Name] {
+            keys = append(keys, Sort{Field: f})
+        }
+    }
+    return keys, nil
+}
@@ -0,0 +1,194 @@
+package query
+
+import (
+    "errors"
+    "net/url"
+    "reflect"
+    "sort"
+    "testing"
+)
+
+var (
+    idField     = Field{Name: "id", Column: "id", Type: Int}
+    nameField   = Field{Name: "name", Column: "name", Type: String}
+    sinceField  = Field{Name: "since", Column: "since", Type: Date}
+    activeField = Field{Name: "active", Column: "active", Type: Bool}
+    rateField   = Field{Name: "rate", Column: "rate", Type: Float}
+
+    testSchema = NewSchema([]Field{idField, nameField, sinceField, activeField, rateField}, "id")
+)
+
+func TestParse(t *testing.T) {
+    tests := []struct {
+        name    string
+        query   string
+        want    Spec
+        wantErr bool
+    }{
+        {
+            name:  "empty sorts by key",
+            query: "",
+            want:  Spec{Sort: []Sort{{Field: idField}}},
+        },
+        {
+            name:  "equality",
+            query: "name=Ann",
+            want: Spec{
+                Filters: []Filter{{Field: nameField, Op: Eq, Values: []interface{}{"Ann"}}},
+                Sort:    []Sort{{Field: idField}},
+            },
+        },
+        {
+            name:  "typed operators",
+            query: "id[gte]=10&rate[lt]=0.5&since[lte]=2024-01-31&active=true",
+            want: Spec{
+                Filters: []Filter{
+                    {Field: activeField, Op: Eq, Values: []interface{}{true}},
+                    {Field: idField, Op: Gte, Values: []interface{}{10}},
+                    {Field: rateField, Op: Lt, Values: []interface{}{0.5}},
+                    {Field: sinceField, Op: Lte, Values: []interface{}{"2024-01-31"}},
+                },
+                Sort: []Sort{{Field: idField}},
+            },
+        },
+        {
+            name:  "in and null",
+            query: "id[in]=1, 2,3&name[null]=false",
+            want: Spec{
+                Filters: []Filter{
+                    {Field: idField, Op: In, Values: []interface{}{1, 2, 3}},
+                    {Field: nameField, Op: IsNull, Values: []interface{}{false}},
+                },
+                Sort: []Sort{{Field: idField}},
+            },
+        },
+        {
+            name:  "sort appends the key",
+            query: "sort=-rate,name",
+            want:  Spec{Sort: []Sort{{Field: rateField, Desc: true}, {Field: nameField}, {Field: idField}}},
+        },
+        {
+            name:  "sort on the key",
+            query: "sort=-id",
+            want:  Spec{Sort: []Sort{{Field: idField, Desc: true}}},
+        },
+        {
+            name:  "reserved parameters",
+            query: "page=2&per_page=10&q=ann&fields=name",
+            want:  Spec{Sort: []Sort{{Field: idField}}},
+        },
+        {name: "unknown field", query: "email=a@b.c", wantErr: true},
+        {name: "malformed key", query: "name[eq=Ann", wantErr: true},
+        {name: "unknown operator", query: "name[like]=Ann", wantErr: true},
+        {name: "integer", query: "id=one", wantErr: true},
+        {name: "number", query: "rate=NaN", wantErr: true},
+        {name: "date", query: "since=31/01/2024", wantErr: true},
+        {name: "bool", query: "active=maybe", wantErr: true},
+        {name: "ordering a bool", query: "active[gt]=true", wantErr: true},
+        {name: "contains on a number", query: "id[contains]=1", wantErr: true},
+        {name: "null expects a bool", query: "name[null]=yes", wantErr: true},
+        {name: "unknown sort field", query: "sort=email", wantErr: true},
+        {name: "repeated sort field", query: "sort=name,-name", wantErr: true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            params, err := url.ParseQuery(tt.query)
+            if err != nil {
+                t.Fatal(err)
+            }
+            got, err := Parse(params, testSchema)
+            if tt.wantErr {
+                var qerr *Error
+                if !errors.As(err, &qerr) {
+                    t.Fatalf("Parse(%q) error = %v, want a *query.Error", tt.query, err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("Parse(%q) error = %v", tt.query, err)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("Parse(%q) = %+v, want %+v", tt.query, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestMatch(t *testing.T) {
+    r := Record{"id": 7, "name": "Ann Lee", "since": "2024-01-31", "active": true, "rate": nil}
+
+    tests := []struct {
+        name  string
+        query string
+        want  bool
+    }{
+        {"no filters", "", true},
+        {"equal", "id=7", true},
+        {"not equal", "id=8", false},
+        {"ne", "id[ne]=8", true},
+        {"ne on null", "rate[ne]=0.5", true},
+        {"gt", "id[gt]=7", false},
+        {"gte", "id[gte]=7", true},
+        {"lt date", "since[lt]=2024-02-01", true},
+        {"lte date", "since[lte]=2024-01-30", false},
+        {"in", "id[in]=1,7", true},
+        {"not in", "id[in]=1,2", false},
+        {"contains folds case", "name[contains]=LEE", true},
+        {"does not contain", "name[contains]=bob", false},
+        {"bool", "active=false", false},
+        {"comparison with null", "rate[gte]=0", false},
+        {"is null", "rate[null]=true", true},
+        {"is not null", "name[null]=false", true},
+        {"every filter", "id=7&active=false", false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            params, _ := url.ParseQuery(tt.query)
+            spec, err := Parse(params, testSchema)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got := spec.Match(r); got != tt.want {
+                t.Errorf("Match(%q) = %v, want %v", tt.query, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestLess(t *testing.T) {
+    records := []Record{
+        {"id": 1, "name": "b", "rate": 0.5},
+        {"id": 2, "name": "a", "rate": nil},
+        {"id": 3, "name": "b", "rate": 0.1},
+        {"id": 4, "name": "a", "rate": 0.5},
+    }
+
+    tests := []struct {
+        sort string
+        want []int
+    }{
+        {"", []int{1, 2, 3, 4}},
+        {"-id", []int{4, 3, 2, 1}},
+        {"name", []int{2, 4, 1, 3}},
+        {"-name,-id", []int{3, 1, 4, 2}},
+        {"rate", []int{3, 1, 4, 2}},
+        {"-rate", []int{2, 1, 4, 3}},
+    }
+    for _, tt := range tests {
+        t.Run("sort="+tt.sort, func(t *testing.T) {
+            spec, err := Parse(url.Values{"sort": {tt.sort}}, testSchema)
+            if err != nil {
+                t.Fatal(err)
+            }
+            sorted := append([]Record(nil), records...)
+            sort.Slice(sorted, func(i, j int) bool { return spec.Less(sorted[i], sorted[j]) })
+            var got []int
+            for _, r := range sorted {
+                got = append(got, r["id"].(int))
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("sorted ids = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
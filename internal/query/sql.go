@@ -0,0 +1,86 @@
+package query
+
+import (
+    "fmt"
+    "strings"
+)
+
+// Args accumulates positional parameters for a single SQL statement.
+type Args struct {
+    Values []interface{}
+}
+
+// Add appends v and returns its placeholder.
+func (a *Args) Add(v interface{}) string {
+    a.Values = append(a.Values, v)
+    return fmt.Sprintf("$%d", len(a.Values))
+}
+
+// Conditions compiles the filters to SQL boolean expressions. Values are
+// always bound through args, never interpolated.
+func (s Spec) Conditions(args *Args) []string {
+    var conditions []string
+    for _, f := range s.Filters {
+        conditions = append(conditions, f.sql(args))
+    }
+    return conditions
+}
+
+// Where joins conditions into a WHERE clause, or returns "" when there are none.
+func Where(conditions []string) string {
+    if len(conditions) == 0 {
+        return ""
+    }
+    return "WHERE " + strings.Join(conditions, " AND ")
+}
+
+var sqlOperators = map[Operator]string{
+    Eq:  "=",
+    Ne:  "<>",
+    Gt:  ">",
+    Gte: ">=",
+    Lt:  "<",
+    Lte: "<=",
+}
+
+func (f Filter) sql(args *Args) string {
+    column := f.Field.Column
+    switch f.Op {
+    case In:
+        placeholders := make([]string, len(f.Values))
+        for i, v := range f.Values {
+            placeholders[i] = args.Add(v)
+        }
+        return fmt.Sprintf("%s IN (%s)", column, strings.Join(placeholders, ", "))
+    case Contains:
+        return fmt.Sprintf("%s ILIKE %s", column, args.Add("%"+escapeLike(f.Values[0].(string))+"%"))
+    case IsNull:
+        if f.Values[0].(bool) {
+            return column + " IS NULL"
+        }
+        return column + " IS NOT NULL"
+    case Ne:
+        // Treat NULL as different from any value, as callers expect.
+        return fmt.Sprintf("%s IS DISTINCT FROM %s", column, args.Add(f.Values[0]))
+    }
+    return fmt.Sprintf("%s %s %s", column, sqlOperators[f.Op], args.Add(f.Values[0]))
+}
+
+func escapeLike(s string) string {
+    return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// OrderBy compiles the sort keys into an ORDER BY clause.
+func (s Spec) OrderBy() string {
+    if len(s.Sort) == 0 {
+        return ""
+    }
+    parts := make([]string, len(s.Sort))
+    for i, k := range s.Sort {
+        parts[i] = k.Field.Column
+        if k.Desc {
+            parts[i] += " DESC"
+        }
+    }
+    return "ORDER BY " + strings.Join(parts, ", ")
+}
@@ -0,0 +1,193 @@
+// Package search turns free-text queries into accent-insensitive prefix
+// terms and renders highlighted snippets for matching text.
+//
+// The tokenization mirrors what Postgres does for the search_vector columns
+// (the "simple" configuration over unaccent()), so the in-memory store and
+// snippet highlighting agree with the database about what matches.
+package search
+
+import (
+    "fmt"
+    "strings"
+    "unicode"
+
+    "golang.org/x/text/runes"
+    "golang.org/x/text/transform"
+    "golang.org/x/text/unicode/norm"
+)
+
+const (
+    markStart  = "<mark>"
+    markEnd    = "</mark>"
+    maxSnippet = 160
+)
+
+// Fold lowercases s and strips diacritics, so "José" becomes "jose".
+func Fold(s string) string {
+    t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+    folded, _, err := transform.String(t, s)
+    if err != nil {
+        folded = s
+    }
+    return strings.ToLower(folded)
+}
+
+// isWordRune reports whether r belongs inside a word. '@' and '.' keep email
+// addresses and domains whole, as the Postgres parser does.
+func isWordRune(r rune) bool {
+    return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '@' || r == '.' || r == '_'
+}
+
+func trimWord(w string) string {
+    return strings.Trim(w, "._")
+}
+
+// Terms splits a query into distinct folded terms.
+func Terms(q string) []string {
+    var terms []string
+    seen := make(map[string]bool)
+    for _, w := range strings.FieldsFunc(Fold(q), func(r rune) bool { return !isWordRune(r) }) {
+        w = trimWord(w)
+        if w != "" && !seen[w] {
+            seen[w] = true
+            terms = append(terms, w)
+        }
+    }
+    return terms
+}
+
+// TSQuery renders terms as a to_tsquery expression in which every term must
+// match as a prefix. Terms never contain quotes, so quoting them is safe.
+func TSQuery(terms []string) string {
+    parts := make([]string, len(terms))
+    for i, t := range terms {
+        parts[i] = fmt.Sprintf("'%s':*", t)
+    }
+    return strings.Join(parts, " & ")
+}
+
+// words returns the folded words of text.
+func words(text string) []string {
+    var out []string
+    for _, w := range strings.FieldsFunc(Fold(text), func(r rune) bool { return !isWordRune(r) }) {
+        if w = trimWord(w); w != "" {
+            out = append(out, w)
+        }
+    }
+    return out
+}
+
+// Score returns how well texts match terms: 0 when some term matches no word
+// at all, otherwise the share of words that match any term.
+func Score(terms []string, texts ...string) float64 {
+    if len(terms) == 0 {
+        return 0
+    }
+    var all []string
+    for _, text := range texts {
+        all = append(all, words(text)...)
+    }
+
+    matched := make(map[string]bool)
+    hits := 0
+    for _, w := range all {
+        hit := false
+        for _, t := range terms {
+            if strings.HasPrefix(w, t) {
+                matched[t] = true
+                hit = true
+            }
+        }
+        if hit {
+            hits++
+        }
+    }
+    if len(matched) < len(terms) {
+        return 0
+    }
+    return float64(hits) / float64(len(all))
+}
+
+// Matches reports whether every term prefixes some word of texts.
+func Matches(terms []string, texts ...string) bool {
+    return Score(terms, texts...) > 0
+}
+
+// Snippet returns the first text containing a match, with matching words
+// wrapped in <mark> tags and long text trimmed around the first match.
+func Snippet(terms []string, texts ...string) string {
+    for _, text := range texts {
+        if snippet, ok := highlight(terms, text); ok {
+            return snippet
+        }
+    }
+    return ""
+}
+
+func highlight(terms []string, text string) (string, bool) {
+    rs := []rune(text)
+    var b strings.Builder
+    first := -1
+    for i := 0; i < len(rs); {
+        if !isWordRune(rs[i]) {
+            b.WriteRune(rs[i])
+            i++
+            continue
+        }
+        j := i
+        for j < len(rs) && isWordRune(rs[j]) {
+            j++
+        }
+        word := string(rs[i:j])
+        folded := trimWord(Fold(word))
+        hit := false
+        for _, t := range terms {
+            if folded != "" && strings.HasPrefix(folded, t) {
+                hit = true
+                break
+            }
+        }
+        if hit {
+            if first == -1 {
+                first = b.Len()
+            }
+            b.WriteString(markStart + word + markEnd)
+        } else {
+            b.WriteString(word)
+        }
+        i = j
+    }
+    if first == -1 {
+        return "", false
+    }
+    return trimAround(b.String(), first), true
+}
+
+// trimAround shortens s to roughly maxSnippet runes, keeping the byte offset
+// at in view and never cutting through a <mark> tag.
+func trimAround(s string, at int) string {
+    if len([]rune(s)) <= maxSnippet {
+        return s
+    }
+    start := at - maxSnippet/4
+    prefix := "…"
+    if start <= 0 {
+        start, prefix = 0, ""
+    }
+    for start > 0 && !isBoundary(s, start) {
+        start--
+    }
+    rest := []rune(s[start:])
+    if len(rest) <= maxSnippet {
+        return prefix + string(rest)
+    }
+    end := maxSnippet
+    for end < len(rest) && (strings.Count(string(rest[:end]), markStart) != strings.Count(string(rest[:end]), markEnd) || !unicode.IsSpace(rest[end])) {
+        end++
+    }
+    return prefix + string(rest[:end]) + "…"
+}
+
+func isBoundary(s string, i int) bool {
+    return i > 0 && s[i-1] == ' '
+}
@@ -0,0 +1,307 @@
+package spotify
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "net/url"
+    "os"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+const (
+    defaultAPIURL   = "https://api.spotify.com/v1"
+    defaultTokenURL = "https://accounts.spotify.com/api/token"
+)
+
+// ErrNotFound is returned when the Web API reports that an entity does not
+// exist, as it does for deleted playlists.
+var ErrNotFound = errors.New("not found on Spotify")
+
+// RateLimitError is returned while the Web API is rate limiting this client.
+// RetryAfter is how long it asked to be left alone.
+type RateLimitError struct {
+    RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+    return fmt.Sprintf("rate limited by Spotify, retry after %s", e.RetryAfter)
+}
+
+// Config holds the app credentials and endpoints of a Client. The URLs
+// default to Spotify's and can point at a local fake server instead.
+type Config struct {
+    ClientID     string
+    ClientSecret string
+    APIURL       string
+    TokenURL     string
+    HTTPClient   *http.Client
+}
+
+// ConfigFromEnv reads SPOTIFY_CLIENT_ID, SPOTIFY_CLIENT_SECRET,
+// SPOTIFY_API_URL and SPOTIFY_TOKEN_URL. ok is false when no client ID is
+// set, meaning the Spotify integration is turned off.
+func ConfigFromEnv() (cfg Config, ok bool) {
+    cfg = Config{
+        ClientID:     os.Getenv("SPOTIFY_CLIENT_ID"),
+        ClientSecret: os.Getenv("SPOTIFY_CLIENT_SECRET"),
+        APIURL:       os.Getenv("SPOTIFY_API_URL"),
+        TokenURL:     os.Getenv("SPOTIFY_TOKEN_URL"),
+    }
+    return cfg, cfg.ClientID != ""
+}
+
+// Client calls the Spotify Web API with an app token from the
+// client-credentials flow. The token is fetched on first use and renewed
+// when it expires or is rejected. Once the API answers 429, every call fails
+// with a RateLimitError until its Retry-After has passed, without reaching
+// the API. A Client is safe for concurrent use.
+type Client struct {
+    cfg Config
+
+    mu           sync.Mutex
+    token        string
+    expires      time.Time
+    blockedUntil time.Time
+}
+
+// NewClient returns a client for cfg, filling in the default endpoints.
+func NewClient(cfg Config) *Client {
+    if cfg.APIURL == "" {
+        cfg.APIURL = defaultAPIURL
+    }
+    if cfg.TokenURL == "" {
+        cfg.TokenURL = defaultTokenURL
+    }
+    cfg.APIURL = strings.TrimRight(cfg.APIURL, "/")
+    if cfg.HTTPClient == nil {
+        cfg.HTTPClient = &http.Client{Timeout: 15 * time.Second}
+    }
+    return &Client{cfg: cfg}
+}
+
+// PlaylistInfo is the part of a playlist's metadata the API tracks.
+type PlaylistInfo struct {
+    ID        string
+    Name      string
+    Followers int
+}
+
+// Playlist fetches the name and follower count of the playlist with the
+// given bare ID.
+func (c *Client) Playlist(ctx context.Context, id string) (PlaylistInfo, error) {
+    var body struct {
+        ID        string `json:"id"`
+        Name      string `json:"name"`
+        Followers struct {
+            Total int `json:"total"`
+        } `json:"followers"`
+    }
+    q := url.Values{"fields": {"id,name,followers.total"}}
+    if err := c.get(ctx, "/playlists/"+url.PathEscape(id), q, &body); err != nil {
+        return PlaylistInfo{}, err
+    }
+    return PlaylistInfo{ID: body.ID, Name: body.Name, Followers: body.Followers.Total}, nil
+}
+
+// tracksPageSize is the most tracks the API returns per page.
+const tracksPageSize = 100
+
+// PlaylistTracks returns the IDs of the tracks on the playlist with the given
+// bare ID, in playlist order, reading every page. Local files and tracks no
+// longer available have no ID and are left out.
+func (c *Client) PlaylistTracks(ctx context.Context, id string) ([]string, error) {
+    tracks := []string{}
+    for offset := 0; ; offset += tracksPageSize {
+        var page struct {
+            Total int `json:"total"`
+            Items []struct {
+                Track *struct {
+                    ID string `json:"id"`
+                } `json:"track"`
+            } `json:"items"`
+        }
+        q := url.Values{
+            "fields": {"total,items(track(id))"},
+            "limit":  {strconv.Itoa(tracksPageSize)},
+            "offset": {strconv.Itoa(offset)},
+        }
+        if err := c.get(ctx, "/playlists/"+url.PathEscape(id)+"/tracks", q, &page); err != nil {
+            return nil, err
+        }
+        for _, item := range page.Items {
+            if item.Track != nil && item.Track.ID != "" {
+                tracks = append(tracks, item.Track.ID)
+            }
+        }
+        if len(page.Items) == 0 || offset+len(page.Items) >= page.Total {
+            return tracks, nil
+        }
+    }
+}
+
+// get decodes the JSON answer to a GET of path into out, retrying once with
+// a fresh token when the current one is rejected.
+func (c *Client) get(ctx context.Context, path string, q url.Values, out interface{}) error {
+    if err := c.checkRateLimit(); err != nil {
+        return err
+    }
+
+    for attempt := 0; ; attempt++ {
+        token, err := c.accessToken(ctx)
+        if err != nil {
+            return err
+        }
+
+        target := c.cfg.APIURL + path
+        if len(q) > 0 {
+            target += "?" + q.Encode()
+        }
+        req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+        if err != nil {
+            return err
+        }
+        req.Header.Set("Authorization", "Bearer "+token)
+
+        resp, err := c.cfg.HTTPClient.Do(req)
+        if err != nil {
+            return fmt.Errorf("error calling Spotify: %w", err)
+        }
+        err = c.decode(resp, out)
+        if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+            c.dropToken(token)
+            continue
+        }
+        return err
+    }
+}
+
+// decode reads resp into out, turning error statuses into errors.
+func (c *Client) decode(resp *http.Response, out interface{}) error {
+    defer resp.Body.Close()
+
+    switch {
+    case resp.StatusCode == http.StatusNotFound:
+        return ErrNotFound
+    case resp.StatusCode == http.StatusTooManyRequests:
+        return c.rateLimited(resp.Header.Get("Retry-After"))
+    case resp.StatusCode >= 300:
+        return fmt.Errorf("Spotify answered %s: %s", resp.Status, apiMessage(resp.Body))
+    }
+    if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+        return fmt.Errorf("error decoding Spotify response: %w", err)
+    }
+    return nil
+}
+
+// accessToken returns the cached token, requesting a new one when there is
+// none or it is about to expire.
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    if c.token != "" && time.Now().Before(c.expires) {
+        return c.token, nil
+    }
+
+    form := url.Values{"grant_type": {"client_credentials"}}
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.TokenURL, strings.NewReader(form.Encode()))
+    if err != nil {
+        return "", err
+    }
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    req.SetBasicAuth(c.cfg.ClientID, c.cfg.ClientSecret)
+
+    resp, err := c.cfg.HTTPClient.Do(req)
+    if err != nil {
+        return "", fmt.Errorf("error requesting Spotify token: %w", err)
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return "", fmt.Errorf("Spotify token request answered %s: %s", resp.Status, apiMessage(resp.Body))
+    }
+
+    var body struct {
+        AccessToken string `json:"access_token"`
+        ExpiresIn   int    `json:"expires_in"`
+    }
+    if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+        return "", fmt.Errorf("error decoding Spotify token: %w", err)
+    }
+    if body.AccessToken == "" {
+        return "", errors.New("Spotify token response has no access_token")
+    }
+
+    // Renew a minute early so a token does not expire mid-request.
+    c.token = body.AccessToken
+    c.expires = time.Now().Add(time.Duration(body.ExpiresIn)*time.Second - time.Minute)
+    return c.token, nil
+}
+
+// dropToken forgets token unless another call has already replaced it.
+func (c *Client) dropToken(token string) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if c.token == token {
+        c.token = ""
+    }
+}
+
+func (c *Client) checkRateLimit() error {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if wait := time.Until(c.blockedUntil); wait > 0 {
+        return &RateLimitError{RetryAfter: wait}
+    }
+    return nil
+}
+
+// rateLimited holds off further calls for the Retry-After of a 429 answer,
+// which is either a number of seconds or an HTTP date. Without a usable one
+// it backs off for a second.
+func (c *Client) rateLimited(retryAfter string) error {
+    wait := time.Second
+    if seconds, err := strconv.Atoi(strings.TrimSpace(retryAfter)); err == nil && seconds > 0 {
+        wait = time.Duration(seconds) * time.Second
+    } else if at, err := http.ParseTime(retryAfter); err == nil && time.Until(at) > 0 {
+        wait = time.Until(at)
+    }
+
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if until := time.Now().Add(wait); until.After(c.blockedUntil) {
+        c.blockedUntil = until
+    }
+    return &RateLimitError{RetryAfter: wait}
+}
+
+// apiMessage extracts the message of a Web API error body, falling back to
+// the start of the raw body.
+func apiMessage(r io.Reader) string {
+    raw, _ := io.ReadAll(io.LimitReader(r, 4096))
+    var body struct {
+        Error struct {
+            Message string `json:"message"`
+        } `json:"error"`
+        Description string `json:"error_description"`
+    }
+    if json.Unmarshal(raw, &body) == nil {
+        if body.Error.Message != "" {
+            return body.Error.Message
+        }
+        if body.Description != "" {
+            return body.Description
+        }
+    }
+    msg := strings.TrimSpace(string(raw))
+    if len(msg) > 200 {
+        msg = msg[:200]
+    }
+    return msg
+}
@@ -0,0 +1,173 @@
+// Package spotify parses the identifiers people paste for Spotify entities —
+// open.spotify.com links, spotify: URIs and bare IDs — into their type and
+// canonical ID, and reads playlists and their tracks from the Web API.
+package spotify
+
+import (
+    "errors"
+    "fmt"
+    "net/url"
+    "regexp"
+    "strings"
+)
+
+// Type is the kind of Spotify entity an identifier names.
+type Type string
+
+const (
+    Playlist Type = "playlist"
+    User     Type = "user"
+    Track    Type = "track"
+    Album    Type = "album"
+    Artist   Type = "artist"
+    Show     Type = "show"
+    Episode  Type = "episode"
+)
+
+// base62Types have 22-character base62 IDs. User IDs are usernames instead.
+var base62Types = map[Type]bool{
+    Playlist: true,
+    Track:    true,
+    Album:    true,
+    Artist:   true,
+    Show:     true,
+    Episode:  true,
+}
+
+var (
+    base62ID = regexp.MustCompile(`^[0-9A-Za-z]{22}$`)
+    userID   = regexp.MustCompile(`^[0-9A-Za-z._-]{1,64}$`)
+)
+
+// ErrInvalid is returned for input that is not a Spotify link, URI or ID.
+var ErrInvalid = errors.New("not a Spotify link, URI or ID")
+
+// ID is a parsed Spotify identifier. Type is empty for a bare ID, whose type
+// the input does not say.
+type ID struct {
+    Type Type   `json:"type"`
+    ID   string `json:"id"`
+}
+
+// URI returns the spotify: URI of a typed ID.
+func (id ID) URI() string {
+    return "spotify:" + string(id.Type) + ":" + id.ID
+}
+
+// URL returns the open.spotify.com link of a typed ID.
+func (id ID) URL() string {
+    return "https://open.spotify.com/" + string(id.Type) + "/" + url.PathEscape(id.ID)
+}
+
+// Parse reads a Spotify identifier in any of the forms people paste:
+//
+//	https://open.spotify.com/playlist/37i9dQZF1DXcBWIGoYBM5M?si=...
+//	https://open.spotify.com/intl-de/track/..., .../embed/playlist/...
+//	https://open.spotify.com/user/someone/playlist/... (legacy)
+//	spotify:playlist:37i9dQZF1DXcBWIGoYBM5M, spotify:user:someone
+//	37i9dQZF1DXcBWIGoYBM5M
+//
+// A link or URI naming a playlist under its owner yields the playlist.
+func Parse(s string) (ID, error) {
+    s = strings.TrimSpace(s)
+    switch {
+    case strings.HasPrefix(strings.ToLower(s), "spotify:"):
+        return fromSegments(strings.Split(s[len("spotify:"):], ":"))
+    case strings.Contains(s, "/"):
+        return parseURL(s)
+    case base62ID.MatchString(s) || userID.MatchString(s):
+        return ID{ID: s}, nil
+    }
+    return ID{}, ErrInvalid
+}
+
+// ParseAs parses s and checks that it names an entity of type t. Bare IDs are
+// taken to be of type t.
+func ParseAs(s string, t Type) (ID, error) {
+    id, err := Parse(s)
+    if err != nil {
+        return ID{}, err
+    }
+    if id.Type == "" {
+        id.Type = t
+        if err := id.validate(); err != nil {
+            return ID{}, err
+        }
+    }
+    if id.Type != t {
+        return ID{}, fmt.Errorf("expected a Spotify %s, got a %s", t, id.Type)
+    }
+    return id, nil
+}
+
+// Canonical returns the bare ID of s as an entity of type t, or s unchanged
+// when it is not one, leaving validation to report it.
+func Canonical(s string, t Type) string {
+    id, err := ParseAs(s, t)
+    if err != nil {
+        return s
+    }
+    return id.ID
+}
+
+// CanonicalLink returns the open.spotify.com link of a typed link or URI, or
+// s unchanged when it is not one.
+func CanonicalLink(s string) string {
+    id, err := Parse(s)
+    if err != nil || id.Type == "" {
+        return s
+    }
+    return id.URL()
+}
+
+func parseURL(s string) (ID, error) {
+    if !strings.Contains(s, "://") {
+        s = "https://" + s
+    }
+    u, err := url.Parse(s)
+    if err != nil || !strings.EqualFold(u.Hostname(), "open.spotify.com") && !strings.EqualFold(u.Hostname(), "play.spotify.com") {
+        return ID{}, ErrInvalid
+    }
+
+    var segments []string
+    for _, seg := range strings.Split(u.Path, "/") {
+        if seg == "" || seg == "embed" || strings.HasPrefix(seg, "intl-") {
+            continue
+        }
+        seg, err := url.PathUnescape(seg)
+        if err != nil {
+            return ID{}, ErrInvalid
+        }
+        segments = append(segments, seg)
+    }
+    return fromSegments(segments)
+}
+
+// fromSegments reads type/id pairs, keeping the last: "user", "someone",
+// "playlist", "37i9..." names the playlist.
+func fromSegments(segments []string) (ID, error) {
+    if len(segments) == 0 || len(segments)%2 != 0 {
+        return ID{}, ErrInvalid
+    }
+    id := ID{Type: Type(strings.ToLower(segments[len(segments)-2])), ID: segments[len(segments)-1]}
+    if err := id.validate(); err != nil {
+        return ID{}, err
+    }
+    return id, nil
+}
+
+func (id ID) validate() error {
+    switch {
+    case base62Types[id.Type]:
+        if !base62ID.MatchString(id.ID) {
+            return fmt.Errorf("invalid Spotify %s ID %q", id.Type, id.ID)
+        }
+    case id.Type == User:
+        if !userID.MatchString(id.ID) {
+            return fmt.Errorf("invalid Spotify user ID %q", id.ID)
+        }
+    default:
+        return fmt.Errorf("unsupported Spotify type %q", id.Type)
+    }
+    return nil
+}
@@ -0,0 +1,59 @@
+package spotify
+
+import (
+    "errors"
+    "testing"
+)
+
+func TestParse(t *testing.T) {
+    const playlistID = "37i9dQZF1DXcBWIGoYBM5M"
+
+    tests := []struct {
+        name    string
+        input   string
+        want    ID
+        wantErr bool
+    }{
+        {"playlist link", "https://open.spotify.com/playlist/" + playlistID + "?si=abc", ID{Playlist, playlistID}, false},
+        {"link without scheme", "open.spotify.com/playlist/" + playlistID, ID{Playlist, playlistID}, false},
+        {"localized link", "https://open.spotify.com/intl-de/track/" + playlistID, ID{Track, playlistID}, false},
+        {"embed link", "https://open.spotify.com/embed/playlist/" + playlistID, ID{Playlist, playlistID}, false},
+        {"legacy playlist link", "https://open.spotify.com/user/someone/playlist/" + playlistID, ID{Playlist, playlistID}, false},
+        {"play host", "https://play.spotify.com/album/" + playlistID, ID{Album, playlistID}, false},
+        {"user link", "https://open.spotify.com/user/some.one", ID{User, "some.one"}, false},
+        {"escaped user link", "https://open.spotify.com/user/some%2Done", ID{User, "some-one"}, false},
+        {"uri", "spotify:playlist:" + playlistID, ID{Playlist, playlistID}, false},
+        {"uri in upper case", "Spotify:Artist:" + playlistID, ID{Artist, playlistID}, false},
+        {"user uri", "spotify:user:someone", ID{User, "someone"}, false},
+        {"legacy playlist uri", "spotify:user:someone:playlist:" + playlistID, ID{Playlist, playlistID}, false},
+        {"bare id", "  " + playlistID + " ", ID{ID: playlistID}, false},
+        {"bare username", "someone", ID{ID: "someone"}, false},
+        {"empty", "", ID{}, true},
+        {"other host", "https://example.com/playlist/" + playlistID, ID{}, true},
+        {"missing id", "https://open.spotify.com/playlist", ID{}, true},
+        {"short id", "spotify:playlist:37i9dQZF1", ID{}, true},
+        {"unsupported type", "spotify:genre:" + playlistID, ID{}, true},
+        {"not an id", "not a playlist", ID{}, true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := Parse(tt.input)
+            if tt.wantErr {
+                if err == nil {
+                    t.Errorf("Parse(%q) = %+v, want an error", tt.input, got)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("Parse(%q) error = %v", tt.input, err)
+            }
+            if got != tt.want {
+                t.Errorf("Parse(%q) = %+v, want %+v", tt.input, got, tt.want)
+            }
+        })
+    }
+
+    if _, err := Parse("https://example.com/x/y"); !errors.Is(err, ErrInvalid) {
+        t.Errorf("Parse of a foreign link error = %v, want ErrInvalid", err)
+    }
+}
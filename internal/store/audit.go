@@ -0,0 +1,59 @@
+package store
+
+import (
+    "context"
+    "fmt"
+    "strconv"
+
+    "github.com/alanowatson/LeadGenAPI/internal/audit"
+    "github.com/alanowatson/LeadGenAPI/internal/query"
+)
+
+// Audit entries name the entity by its resource path.
+const (
+    EntityPlaylister       = "playlisters"
+    EntityPlaylist         = "playlists"
+    EntityCampaign         = "campaigns"
+    EntityPlaylistCampaign = "playlistcampaigns"
+)
+
+// AuditRepository reads the history the other repositories record. Every
+// Create, Update and Delete appends an entry in the same transaction as the
+// write, attributed to audit.Actor of the write's context.
+type AuditRepository interface {
+    List(ctx context.Context, opts ListOptions) (Page[audit.Entry], error)
+    Each(ctx context.Context, opts ListOptions, fn func(audit.Entry) error) error
+}
+
+// Key renders a single-column key for an audit entry.
+func Key(id int) string {
+    return strconv.Itoa(id)
+}
+
+// PlacementKey renders a placement's key for an audit entry, in the same
+// playlist/campaign order as its route.
+func PlacementKey(playlistID, campaignID int) string {
+    return fmt.Sprintf("%d/%d", playlistID, campaignID)
+}
+
+// AuditSchema whitelists the audit fields that can be filtered and sorted.
+// at filters and sorts by the UTC day of the write.
+var AuditSchema = query.NewSchema([]query.Field{
+    {Name: "id", Column: "id", Type: query.Int},
+    {Name: "actor", Column: "actor", Type: query.String},
+    {Name: "at", Column: "(at AT TIME ZONE 'UTC')::date", Type: query.Date},
+    {Name: "entity", Column: "entity", Type: query.String},
+    {Name: "key", Column: "entity_key", Type: query.String},
+    {Name: "action", Column: "action", Type: query.String},
+}, "id")
+
+func AuditRecord(e audit.Entry) query.Record {
+    return query.Record{
+        "id":     e.ID,
+        "actor":  e.Actor,
+        "at":     e.At.UTC().Format("2006-01-02"),
+        "entity": e.Entity,
+        "key":    e.Key,
+        "action": string(e.Action),
+    }
+}
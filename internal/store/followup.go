@@ -0,0 +1,65 @@
+package store
+
+import (
+    "time"
+
+    "github.com/alanowatson/LeadGenAPI/internal/models"
+)
+
+// FollowupQuery selects the follow-ups DueFollowups returns: those due by
+// Until, at most Limit of them, for one assignee and channel when set.
+type FollowupQuery struct {
+    Assignee string
+    Channel  string
+    Until    time.Time
+    Limit    int
+}
+
+// Matches reports whether f passes the query's filters.
+func (q FollowupQuery) Matches(f models.Followup) bool {
+    if q.Assignee != "" && f.Assignee != q.Assignee {
+        return false
+    }
+    if q.Channel != "" && f.Channel != q.Channel {
+        return false
+    }
+    due, err := time.Parse(time.RFC3339, f.DueAt)
+    return err == nil && !due.After(q.Until)
+}
+
+// NextFollowup returns the next step of c's sequence for pc, given when its
+// latest outbound message was sent, or "" when it has none. Step n is the
+// placement's nth outbound message and is due its AfterDays after the one
+// before it; the first counts from StatusChangedAt. ok is false when the
+// placement is no longer followed up, or has had every step the sequence and
+// the campaign's cap allow.
+func NextFollowup(pc models.PlaylistCampaign, c models.Campaign, lastOutbound string) (f models.Followup, ok bool) {
+    steps := len(c.FollowupSequence)
+    if steps > c.MaxMessages {
+        steps = c.MaxMessages
+    }
+    if !models.FollowsUp(pc.PlacementStatus.String) || pc.NumberOfMessages >= steps {
+        return models.Followup{}, false
+    }
+
+    step := c.FollowupSequence[pc.NumberOfMessages]
+    from := pc.StatusChangedAt.String
+    if pc.NumberOfMessages > 0 && lastOutbound != "" {
+        from = lastOutbound
+    }
+    at, err := time.Parse(time.RFC3339, from)
+    if err != nil {
+        return models.Followup{}, false
+    }
+    return models.Followup{
+        PlaylistID:      pc.PlaylistID,
+        CampaignID:      pc.CampaignID,
+        PlaylisterID:    pc.PlaylisterId,
+        Assignee:        pc.Assignee.String,
+        PlacementStatus: pc.PlacementStatus.String,
+        Step:            pc.NumberOfMessages + 1,
+        Steps:           steps,
+        Channel:         step.Channel,
+        DueAt:           at.AddDate(0, 0, step.AfterDays).UTC().Format(time.RFC3339),
+    }, true
+}
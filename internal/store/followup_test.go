@@ -0,0 +1,71 @@
+package store
+
+import (
+    "database/sql"
+    "testing"
+
+    "github.com/alanowatson/LeadGenAPI/internal/models"
+)
+
+func TestNextFollowup(t *testing.T) {
+    campaign := models.Campaign{
+        ID:          2,
+        MaxMessages: 3,
+        FollowupSequence: models.Sequence{
+            {Channel: "email", AfterDays: 0},
+            {Channel: "instagram", AfterDays: 3},
+            {Channel: "email", AfterDays: 7},
+            {Channel: "whatsapp", AfterDays: 14},
+        },
+    }
+    placement := func(status string, sent int) models.PlaylistCampaign {
+        return models.PlaylistCampaign{
+            PlaylistID:       1,
+            CampaignID:       2,
+            PlaylisterId:     3,
+            PlacementStatus:  sql.NullString{String: status, Valid: true},
+            NumberOfMessages: sent,
+            Assignee:         sql.NullString{String: "ann", Valid: true},
+            StatusChangedAt:  sql.NullString{String: "2024-03-01T10:00:00Z", Valid: true},
+        }
+    }
+    due := func(step int, channel, at string) models.Followup {
+        return models.Followup{PlaylistID: 1, CampaignID: 2, PlaylisterID: 3, Assignee: "ann",
+            PlacementStatus: models.PlacementPitched, Step: step, Steps: 3, Channel: channel, DueAt: at}
+    }
+
+    tests := []struct {
+        name         string
+        pc           models.PlaylistCampaign
+        c            models.Campaign
+        lastOutbound string
+        want         models.Followup
+        ok           bool
+    }{
+        {"first step counts from the status change", placement(models.PlacementPitched, 0), campaign, "",
+            due(1, "email", "2024-03-01T10:00:00Z"), true},
+        {"later steps count from the last message", placement(models.PlacementPitched, 1), campaign, "2024-03-02T08:30:00+02:00",
+            due(2, "instagram", "2024-03-05T06:30:00Z"), true},
+        {"no message time falls back to the status change", placement(models.PlacementPitched, 2), campaign, "",
+            due(3, "email", "2024-03-08T10:00:00Z"), true},
+        {"cap ends the sequence early", placement(models.PlacementPitched, 3), campaign, "2024-03-09T10:00:00Z",
+            models.Followup{}, false},
+        {"sequence shorter than the cap", placement(models.PlacementPitched, 1),
+            models.Campaign{ID: 2, MaxMessages: 5, FollowupSequence: campaign.FollowupSequence[:1]}, "2024-03-02T10:00:00Z",
+            models.Followup{}, false},
+        {"no sequence", placement(models.PlacementPitched, 0), models.Campaign{ID: 2, MaxMessages: 3}, "",
+            models.Followup{}, false},
+        {"placed placements are not followed up", placement(models.PlacementPlaced, 0), campaign, "",
+            models.Followup{}, false},
+        {"invalid timestamp", models.PlaylistCampaign{PlacementStatus: sql.NullString{String: models.PlacementDrafted, Valid: true}},
+            campaign, "", models.Followup{}, false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, ok := NextFollowup(tt.pc, tt.c, tt.lastOutbound)
+            if got != tt.want || ok != tt.ok {
+                t.Errorf("NextFollowup() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.ok)
+            }
+        })
+    }
+}
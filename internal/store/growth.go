@@ -0,0 +1,89 @@
+package store
+
+import (
+    "database/sql"
+    "fmt"
+    "math"
+    "time"
+
+    "github.com/alanowatson/LeadGenAPI/internal/models"
+    "github.com/alanowatson/LeadGenAPI/internal/query"
+)
+
+// The growth columns call SQL functions defined by the follower snapshots
+// migration, so the database computes them for filters and sorts too.
+
+func growthColumn(days int) string {
+    return fmt.Sprintf("numberoffollowers - playlist_follower_baseline(playlistid, %d)", days)
+}
+
+func growthRateColumn(days int) string {
+    return fmt.Sprintf("playlist_follower_growth_rate(playlistid, numberoffollowers, %d)", days)
+}
+
+// FollowerGrowth computes a playlist's growth windows from its current count
+// and its snapshots, oldest first, as of now. Each window is measured from the
+// last snapshot recorded at or before its start or, when the history is
+// shorter than the window, from the first snapshot.
+func FollowerGrowth(current int, snapshots []models.FollowerSnapshot, now time.Time) models.FollowerGrowth {
+    var g models.FollowerGrowth
+    g.Days7, g.Rate7 = growth(current, snapshots, now.AddDate(0, 0, -7))
+    g.Days30, g.Rate30 = growth(current, snapshots, now.AddDate(0, 0, -30))
+    g.Days90, g.Rate90 = growth(current, snapshots, now.AddDate(0, 0, -90))
+    return g
+}
+
+// growth is the change from the baseline at start to current, and the same
+// change in percent rounded to two decimals.
+func growth(current int, snapshots []models.FollowerSnapshot, start time.Time) (sql.NullInt64, sql.NullFloat64) {
+    baseline, ok := followerBaseline(snapshots, start)
+    if !ok {
+        return sql.NullInt64{}, sql.NullFloat64{}
+    }
+    change := current - baseline
+    if baseline == 0 {
+        return sql.NullInt64{Int64: int64(change), Valid: true}, sql.NullFloat64{}
+    }
+    rate := math.Round(float64(change)*100/float64(baseline)*100) / 100
+    return sql.NullInt64{Int64: int64(change), Valid: true}, sql.NullFloat64{Float64: rate, Valid: true}
+}
+
+// followerBaseline mirrors the playlist_follower_baseline SQL function.
+func followerBaseline(snapshots []models.FollowerSnapshot, start time.Time) (int, bool) {
+    if len(snapshots) == 0 {
+        return 0, false
+    }
+    baseline := snapshots[0].Followers
+    for _, s := range snapshots {
+        at, err := time.Parse(time.RFC3339, s.RecordedAt)
+        if err != nil || at.After(start) {
+            break
+        }
+        baseline = s.Followers
+    }
+    return baseline, true
+}
+
+// growthRecord adds the growth fields to a playlist's record.
+func growthRecord(r query.Record, g models.FollowerGrowth) {
+    r["growth_7d"] = nullableInt(g.Days7)
+    r["growth_30d"] = nullableInt(g.Days30)
+    r["growth_90d"] = nullableInt(g.Days90)
+    r["growth_rate_7d"] = nullableFloat(g.Rate7)
+    r["growth_rate_30d"] = nullableFloat(g.Rate30)
+    r["growth_rate_90d"] = nullableFloat(g.Rate90)
+}
+
+func nullableInt(n sql.NullInt64) interface{} {
+    if !n.Valid {
+        return nil
+    }
+    return int(n.Int64)
+}
+
+func nullableFloat(f sql.NullFloat64) interface{} {
+    if !f.Valid {
+        return nil
+    }
+    return f.Float64
+}
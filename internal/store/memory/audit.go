@@ -0,0 +1,27 @@
+package memory
+
+import (
+    "context"
+
+    "github.com/alanowatson/LeadGenAPI/internal/audit"
+    "github.com/alanowatson/LeadGenAPI/internal/store"
+)
+
+var auditEntity = entity[audit.Entry]{
+    toRecord: store.AuditRecord,
+}
+
+type auditRepo struct {
+    *data
+}
+
+func (r *auditRepo) List(ctx context.Context, opts store.ListOptions) (store.Page[audit.Entry], error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    return list(r.data, r.audit, opts, auditEntity), nil
+}
+
+func (r *auditRepo) Each(ctx context.Context, opts store.ListOptions, fn func(audit.Entry) error) error {
+    return each(r.data, r.audit, opts, auditEntity, fn)
+}
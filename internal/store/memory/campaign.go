@@ -0,0 +1,169 @@
+package memory
+
+import (
+    "context"
+    "database/sql"
+
+    "github.com/alanowatson/LeadGenAPI/internal/audit"
+    "github.com/alanowatson/LeadGenAPI/internal/models"
+    "github.com/alanowatson/LeadGenAPI/internal/store"
+)
+
+var campaignEntity = entity[models.Campaign]{
+    toRecord:   store.CampaignRecord,
+    searchText: store.CampaignSearchText,
+    key:        "campaignid",
+}
+
+type campaignRepo struct {
+    *data
+}
+
+func (r *campaignRepo) List(ctx context.Context, opts store.ListOptions) (store.Page[models.Campaign], error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    return list(r.data, r.campaigns, opts, campaignEntity), nil
+}
+
+func (r *campaignRepo) Each(ctx context.Context, opts store.ListOptions, fn func(models.Campaign) error) error {
+    return each(r.data, r.campaigns, opts, campaignEntity, fn)
+}
+
+func (r *campaignRepo) Get(ctx context.Context, id int, fields ...string) (models.Campaign, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    c, found := r.campaigns[id]
+    if !found {
+        return models.Campaign{}, store.ErrNotFound
+    }
+    return pick(c, fields, "campaignid"), nil
+}
+
+func (r *campaignRepo) GetMany(ctx context.Context, ids []int) (map[int]models.Campaign, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    return getMany(r.campaigns, ids), nil
+}
+
+func (r *campaignRepo) Create(ctx context.Context, c *models.Campaign) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    c.ID = r.nextCampaignID
+    c.Version = 1
+    if c.MaxMessages == 0 {
+        c.MaxMessages = models.DefaultMaxMessages
+    }
+    r.nextCampaignID++
+    if err := r.record(ctx, audit.Create, store.EntityCampaign, store.Key(c.ID), nil, *c); err != nil {
+        return err
+    }
+    r.campaigns[c.ID] = *c
+    return nil
+}
+
+func (r *campaignRepo) Update(ctx context.Context, c *models.Campaign) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    existing, found := r.campaigns[c.ID]
+    if !found || existing.DeletedAt.Valid {
+        return store.ErrNotFound
+    }
+    if err := store.CheckVersion(existing.Version, c.Version); err != nil {
+        return err
+    }
+
+    c.Version = existing.Version + 1
+    if c.MaxMessages == 0 {
+        c.MaxMessages = existing.MaxMessages
+    }
+    if err := r.record(ctx, audit.Update, store.EntityCampaign, store.Key(c.ID), existing, *c); err != nil {
+        return err
+    }
+    r.campaigns[c.ID] = *c
+    return nil
+}
+
+func (r *campaignRepo) Delete(ctx context.Context, id, version int) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    existing, found := r.campaigns[id]
+    if !found || existing.DeletedAt.Valid {
+        return store.ErrNotFound
+    }
+    if err := store.CheckVersion(existing.Version, version); err != nil {
+        return err
+    }
+    if err := r.checkReferenced(id, false); err != nil {
+        return err
+    }
+
+    c := existing
+    c.DeletedAt = nowString()
+    c.Version++
+    if err := r.record(ctx, audit.Delete, store.EntityCampaign, store.Key(id), existing, c); err != nil {
+        return err
+    }
+    r.campaigns[id] = c
+    return nil
+}
+
+func (r *campaignRepo) Restore(ctx context.Context, id int) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    existing, found := r.campaigns[id]
+    if !found {
+        return store.ErrNotFound
+    }
+    if !existing.DeletedAt.Valid {
+        return store.ErrNotDeleted
+    }
+
+    c := existing
+    c.DeletedAt = sql.NullString{}
+    c.Version++
+    if err := r.record(ctx, audit.Restore, store.EntityCampaign, store.Key(id), existing, c); err != nil {
+        return err
+    }
+    r.campaigns[id] = c
+    return nil
+}
+
+func (r *campaignRepo) Purge(ctx context.Context, id int) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    existing, found := r.campaigns[id]
+    if !found {
+        return store.ErrNotFound
+    }
+    if !existing.DeletedAt.Valid {
+        return store.ErrNotDeleted
+    }
+    if err := r.checkReferenced(id, true); err != nil {
+        return err
+    }
+
+    if err := r.record(ctx, audit.Purge, store.EntityCampaign, store.Key(id), existing, nil); err != nil {
+        return err
+    }
+    delete(r.campaigns, id)
+    return nil
+}
+
+// checkReferenced rejects removing a campaign that still has placements.
+// Trashed ones only count when includeDeleted is set.
+func (r *campaignRepo) checkReferenced(id int, includeDeleted bool) error {
+    for key, pc := range r.playlistCampaigns {
+        if key.campaignID == id && (includeDeleted || !pc.DeletedAt.Valid) {
+            return referenced("playlistcampaigns")
+        }
+    }
+    return nil
+}
@@ -0,0 +1,53 @@
+package memory
+
+import (
+    "context"
+    "sort"
+
+    "github.com/alanowatson/LeadGenAPI/internal/models"
+    "github.com/alanowatson/LeadGenAPI/internal/store"
+)
+
+func (r *playlistCampaignRepo) DueFollowups(ctx context.Context, q store.FollowupQuery) ([]models.Followup, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    due := []models.Followup{}
+    for key, pc := range r.playlistCampaigns {
+        c, found := r.campaigns[pc.CampaignID]
+        if pc.DeletedAt.Valid || !found || c.DeletedAt.Valid {
+            continue
+        }
+        f, ok := store.NextFollowup(r.counted(pc), c, r.lastOutbound(key))
+        if ok && q.Matches(f) {
+            due = append(due, f)
+        }
+    }
+    // RFC 3339 UTC timestamps order as strings.
+    sort.Slice(due, func(i, j int) bool {
+        a, b := due[i], due[j]
+        if a.DueAt != b.DueAt {
+            return a.DueAt < b.DueAt
+        }
+        if a.PlaylistID != b.PlaylistID {
+            return a.PlaylistID < b.PlaylistID
+        }
+        return a.CampaignID < b.CampaignID
+    })
+    if len(due) > q.Limit {
+        due = due[:q.Limit]
+    }
+    return due, nil
+}
+
+// lastOutbound returns when the placement's latest outbound message was
+// sent, or "" when it has none. Callers must hold r.mu.
+func (r *playlistCampaignRepo) lastOutbound(key placementKey) string {
+    last := ""
+    for _, m := range r.messages[key] {
+        if m.Direction == models.MessageOutbound && m.SentAt > last {
+            last = m.SentAt
+        }
+    }
+    return last
+}
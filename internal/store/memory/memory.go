@@ -0,0 +1,267 @@
+// Package memory implements the store repositories with in-process maps. It
+// enforces the same keys, unique columns and foreign keys as the Postgres
+// schema so handlers behave identically against either backend.
+package memory
+
+import (
+    "context"
+    "database/sql"
+    "reflect"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/alanowatson/LeadGenAPI/internal/audit"
+    "github.com/alanowatson/LeadGenAPI/internal/models"
+    "github.com/alanowatson/LeadGenAPI/internal/query"
+    "github.com/alanowatson/LeadGenAPI/internal/search"
+    "github.com/alanowatson/LeadGenAPI/internal/store"
+)
+
+// placementKey identifies a PlaylistCampaign.
+type placementKey struct {
+    playlistID int
+    campaignID int
+}
+
+// data holds every table behind a single lock so cross-entity constraint
+// checks see a consistent snapshot.
+type data struct {
+    mu sync.RWMutex
+
+    playlisters       map[int]models.Playlister
+    playlists         map[int]models.Playlist
+    campaigns         map[int]models.Campaign
+    playlistCampaigns map[placementKey]models.PlaylistCampaign
+    audit             map[int]audit.Entry
+    // followers holds each playlist's follower snapshots, oldest first.
+    followers         map[int][]models.FollowerSnapshot
+    // syncFailed holds when each playlist last failed to sync.
+    syncFailed        map[int]time.Time
+    // transitions holds each placement's status changes, oldest first.
+    transitions       map[placementKey][]models.PlacementTransition
+    // messages holds each placement's message log in the order logged.
+    messages          map[placementKey][]models.Message
+    // verifyFailed holds when each placement last failed to be verified.
+    verifyFailed      map[placementKey]time.Time
+
+    nextPlaylisterID int
+    nextPlaylistID   int
+    nextCampaignID   int
+    nextAuditID      int
+    nextMessageID    int
+}
+
+// New returns an empty Store backed by memory.
+func New() *store.Store {
+    d := &data{
+        playlisters:       make(map[int]models.Playlister),
+        playlists:         make(map[int]models.Playlist),
+        campaigns:         make(map[int]models.Campaign),
+        playlistCampaigns: make(map[placementKey]models.PlaylistCampaign),
+        audit:             make(map[int]audit.Entry),
+        followers:         make(map[int][]models.FollowerSnapshot),
+        syncFailed:        make(map[int]time.Time),
+        transitions:       make(map[placementKey][]models.PlacementTransition),
+        messages:          make(map[placementKey][]models.Message),
+        verifyFailed:      make(map[placementKey]time.Time),
+        nextPlaylisterID:  1,
+        nextPlaylistID:    1,
+        nextCampaignID:    1,
+        nextAuditID:       1,
+        nextMessageID:     1,
+    }
+    return &store.Store{
+        Playlisters:       &playlisterRepo{d},
+        Playlists:         &playlistRepo{d},
+        Campaigns:         &campaignRepo{d},
+        PlaylistCampaigns: &playlistCampaignRepo{d},
+        Search:            &searchRepo{d},
+        Audit:             &auditRepo{d},
+    }
+}
+
+// getMany picks the values for ids out of m. Callers must hold d.mu.
+func getMany[V any](m map[int]V, ids []int) map[int]V {
+    found := make(map[int]V, len(ids))
+    for _, id := range ids {
+        if v, ok := m[id]; ok {
+            found[id] = v
+        }
+    }
+    return found
+}
+
+// pick zeroes the fields of v that are not named in fields or keys, keeping
+// deleted_at and the version, as the Postgres repositories load only those
+// columns. Fields go by their JSON names, which are their schema names. A nil
+// fields keeps v whole.
+func pick[V any](v V, fields []string, keys ...string) V {
+    if fields == nil {
+        return v
+    }
+    wanted := map[string]bool{"deleted_at": true}
+    for _, f := range fields {
+        wanted[f] = true
+    }
+    for _, k := range keys {
+        wanted[k] = true
+    }
+    rv := reflect.ValueOf(&v).Elem()
+    for i := 0; i < rv.NumField(); i++ {
+        f := rv.Type().Field(i)
+        // Fields the JSON leaves out, such as Version, are always loaded.
+        name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+        if name != "-" && !wanted[name] {
+            rv.Field(i).Set(reflect.Zero(f.Type))
+        }
+    }
+    return v
+}
+
+// entity tells list how to treat one model type.
+type entity[V any] struct {
+    // toRecord exposes the value's fields by schema name.
+    toRecord func(V) query.Record
+    // searchText lists its full-text fields; nil for unsearchable types.
+    searchText func(V) []string
+    // key is the schema field matched against placements for ViaPlacement,
+    // or "" when the type cannot be scoped that way.
+    key string
+}
+
+// entry is a value together with its record.
+type entry[V any] struct {
+    value  V
+    record query.Record
+}
+
+// matching filters and sorts the map's values the way the Postgres
+// repositories do in SQL, leaving the cursor to afterCursor. Callers must
+// hold d.mu.
+func matching[K comparable, V any](d *data, m map[K]V, opts store.ListOptions, e entity[V]) []entry[V] {
+    var entries []entry[V]
+    for _, v := range m {
+        if e.searchText != nil && len(opts.Search) > 0 && !search.Matches(opts.Search, e.searchText(v)...) {
+            continue
+        }
+        record := e.toRecord(v)
+        if !opts.IncludeDeleted && record["deleted_at"] != nil {
+            continue
+        }
+        if opts.ViaPlacement != nil && e.key != "" && !d.linked(*opts.ViaPlacement, e.key, record[e.key], opts.IncludeDeleted) {
+            continue
+        }
+        if opts.Query.Match(record) {
+            entries = append(entries, entry[V]{v, record})
+        }
+    }
+    sort.Slice(entries, func(i, j int) bool { return opts.Query.Less(entries[i].record, entries[j].record) })
+    return entries
+}
+
+// afterCursor drops the entries up to and including the one opts.After
+// points at.
+func afterCursor[V any](entries []entry[V], opts store.ListOptions) []entry[V] {
+    if opts.After == nil {
+        return entries
+    }
+    start := sort.Search(len(entries), func(i int) bool { return opts.Query.Less(opts.After, entries[i].record) })
+    return entries[start:]
+}
+
+// list pages the matching values. Callers must hold d.mu.
+func list[K comparable, V any](d *data, m map[K]V, opts store.ListOptions, e entity[V]) store.Page[V] {
+    entries := matching(d, m, opts, e)
+
+    total := len(entries)
+    if opts.Page.SkipCount {
+        total = -1
+    }
+    entries = afterCursor(entries, opts)
+
+    start := opts.Page.Offset()
+    if start > len(entries) {
+        start = len(entries)
+    }
+    end := start + opts.Limit()
+    if end > len(entries) {
+        end = len(entries)
+    }
+
+    rows := make([]V, 0, end-start)
+    for _, e := range entries[start:end] {
+        rows = append(rows, e.value)
+    }
+    return store.NewPage(rows, total, opts, e.toRecord)
+}
+
+// each calls fn with every matching value. The values are collected under
+// d.mu, which is released before fn runs so slow consumers do not hold up
+// writers.
+func each[K comparable, V any](d *data, m map[K]V, opts store.ListOptions, e entity[V], fn func(V) error) error {
+    d.mu.RLock()
+    entries := afterCursor(matching(d, m, opts, e), opts)
+    d.mu.RUnlock()
+
+    for _, e := range entries {
+        if err := fn(e.value); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// linked reports whether a placement joins the parent named by link to the
+// row whose key field holds id. Trashed placements only count when
+// includeDeleted is set.
+func (d *data) linked(link store.PlacementLink, key string, id interface{}, includeDeleted bool) bool {
+    for _, pc := range d.playlistCampaigns {
+        if pc.DeletedAt.Valid && !includeDeleted {
+            continue
+        }
+        record := store.PlaylistCampaignRecord(pc)
+        if record[link.Field] == link.ID && record[key] == id {
+            return true
+        }
+    }
+    return false
+}
+
+// record appends the audit entry for a write, which the caller then applies.
+// Callers must hold d.mu for writing.
+func (d *data) record(ctx context.Context, action audit.Action, entity, key string, before, after interface{}) error {
+    e, err := audit.NewEntry(ctx, action, entity, key, before, after)
+    if err != nil {
+        return err
+    }
+    d.appendEntry(e)
+    return nil
+}
+
+// appendEntry stores e under the next audit ID. Callers must hold d.mu for
+// writing.
+func (d *data) appendEntry(e audit.Entry) {
+    e.ID = d.nextAuditID
+    d.nextAuditID++
+    d.audit[e.ID] = e
+}
+
+// nowString returns the current time as a stored timestamp, for stamps such as
+// DeletedAt and StatusChangedAt.
+func nowString() sql.NullString {
+    return sql.NullString{String: time.Now().UTC().Format(time.RFC3339), Valid: true}
+}
+
+func unique(column string) error {
+    return &store.ConstraintError{Kind: store.Unique, Column: column}
+}
+
+func missing(column, table string) error {
+    return &store.ConstraintError{Kind: store.MissingReference, Column: column, Table: table}
+}
+
+func referenced(table string) error {
+    return &store.ConstraintError{Kind: store.StillReferenced, Table: table}
+}
@@ -0,0 +1,251 @@
+package memory
+
+import (
+    "context"
+    "database/sql"
+    "net/url"
+    "reflect"
+    "testing"
+    "time"
+
+    "github.com/alanowatson/LeadGenAPI/internal/models"
+    "github.com/alanowatson/LeadGenAPI/internal/pagination"
+    "github.com/alanowatson/LeadGenAPI/internal/query"
+    "github.com/alanowatson/LeadGenAPI/internal/store"
+)
+
+func valid(s string) sql.NullString {
+    return sql.NullString{String: s, Valid: true}
+}
+
+func TestGetFields(t *testing.T) {
+    ctx := context.Background()
+    s := New()
+    p := models.Playlister{
+        SpotifyUserID:     valid("user00001"),
+        CuratorFullName:   valid("Ann Lee"),
+        Email:             valid("ann@example.com"),
+        Instagram:         valid("annlee"),
+        PreferredLanguage: valid("en"),
+        FollowupStatus:    valid("Pending"),
+    }
+    if err := s.Playlisters.Create(ctx, &p); err != nil {
+        t.Fatal(err)
+    }
+
+    tests := []struct {
+        name   string
+        fields []string
+        want   models.Playlister
+    }{
+        {"all", nil, p},
+        {"named", []string{"email"}, models.Playlister{ID: p.ID, Email: p.Email, Version: p.Version}},
+        {"key only", []string{"playlisterid"}, models.Playlister{ID: p.ID, Version: p.Version}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := s.Playlisters.Get(ctx, p.ID, tt.fields...)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got != tt.want {
+                t.Errorf("Get(%v) = %+v, want %+v", tt.fields, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestListCursor(t *testing.T) {
+    ctx := context.Background()
+    s := New()
+    for _, name := range []string{"Cleo", "Ann", "Bob", "Ann", "Dee"} {
+        p := models.Playlister{CuratorFullName: valid(name), FollowupStatus: valid("Pending")}
+        if name == "Bob" {
+            p.FollowupStatus = valid("Done")
+        }
+        if err := s.Playlisters.Create(ctx, &p); err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    tests := []struct {
+        name  string
+        query string
+        want  []int
+    }{
+        {"key order", "", []int{1, 2, 3, 4, 5}},
+        {"ties broken by key", "sort=curatorfullname", []int{2, 4, 3, 1, 5}},
+        {"descending", "sort=-curatorfullname", []int{5, 1, 3, 2, 4}},
+        {"filtered", "followupstatus=Pending&sort=-curatorfullname", []int{5, 1, 2, 4}},
+        {"nothing matches", "curatorfullname=Eve", nil},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            params, _ := url.ParseQuery(tt.query)
+            spec, err := query.Parse(params, store.PlaylisterSchema)
+            if err != nil {
+                t.Fatal(err)
+            }
+            opts := store.ListOptions{Query: spec, Page: pagination.PaginationParams{Page: 1, PerPage: 2}}
+
+            var got []int
+            for pages := 0; pages < 5; pages++ {
+                page, err := s.Playlisters.List(ctx, opts)
+                if err != nil {
+                    t.Fatal(err)
+                }
+                for _, p := range page.Items {
+                    got = append(got, p.ID)
+                }
+                if page.NextCursor == "" {
+                    break
+                }
+                opts.Page.Cursor = page.NextCursor
+                if opts.After, err = spec.DecodeCursor(page.NextCursor); err != nil {
+                    t.Fatal(err)
+                }
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("listed %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestDueFollowups(t *testing.T) {
+    ctx := context.Background()
+    s := New()
+    owner := models.Playlister{CuratorFullName: valid("Ann Lee")}
+    if err := s.Playlisters.Create(ctx, &owner); err != nil {
+        t.Fatal(err)
+    }
+    c := models.Campaign{
+        CampaignName: valid("Spring"),
+        FollowupSequence: models.Sequence{
+            {Channel: "email", AfterDays: 0},
+            {Channel: "instagram", AfterDays: 3},
+        },
+    }
+    if err := s.Campaigns.Create(ctx, &c); err != nil {
+        t.Fatal(err)
+    }
+    for i, spotifyID := range []string{"37i9dQZF1DXcBWIGoYBM5M", "37i9dQZF1DX0XUsuxWHRQd", "37i9dQZF1DX4dyzvuaRJ0n"} {
+        p := models.Playlist{PlaylisterId: owner.ID, PlaylistSpotifyId: valid(spotifyID)}
+        if err := s.Playlists.Create(ctx, &p); err != nil {
+            t.Fatal(err)
+        }
+        pc := models.PlaylistCampaign{PlaylistID: p.ID, CampaignID: c.ID, PlaylisterId: owner.ID,
+            PlacementStatus: valid(models.PlacementPitched), Assignee: valid("ann")}
+        if err := s.PlaylistCampaigns.Create(ctx, &pc); err != nil {
+            t.Fatal(err)
+        }
+        // The first placement has had its first message, the second only a
+        // reply and the third both of its steps.
+        var messages []models.Message
+        switch i {
+        case 0:
+            messages = []models.Message{{Direction: models.MessageOutbound, SentAt: "2024-03-02T10:00:00Z"}}
+        case 1:
+            messages = []models.Message{{Direction: models.MessageInbound, SentAt: "2024-03-02T10:00:00Z"}}
+        case 2:
+            messages = []models.Message{
+                {Direction: models.MessageOutbound, SentAt: "2024-03-01T10:00:00Z"},
+                {Direction: models.MessageOutbound, SentAt: "2024-03-04T10:00:00Z"},
+            }
+        }
+        for _, m := range messages {
+            m.PlaylistID, m.CampaignID, m.Channel = p.ID, c.ID, "email"
+            if err := s.PlaylistCampaigns.LogMessage(ctx, &m); err != nil {
+                t.Fatal(err)
+            }
+        }
+    }
+
+    later := time.Now().Add(time.Hour)
+    tests := []struct {
+        name string
+        q    store.FollowupQuery
+        want [][2]int
+    }{
+        {"all due", store.FollowupQuery{Until: later, Limit: 10}, [][2]int{{1, 2}, {2, 1}}},
+        {"limit", store.FollowupQuery{Until: later, Limit: 1}, [][2]int{{1, 2}}},
+        {"until", store.FollowupQuery{Until: time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC), Limit: 10}, [][2]int{{1, 2}}},
+        {"not yet due", store.FollowupQuery{Until: time.Date(2024, 3, 5, 9, 59, 0, 0, time.UTC), Limit: 10}, nil},
+        {"channel", store.FollowupQuery{Channel: "email", Until: later, Limit: 10}, [][2]int{{2, 1}}},
+        {"assignee", store.FollowupQuery{Assignee: "bob", Until: later, Limit: 10}, nil},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            due, err := s.PlaylistCampaigns.DueFollowups(ctx, tt.q)
+            if err != nil {
+                t.Fatal(err)
+            }
+            var got [][2]int
+            for _, f := range due {
+                got = append(got, [2]int{f.PlaylistID, f.Step})
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("due (playlist, step) = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestFlaggedFields(t *testing.T) {
+    ctx := context.Background()
+    s := New()
+    owner := models.Playlister{CuratorFullName: valid("Ann Lee")}
+    if err := s.Playlisters.Create(ctx, &owner); err != nil {
+        t.Fatal(err)
+    }
+    p := models.Playlist{PlaylisterId: owner.ID, PlaylistSpotifyId: valid("37i9dQZF1DXcBWIGoYBM5M")}
+    if err := s.Playlists.Create(ctx, &p); err != nil {
+        t.Fatal(err)
+    }
+    c := models.Campaign{CampaignName: valid("Spring")}
+    if err := s.Campaigns.Create(ctx, &c); err != nil {
+        t.Fatal(err)
+    }
+    pc := models.PlaylistCampaign{PlaylistID: p.ID, CampaignID: c.ID, PlaylisterId: owner.ID,
+        PlacementStatus: valid(models.PlacementPitched)}
+    if err := s.PlaylistCampaigns.Create(ctx, &pc); err != nil {
+        t.Fatal(err)
+    }
+    pc.PlacementStatus = valid(models.PlacementPlaced)
+    if err := s.PlaylistCampaigns.Update(ctx, &pc); err != nil {
+        t.Fatal(err)
+    }
+    if err := s.PlaylistCampaigns.Verify(ctx, p.ID, c.ID, store.PlacementCheck{Present: false, At: time.Now()}); err != nil {
+        t.Fatal(err)
+    }
+
+    tests := []struct {
+        name string
+        load func(fields []string) (models.PlaylistCampaign, error)
+    }{
+        {"list", func(fields []string) (models.PlaylistCampaign, error) {
+            page, err := s.PlaylistCampaigns.List(ctx, store.ListOptions{
+                Page:   pagination.PaginationParams{Page: 1, PerPage: 10},
+                Fields: fields,
+            })
+            if err != nil || len(page.Items) != 1 {
+                return models.PlaylistCampaign{}, err
+            }
+            return page.Items[0], nil
+        }},
+        {"get", func(fields []string) (models.PlaylistCampaign, error) {
+            return s.PlaylistCampaigns.Get(ctx, p.ID, c.ID, fields...)
+        }},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := tt.load([]string{"flagged"})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if !got.Flagged() {
+                t.Errorf("placement loaded with fields=flagged is not flagged: %+v", got)
+            }
+        })
+    }
+}
@@ -0,0 +1,135 @@
+package memory
+
+import (
+    "context"
+    "database/sql"
+    "sort"
+    "time"
+
+    "github.com/alanowatson/LeadGenAPI/internal/audit"
+    "github.com/alanowatson/LeadGenAPI/internal/models"
+    "github.com/alanowatson/LeadGenAPI/internal/store"
+)
+
+func (r *playlistCampaignRepo) LogMessage(ctx context.Context, m *models.Message) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    key := placementKey{m.PlaylistID, m.CampaignID}
+    if pc, found := r.playlistCampaigns[key]; !found || pc.DeletedAt.Valid {
+        return store.ErrNotFound
+    }
+    sentAt, err := store.SentAt(*m)
+    if err != nil {
+        return err
+    }
+    if m.ExternalID != "" {
+        for _, logged := range r.messages {
+            for _, other := range logged {
+                if other.Channel == m.Channel && other.ExternalID == m.ExternalID {
+                    return unique("channel, external_id")
+                }
+            }
+        }
+    }
+
+    if m.Direction == models.MessageOutbound {
+        limit := r.campaigns[m.CampaignID].MaxMessages
+        if r.outbound(key) >= limit {
+            return &store.MessageCapError{Cap: limit}
+        }
+    }
+
+    m.ID = r.nextMessageID
+    r.nextMessageID++
+    m.SentAt = sentAt.Format(time.RFC3339)
+    m.Actor = audit.Actor(ctx)
+    r.messages[key] = append(r.messages[key], *m)
+    return nil
+}
+
+func (r *playlistCampaignRepo) Messages(ctx context.Context, playlistID, campaignID int) ([]models.Message, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    key := placementKey{playlistID, campaignID}
+    if _, found := r.playlistCampaigns[key]; !found {
+        return nil, store.ErrNotFound
+    }
+    messages := append([]models.Message{}, r.messages[key]...)
+    // Messages can be logged after the fact; RFC 3339 UTC times order as
+    // strings.
+    sort.SliceStable(messages, func(i, j int) bool {
+        return messages[i].SentAt < messages[j].SentAt
+    })
+    return messages, nil
+}
+
+// The stored placements and playlisters leave NumberOfMessages and
+// LastContacted unset; reads fill them in from the message log, as the
+// Postgres repositories select them.
+
+// outbound counts the placement's outbound messages. Callers must hold d.mu.
+func (d *data) outbound(key placementKey) int {
+    sent := 0
+    for _, m := range d.messages[key] {
+        if m.Direction == models.MessageOutbound {
+            sent++
+        }
+    }
+    return sent
+}
+
+// counted returns pc with NumberOfMessages read from its messages. Callers
+// must hold d.mu.
+func (d *data) counted(pc models.PlaylistCampaign) models.PlaylistCampaign {
+    pc.NumberOfMessages = d.outbound(keyOf(pc))
+    return pc
+}
+
+// withCounts copies the placements with their NumberOfMessages filled in.
+// Callers must hold d.mu.
+func (d *data) withCounts() map[placementKey]models.PlaylistCampaign {
+    placements := make(map[placementKey]models.PlaylistCampaign, len(d.playlistCampaigns))
+    for key, pc := range d.playlistCampaigns {
+        placements[key] = d.counted(pc)
+    }
+    return placements
+}
+
+// contacts maps each playlister to the UTC day of the latest message logged
+// for any of their placements. Callers must hold d.mu.
+func (d *data) contacts() map[int]string {
+    days := map[int]string{}
+    for key, logged := range d.messages {
+        id := d.playlistCampaigns[key].PlaylisterId
+        for _, m := range logged {
+            // RFC 3339 UTC timestamps start with their day.
+            if day := m.SentAt[:len("2006-01-02")]; day > days[id] {
+                days[id] = day
+            }
+        }
+    }
+    return days
+}
+
+// contacted returns p with LastContacted read from days, as contacts builds
+// it.
+func contacted(p models.Playlister, days map[int]string) models.Playlister {
+    p.LastContacted = sql.NullString{}
+    if day, found := days[p.ID]; found {
+        p.LastContacted = sql.NullString{String: day, Valid: true}
+    }
+    return p
+}
+
+// withContacts copies the playlisters with their LastContacted filled in.
+// Callers must hold d.mu.
+func (d *data) withContacts() map[int]models.Playlister {
+    days := d.contacts()
+    playlisters := make(map[int]models.Playlister, len(d.playlisters))
+    for id, p := range d.playlisters {
+        playlisters[id] = contacted(p, days)
+    }
+    return playlisters
+}
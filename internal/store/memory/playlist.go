@@ -0,0 +1,367 @@
+package memory
+
+import (
+    "context"
+    "database/sql"
+    "sort"
+    "time"
+
+    "github.com/alanowatson/LeadGenAPI/internal/audit"
+    "github.com/alanowatson/LeadGenAPI/internal/models"
+    "github.com/alanowatson/LeadGenAPI/internal/store"
+)
+
+var playlistEntity = entity[models.Playlist]{
+    toRecord:   store.PlaylistRecord,
+    searchText: store.PlaylistSearchText,
+    key:        "playlistid",
+}
+
+type playlistRepo struct {
+    *data
+}
+
+func (r *playlistRepo) List(ctx context.Context, opts store.ListOptions) (store.Page[models.Playlist], error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    return list(r.data, r.withGrowth(), opts, playlistEntity), nil
+}
+
+func (r *playlistRepo) Each(ctx context.Context, opts store.ListOptions, fn func(models.Playlist) error) error {
+    r.mu.RLock()
+    playlists := r.withGrowth()
+    r.mu.RUnlock()
+    return each(r.data, playlists, opts, playlistEntity, fn)
+}
+
+func (r *playlistRepo) Get(ctx context.Context, id int, fields ...string) (models.Playlist, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    p, found := r.playlists[id]
+    if !found {
+        return models.Playlist{}, store.ErrNotFound
+    }
+    p.Growth = pickGrowth(store.FollowerGrowth(p.NumberOfFollowers, r.followers[id], time.Now()), fields)
+    return pick(p, fields, "playlistid"), nil
+}
+
+// pickGrowth keeps the growth windows named in fields, as pick does for the
+// stored fields.
+func pickGrowth(g models.FollowerGrowth, fields []string) models.FollowerGrowth {
+    if fields == nil {
+        return g
+    }
+    var picked models.FollowerGrowth
+    for _, f := range fields {
+        switch f {
+        case "growth_7d":
+            picked.Days7 = g.Days7
+        case "growth_30d":
+            picked.Days30 = g.Days30
+        case "growth_90d":
+            picked.Days90 = g.Days90
+        case "growth_rate_7d":
+            picked.Rate7 = g.Rate7
+        case "growth_rate_30d":
+            picked.Rate30 = g.Rate30
+        case "growth_rate_90d":
+            picked.Rate90 = g.Rate90
+        }
+    }
+    return picked
+}
+
+func (r *playlistRepo) GetMany(ctx context.Context, ids []int) (map[int]models.Playlist, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    found := getMany(r.playlists, ids)
+    now := time.Now()
+    for id, p := range found {
+        p.Growth = store.FollowerGrowth(p.NumberOfFollowers, r.followers[id], now)
+        found[id] = p
+    }
+    return found, nil
+}
+
+func (r *playlistRepo) Followers(ctx context.Context, id int, since, until time.Time) ([]models.FollowerSnapshot, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    if _, found := r.playlists[id]; !found {
+        return nil, store.ErrNotFound
+    }
+    snapshots := []models.FollowerSnapshot{}
+    for _, s := range r.followers[id] {
+        at, _ := time.Parse(time.RFC3339, s.RecordedAt)
+        if (since.IsZero() || !at.Before(since)) && (until.IsZero() || at.Before(until)) {
+            snapshots = append(snapshots, s)
+        }
+    }
+    return snapshots, nil
+}
+
+// withGrowth copies the playlists with their Growth filled in. Callers must
+// hold r.mu.
+func (r *playlistRepo) withGrowth() map[int]models.Playlist {
+    now := time.Now()
+    playlists := make(map[int]models.Playlist, len(r.playlists))
+    for id, p := range r.playlists {
+        p.Growth = store.FollowerGrowth(p.NumberOfFollowers, r.followers[id], now)
+        playlists[id] = p
+    }
+    return playlists
+}
+
+// snapshotFollowers records the playlist's follower count. Callers must hold
+// r.mu for writing.
+func (r *playlistRepo) snapshotFollowers(p models.Playlist) {
+    r.followers[p.ID] = append(r.followers[p.ID], models.FollowerSnapshot{
+        PlaylistID: p.ID,
+        Followers:  p.NumberOfFollowers,
+        RecordedAt: time.Now().UTC().Format(time.RFC3339),
+    })
+}
+
+func (r *playlistRepo) Create(ctx context.Context, p *models.Playlist) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if err := r.checkConstraints(*p, 0); err != nil {
+        return err
+    }
+
+    p.ID = r.nextPlaylistID
+    p.Version = 1
+    r.nextPlaylistID++
+    if err := r.record(ctx, audit.Create, store.EntityPlaylist, store.Key(p.ID), nil, *p); err != nil {
+        return err
+    }
+    r.playlists[p.ID] = *p
+    r.snapshotFollowers(*p)
+    return nil
+}
+
+func (r *playlistRepo) Update(ctx context.Context, p *models.Playlist) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    existing, found := r.playlists[p.ID]
+    if !found || existing.DeletedAt.Valid {
+        return store.ErrNotFound
+    }
+    if err := store.CheckVersion(existing.Version, p.Version); err != nil {
+        return err
+    }
+    if err := r.checkConstraints(*p, p.ID); err != nil {
+        return err
+    }
+
+    p.Version = existing.Version + 1
+    p.LastSyncedAt = existing.LastSyncedAt
+    p.SpotifyDeletedAt = existing.SpotifyDeletedAt
+    if err := r.record(ctx, audit.Update, store.EntityPlaylist, store.Key(p.ID), existing, *p); err != nil {
+        return err
+    }
+    r.playlists[p.ID] = *p
+    if p.NumberOfFollowers != existing.NumberOfFollowers {
+        r.snapshotFollowers(*p)
+    }
+    return nil
+}
+
+func (r *playlistRepo) Stale(ctx context.Context, before time.Time, limit int) ([]models.Playlist, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    stale := []models.Playlist{}
+    tried := map[int]time.Time{}
+    for id, p := range r.playlists {
+        if p.DeletedAt.Valid || p.SpotifyDeletedAt.Valid {
+            continue
+        }
+        // Never tried ones keep the zero time and so sort first.
+        at, _ := time.Parse(time.RFC3339, p.LastSyncedAt.String)
+        if failed := r.syncFailed[id]; failed.After(at) {
+            at = failed
+        }
+        if !at.IsZero() && !at.Before(before) {
+            continue
+        }
+        tried[id] = at
+        stale = append(stale, p)
+    }
+    sort.Slice(stale, func(i, j int) bool {
+        a, b := tried[stale[i].ID], tried[stale[j].ID]
+        if !a.Equal(b) {
+            return a.Before(b)
+        }
+        return stale[i].ID < stale[j].ID
+    })
+    if len(stale) > limit {
+        stale = stale[:limit]
+    }
+    return stale, nil
+}
+
+func (r *playlistRepo) SyncFailed(ctx context.Context, id int, at time.Time) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if _, found := r.playlists[id]; !found {
+        return store.ErrNotFound
+    }
+    r.syncFailed[id] = at
+    return nil
+}
+
+func (r *playlistRepo) Sync(ctx context.Context, id int, result store.PlaylistSync) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    existing, found := r.playlists[id]
+    if !found || existing.DeletedAt.Valid {
+        return store.ErrNotFound
+    }
+
+    p := store.SyncedPlaylist(existing, result)
+    p.Version++
+    if err := r.record(ctx, audit.Update, store.EntityPlaylist, store.Key(id), existing, p); err != nil {
+        return err
+    }
+    r.playlists[id] = p
+    if p.NumberOfFollowers != existing.NumberOfFollowers {
+        r.snapshotFollowers(p)
+    }
+    return nil
+}
+
+// Delete trashes the playlist together with its live placements, stamping
+// them all with the same DeletedAt so Restore can bring them back as a unit.
+func (r *playlistRepo) Delete(ctx context.Context, id, version int) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    existing, found := r.playlists[id]
+    if !found || existing.DeletedAt.Valid {
+        return store.ErrNotFound
+    }
+    if err := store.CheckVersion(existing.Version, version); err != nil {
+        return err
+    }
+
+    deletedAt := nowString()
+    for key, pc := range r.playlistCampaigns {
+        if key.playlistID != id || pc.DeletedAt.Valid {
+            continue
+        }
+        trashed := pc
+        trashed.DeletedAt = deletedAt
+        trashed.Version++
+        if err := r.record(ctx, audit.Delete, store.EntityPlaylistCampaign, store.PlacementKey(key.playlistID, key.campaignID), pc, trashed); err != nil {
+            return err
+        }
+        r.playlistCampaigns[key] = trashed
+    }
+
+    p := existing
+    p.DeletedAt = deletedAt
+    p.Version++
+    if err := r.record(ctx, audit.Delete, store.EntityPlaylist, store.Key(id), existing, p); err != nil {
+        return err
+    }
+    r.playlists[id] = p
+    return nil
+}
+
+// Restore brings the playlist back together with the placements its Delete
+// trashed, skipping those whose campaign is still in the trash.
+func (r *playlistRepo) Restore(ctx context.Context, id int) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    existing, found := r.playlists[id]
+    if !found {
+        return store.ErrNotFound
+    }
+    if !existing.DeletedAt.Valid {
+        return store.ErrNotDeleted
+    }
+    if owner, found := r.playlisters[existing.PlaylisterId]; !found || owner.DeletedAt.Valid {
+        return missing("playlisterid", "playlisters")
+    }
+
+    p := existing
+    p.DeletedAt = sql.NullString{}
+    p.Version++
+    if err := r.record(ctx, audit.Restore, store.EntityPlaylist, store.Key(id), existing, p); err != nil {
+        return err
+    }
+    r.playlists[id] = p
+
+    for key, pc := range r.playlistCampaigns {
+        if key.playlistID != id || pc.DeletedAt != existing.DeletedAt {
+            continue
+        }
+        if c, found := r.campaigns[key.campaignID]; !found || c.DeletedAt.Valid {
+            continue
+        }
+        restored := pc
+        restored.DeletedAt = sql.NullString{}
+        restored.Version++
+        if err := r.record(ctx, audit.Restore, store.EntityPlaylistCampaign, store.PlacementKey(key.playlistID, key.campaignID), pc, restored); err != nil {
+            return err
+        }
+        r.playlistCampaigns[key] = restored
+    }
+    return nil
+}
+
+// Purge removes the trashed playlist and every placement on it for good.
+func (r *playlistRepo) Purge(ctx context.Context, id int) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    existing, found := r.playlists[id]
+    if !found {
+        return store.ErrNotFound
+    }
+    if !existing.DeletedAt.Valid {
+        return store.ErrNotDeleted
+    }
+
+    for key, pc := range r.playlistCampaigns {
+        if key.playlistID != id {
+            continue
+        }
+        if err := r.record(ctx, audit.Purge, store.EntityPlaylistCampaign, store.PlacementKey(key.playlistID, key.campaignID), pc, nil); err != nil {
+            return err
+        }
+        delete(r.playlistCampaigns, key)
+        delete(r.transitions, key)
+        delete(r.messages, key)
+        delete(r.verifyFailed, key)
+    }
+
+    if err := r.record(ctx, audit.Purge, store.EntityPlaylist, store.Key(id), existing, nil); err != nil {
+        return err
+    }
+    delete(r.playlists, id)
+    delete(r.followers, id)
+    delete(r.syncFailed, id)
+    return nil
+}
+
+func (r *playlistRepo) checkConstraints(p models.Playlist, selfID int) error {
+    if owner, found := r.playlisters[p.PlaylisterId]; !found || owner.DeletedAt.Valid {
+        return missing("playlisterid", "playlisters")
+    }
+    for id, existing := range r.playlists {
+        if id != selfID && sameValue(existing.PlaylistSpotifyId, p.PlaylistSpotifyId) {
+            return unique("playlistspotifyid")
+        }
+    }
+    return nil
+}
@@ -0,0 +1,335 @@
+package memory
+
+import (
+    "context"
+    "database/sql"
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/alanowatson/LeadGenAPI/internal/audit"
+    "github.com/alanowatson/LeadGenAPI/internal/models"
+    "github.com/alanowatson/LeadGenAPI/internal/store"
+)
+
+var playlistCampaignEntity = entity[models.PlaylistCampaign]{
+    toRecord: store.PlaylistCampaignRecord,
+}
+
+type playlistCampaignRepo struct {
+    *data
+}
+
+func keyOf(pc models.PlaylistCampaign) placementKey {
+    return placementKey{playlistID: pc.PlaylistID, campaignID: pc.CampaignID}
+}
+
+func (r *playlistCampaignRepo) List(ctx context.Context, opts store.ListOptions) (store.Page[models.PlaylistCampaign], error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    return list(r.data, r.withCounts(), opts, playlistCampaignEntity), nil
+}
+
+func (r *playlistCampaignRepo) Each(ctx context.Context, opts store.ListOptions, fn func(models.PlaylistCampaign) error) error {
+    r.mu.RLock()
+    placements := r.withCounts()
+    r.mu.RUnlock()
+    return each(r.data, placements, opts, playlistCampaignEntity, fn)
+}
+
+func (r *playlistCampaignRepo) Get(ctx context.Context, playlistID, campaignID int, fields ...string) (models.PlaylistCampaign, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    pc, found := r.playlistCampaigns[placementKey{playlistID, campaignID}]
+    if !found {
+        return models.PlaylistCampaign{}, store.ErrNotFound
+    }
+    return pick(r.counted(pc), store.PlacementFields(fields), "playlistid", "campaignid"), nil
+}
+
+func (r *playlistCampaignRepo) Create(ctx context.Context, pc *models.PlaylistCampaign) error {
+    if err := store.CheckTransition("", pc.PlacementStatus.String); err != nil {
+        return err
+    }
+
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if err := r.checkReferences(*pc); err != nil {
+        return err
+    }
+    if _, found := r.playlistCampaigns[keyOf(*pc)]; found {
+        return unique("playlistid, campaignid")
+    }
+
+    pc.Version = 1
+    pc.NumberOfMessages = 0
+    pc.StatusChangedAt = nowString()
+    if err := r.record(ctx, audit.Create, store.EntityPlaylistCampaign, store.PlacementKey(pc.PlaylistID, pc.CampaignID), nil, *pc); err != nil {
+        return err
+    }
+    r.playlistCampaigns[keyOf(*pc)] = *pc
+    r.addTransition(ctx, *pc, "", "")
+    return nil
+}
+
+func (r *playlistCampaignRepo) Update(ctx context.Context, pc *models.PlaylistCampaign) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if err := r.checkReferences(*pc); err != nil {
+        return err
+    }
+    existing, found := r.playlistCampaigns[keyOf(*pc)]
+    if !found || existing.DeletedAt.Valid {
+        return store.ErrNotFound
+    }
+    if err := store.CheckVersion(existing.Version, pc.Version); err != nil {
+        return err
+    }
+    existing = r.counted(existing)
+    from := existing.PlacementStatus.String
+    changed := pc.PlacementStatus.String != from
+    if changed {
+        if err := store.CheckTransition(from, pc.PlacementStatus.String); err != nil {
+            return err
+        }
+        pc.StatusChangedAt = nowString()
+    } else {
+        pc.StatusChangedAt = existing.StatusChangedAt
+    }
+
+    pc.Version = existing.Version + 1
+    pc.NumberOfMessages = existing.NumberOfMessages
+    pc.VerifiedAt = existing.VerifiedAt
+    pc.TrackPresent = existing.TrackPresent
+    pc.TrackFirstSeenAt = existing.TrackFirstSeenAt
+    pc.TrackRemovedAt = existing.TrackRemovedAt
+    if err := r.record(ctx, audit.Update, store.EntityPlaylistCampaign, store.PlacementKey(pc.PlaylistID, pc.CampaignID), existing, *pc); err != nil {
+        return err
+    }
+    r.playlistCampaigns[keyOf(*pc)] = *pc
+    if changed {
+        r.addTransition(ctx, *pc, from, "")
+    }
+    return nil
+}
+
+func (r *playlistCampaignRepo) Transition(ctx context.Context, playlistID, campaignID int, status, note string, version int) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    key := placementKey{playlistID, campaignID}
+    existing, found := r.playlistCampaigns[key]
+    if !found || existing.DeletedAt.Valid {
+        return store.ErrNotFound
+    }
+    if err := store.CheckVersion(existing.Version, version); err != nil {
+        return err
+    }
+    from := existing.PlacementStatus.String
+    if err := store.CheckTransition(from, status); err != nil {
+        return err
+    }
+
+    pc := existing
+    pc.PlacementStatus = sql.NullString{String: status, Valid: true}
+    pc.StatusChangedAt = nowString()
+    pc.Version++
+    if err := r.record(ctx, audit.Update, store.EntityPlaylistCampaign, store.PlacementKey(playlistID, campaignID), existing, pc); err != nil {
+        return err
+    }
+    r.playlistCampaigns[key] = pc
+    r.addTransition(ctx, pc, from, note)
+    return nil
+}
+
+func (r *playlistCampaignRepo) Transitions(ctx context.Context, playlistID, campaignID int) ([]models.PlacementTransition, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    key := placementKey{playlistID, campaignID}
+    if _, found := r.playlistCampaigns[key]; !found {
+        return nil, store.ErrNotFound
+    }
+    return append([]models.PlacementTransition{}, r.transitions[key]...), nil
+}
+
+// addTransition records that pc moved from the given status to its current
+// one at its StatusChangedAt. Callers must hold r.mu for writing.
+func (r *playlistCampaignRepo) addTransition(ctx context.Context, pc models.PlaylistCampaign, from, note string) {
+    key := keyOf(pc)
+    r.transitions[key] = append(r.transitions[key], models.PlacementTransition{
+        PlaylistID: pc.PlaylistID,
+        CampaignID: pc.CampaignID,
+        From:       from,
+        To:         pc.PlacementStatus.String,
+        Actor:      audit.Actor(ctx),
+        At:         pc.StatusChangedAt.String,
+        Note:       note,
+    })
+}
+
+func (r *playlistCampaignRepo) Unverified(ctx context.Context, before time.Time, limit int) ([]models.PlaylistCampaign, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    due := []models.PlaylistCampaign{}
+    tried := map[placementKey]time.Time{}
+    for key, pc := range r.playlistCampaigns {
+        if pc.DeletedAt.Valid || !store.NeedsVerification(pc) {
+            continue
+        }
+        if c, found := r.campaigns[pc.CampaignID]; !found || !strings.HasPrefix(c.SpotifyLink.String, store.TrackLinkPrefix) {
+            continue
+        }
+        // Never tried ones keep the zero time and so sort first.
+        at, _ := time.Parse(time.RFC3339, pc.VerifiedAt.String)
+        if failed := r.verifyFailed[key]; failed.After(at) {
+            at = failed
+        }
+        if !at.IsZero() && !at.Before(before) {
+            continue
+        }
+        tried[key] = at
+        due = append(due, r.counted(pc))
+    }
+    sort.Slice(due, func(i, j int) bool {
+        a, b := due[i], due[j]
+        if at, bt := tried[keyOf(a)], tried[keyOf(b)]; !at.Equal(bt) {
+            return at.Before(bt)
+        }
+        if a.PlaylistID != b.PlaylistID {
+            return a.PlaylistID < b.PlaylistID
+        }
+        return a.CampaignID < b.CampaignID
+    })
+    if len(due) > limit {
+        due = due[:limit]
+    }
+    return due, nil
+}
+
+func (r *playlistCampaignRepo) VerifyFailed(ctx context.Context, playlistID, campaignID int, at time.Time) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    key := placementKey{playlistID, campaignID}
+    if _, found := r.playlistCampaigns[key]; !found {
+        return store.ErrNotFound
+    }
+    r.verifyFailed[key] = at
+    return nil
+}
+
+func (r *playlistCampaignRepo) Verify(ctx context.Context, playlistID, campaignID int, check store.PlacementCheck) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    key := placementKey{playlistID, campaignID}
+    existing, found := r.playlistCampaigns[key]
+    if !found || existing.DeletedAt.Valid {
+        return store.ErrNotFound
+    }
+
+    pc := store.VerifiedPlacement(existing, check)
+    pc.Version++
+    if err := r.record(ctx, audit.Update, store.EntityPlaylistCampaign, store.PlacementKey(playlistID, campaignID), existing, pc); err != nil {
+        return err
+    }
+    r.playlistCampaigns[key] = pc
+    return nil
+}
+
+func (r *playlistCampaignRepo) Delete(ctx context.Context, playlistID, campaignID, version int) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    key := placementKey{playlistID, campaignID}
+    existing, found := r.playlistCampaigns[key]
+    if !found || existing.DeletedAt.Valid {
+        return store.ErrNotFound
+    }
+    if err := store.CheckVersion(existing.Version, version); err != nil {
+        return err
+    }
+
+    pc := existing
+    pc.DeletedAt = nowString()
+    pc.Version++
+    if err := r.record(ctx, audit.Delete, store.EntityPlaylistCampaign, store.PlacementKey(playlistID, campaignID), existing, pc); err != nil {
+        return err
+    }
+    r.playlistCampaigns[key] = pc
+    return nil
+}
+
+func (r *playlistCampaignRepo) Restore(ctx context.Context, playlistID, campaignID int) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    key := placementKey{playlistID, campaignID}
+    existing, found := r.playlistCampaigns[key]
+    if !found {
+        return store.ErrNotFound
+    }
+    if !existing.DeletedAt.Valid {
+        return store.ErrNotDeleted
+    }
+    if err := r.checkReferences(existing); err != nil {
+        return err
+    }
+
+    pc := existing
+    pc.DeletedAt = sql.NullString{}
+    pc.Version++
+    if err := r.record(ctx, audit.Restore, store.EntityPlaylistCampaign, store.PlacementKey(playlistID, campaignID), existing, pc); err != nil {
+        return err
+    }
+    r.playlistCampaigns[key] = pc
+    return nil
+}
+
+func (r *playlistCampaignRepo) Purge(ctx context.Context, playlistID, campaignID int) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    key := placementKey{playlistID, campaignID}
+    existing, found := r.playlistCampaigns[key]
+    if !found {
+        return store.ErrNotFound
+    }
+    if !existing.DeletedAt.Valid {
+        return store.ErrNotDeleted
+    }
+
+    if err := r.record(ctx, audit.Purge, store.EntityPlaylistCampaign, store.PlacementKey(playlistID, campaignID), existing, nil); err != nil {
+        return err
+    }
+    delete(r.playlistCampaigns, key)
+    delete(r.transitions, key)
+    delete(r.messages, key)
+    delete(r.verifyFailed, key)
+    return nil
+}
+
+// checkReferences mirrors the placement's foreign keys and the ownership rule
+// enforced by the Postgres repository. Trashed parents count as missing.
+func (r *playlistCampaignRepo) checkReferences(pc models.PlaylistCampaign) error {
+    playlist, found := r.playlists[pc.PlaylistID]
+    if !found || playlist.DeletedAt.Valid {
+        return missing("playlistid", "playlists")
+    }
+    if c, found := r.campaigns[pc.CampaignID]; !found || c.DeletedAt.Valid {
+        return missing("campaignid", "campaigns")
+    }
+    if p, found := r.playlisters[pc.PlaylisterId]; !found || p.DeletedAt.Valid {
+        return missing("playlisterid", "playlisters")
+    }
+    if playlist.PlaylisterId != pc.PlaylisterId {
+        return store.ErrOwnerMismatch
+    }
+    return nil
+}
@@ -0,0 +1,288 @@
+package memory
+
+import (
+    "context"
+    "database/sql"
+
+    "github.com/alanowatson/LeadGenAPI/internal/audit"
+    "github.com/alanowatson/LeadGenAPI/internal/models"
+    "github.com/alanowatson/LeadGenAPI/internal/store"
+)
+
+var playlisterEntity = entity[models.Playlister]{
+    toRecord:   store.PlaylisterRecord,
+    searchText: store.PlaylisterSearchText,
+}
+
+type playlisterRepo struct {
+    *data
+}
+
+func (r *playlisterRepo) List(ctx context.Context, opts store.ListOptions) (store.Page[models.Playlister], error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    return list(r.data, r.withContacts(), opts, playlisterEntity), nil
+}
+
+func (r *playlisterRepo) Each(ctx context.Context, opts store.ListOptions, fn func(models.Playlister) error) error {
+    r.mu.RLock()
+    playlisters := r.withContacts()
+    r.mu.RUnlock()
+    return each(r.data, playlisters, opts, playlisterEntity, fn)
+}
+
+func (r *playlisterRepo) Get(ctx context.Context, id int, fields ...string) (models.Playlister, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    p, found := r.playlisters[id]
+    if !found {
+        return models.Playlister{}, store.ErrNotFound
+    }
+    return pick(contacted(p, r.contacts()), fields, "playlisterid"), nil
+}
+
+func (r *playlisterRepo) GetMany(ctx context.Context, ids []int) (map[int]models.Playlister, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    found := getMany(r.playlisters, ids)
+    days := r.contacts()
+    for id, p := range found {
+        found[id] = contacted(p, days)
+    }
+    return found, nil
+}
+
+func (r *playlisterRepo) Create(ctx context.Context, p *models.Playlister) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if err := r.checkUnique(*p, 0); err != nil {
+        return err
+    }
+
+    p.ID = r.nextPlaylisterID
+    p.Version = 1
+    p.LastContacted = sql.NullString{}
+    r.nextPlaylisterID++
+    if err := r.record(ctx, audit.Create, store.EntityPlaylister, store.Key(p.ID), nil, *p); err != nil {
+        return err
+    }
+    r.playlisters[p.ID] = *p
+    return nil
+}
+
+func (r *playlisterRepo) Update(ctx context.Context, p *models.Playlister) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    existing, found := r.playlisters[p.ID]
+    if !found || existing.DeletedAt.Valid {
+        return store.ErrNotFound
+    }
+    if err := store.CheckVersion(existing.Version, p.Version); err != nil {
+        return err
+    }
+    if err := r.checkUnique(*p, p.ID); err != nil {
+        return err
+    }
+    existing = contacted(existing, r.contacts())
+
+    p.Version = existing.Version + 1
+    p.LastContacted = existing.LastContacted
+    if err := r.record(ctx, audit.Update, store.EntityPlaylister, store.Key(p.ID), existing, *p); err != nil {
+        return err
+    }
+    r.playlisters[p.ID] = *p
+    return nil
+}
+
+func (r *playlisterRepo) Delete(ctx context.Context, id, version int) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    existing, found := r.playlisters[id]
+    if !found || existing.DeletedAt.Valid {
+        return store.ErrNotFound
+    }
+    if err := store.CheckVersion(existing.Version, version); err != nil {
+        return err
+    }
+    if err := r.checkReferenced(id, false); err != nil {
+        return err
+    }
+
+    p := existing
+    p.DeletedAt = nowString()
+    p.Version++
+    if err := r.record(ctx, audit.Delete, store.EntityPlaylister, store.Key(id), existing, p); err != nil {
+        return err
+    }
+    r.playlisters[id] = p
+    return nil
+}
+
+func (r *playlisterRepo) Restore(ctx context.Context, id int) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    existing, found := r.playlisters[id]
+    if !found {
+        return store.ErrNotFound
+    }
+    if !existing.DeletedAt.Valid {
+        return store.ErrNotDeleted
+    }
+    p := existing
+    p.DeletedAt = sql.NullString{}
+    p.Version++
+    if err := r.record(ctx, audit.Restore, store.EntityPlaylister, store.Key(id), existing, p); err != nil {
+        return err
+    }
+    r.playlisters[id] = p
+    return nil
+}
+
+func (r *playlisterRepo) Purge(ctx context.Context, id int) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    existing, found := r.playlisters[id]
+    if !found {
+        return store.ErrNotFound
+    }
+    if !existing.DeletedAt.Valid {
+        return store.ErrNotDeleted
+    }
+    if err := r.checkReferenced(id, true); err != nil {
+        return err
+    }
+
+    if err := r.record(ctx, audit.Purge, store.EntityPlaylister, store.Key(id), existing, nil); err != nil {
+        return err
+    }
+    delete(r.playlisters, id)
+    return nil
+}
+
+func (r *playlisterRepo) Merge(ctx context.Context, survivorID, duplicateID, version int) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    survivor, found := r.playlisters[survivorID]
+    if !found || survivor.DeletedAt.Valid {
+        return store.ErrNotFound
+    }
+    if err := store.CheckVersion(survivor.Version, version); err != nil {
+        return err
+    }
+    duplicate, found := r.playlisters[duplicateID]
+    if !found || duplicate.DeletedAt.Valid || duplicateID == survivorID {
+        return missing("duplicate_id", "playlisters")
+    }
+
+    // Every change and its audit entry are built before any is applied, so a
+    // failure part way leaves the store as it was.
+    var entries []audit.Entry
+    playlists := map[int]models.Playlist{}
+    for id, pl := range r.playlists {
+        if pl.PlaylisterId != duplicateID {
+            continue
+        }
+        moved := pl
+        moved.PlaylisterId = survivorID
+        moved.Version++
+        e, err := audit.NewEntry(ctx, audit.Update, store.EntityPlaylist, store.Key(id), pl, moved)
+        if err != nil {
+            return err
+        }
+        entries = append(entries, e)
+        playlists[id] = moved
+    }
+    placements := map[placementKey]models.PlaylistCampaign{}
+    for key, pc := range r.playlistCampaigns {
+        if pc.PlaylisterId != duplicateID {
+            continue
+        }
+        moved := pc
+        moved.PlaylisterId = survivorID
+        moved.Version++
+        e, err := audit.NewEntry(ctx, audit.Update, store.EntityPlaylistCampaign, store.PlacementKey(key.playlistID, key.campaignID), pc, moved)
+        if err != nil {
+            return err
+        }
+        entries = append(entries, e)
+        placements[key] = moved
+    }
+
+    merged := store.MergedPlaylister(survivor, duplicate)
+    merged.Version++
+    kept, err := audit.NewEntry(ctx, audit.Merge, store.EntityPlaylister, store.Key(survivorID), survivor, merged)
+    if err != nil {
+        return err
+    }
+    kept.Note("merged_from", duplicateID)
+
+    trashed := duplicate
+    trashed.DeletedAt = nowString()
+    trashed.Version++
+    folded, err := audit.NewEntry(ctx, audit.Merge, store.EntityPlaylister, store.Key(duplicateID), duplicate, trashed)
+    if err != nil {
+        return err
+    }
+    folded.Note("merged_into", survivorID)
+
+    for _, e := range append(entries, kept, folded) {
+        r.appendEntry(e)
+    }
+    for id, pl := range playlists {
+        r.playlists[id] = pl
+    }
+    for key, pc := range placements {
+        r.playlistCampaigns[key] = pc
+    }
+    r.playlisters[survivorID] = merged
+    r.playlisters[duplicateID] = trashed
+    return nil
+}
+
+// checkReferenced rejects removing a playlister that still owns playlists or
+// placements. Trashed ones only count when includeDeleted is set.
+func (r *playlisterRepo) checkReferenced(id int, includeDeleted bool) error {
+    for _, pl := range r.playlists {
+        if pl.PlaylisterId == id && (includeDeleted || !pl.DeletedAt.Valid) {
+            return referenced("playlists")
+        }
+    }
+    for _, pc := range r.playlistCampaigns {
+        if pc.PlaylisterId == id && (includeDeleted || !pc.DeletedAt.Valid) {
+            return referenced("playlistcampaigns")
+        }
+    }
+    return nil
+}
+
+// checkUnique enforces the unique spotifyuserid and email columns, ignoring
+// the row being updated.
+func (r *playlisterRepo) checkUnique(p models.Playlister, selfID int) error {
+    for id, existing := range r.playlisters {
+        if id == selfID {
+            continue
+        }
+        if sameValue(existing.SpotifyUserID, p.SpotifyUserID) {
+            return unique("spotifyuserid")
+        }
+        if sameValue(existing.Email, p.Email) {
+            return unique("email")
+        }
+    }
+    return nil
+}
+
+// sameValue compares two nullable columns the way a unique index does:
+// NULLs never collide.
+func sameValue(a, b sql.NullString) bool {
+    return a.Valid && b.Valid && a.String == b.String
+}
@@ -0,0 +1,71 @@
+package memory
+
+import (
+    "context"
+    "fmt"
+    "sort"
+
+    "github.com/alanowatson/LeadGenAPI/internal/search"
+    "github.com/alanowatson/LeadGenAPI/internal/store"
+)
+
+type searchRepo struct {
+    *data
+}
+
+func (r *searchRepo) Search(ctx context.Context, terms []string, types []string, limit int) ([]store.SearchResult, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    var results []store.SearchResult
+    add := func(t string, id int, title string, text []string, data interface{}) {
+        if rank := search.Score(terms, text...); rank > 0 {
+            results = append(results, store.SearchResult{
+                Type: t, ID: id, Rank: rank, Title: title,
+                Snippet: search.Snippet(terms, text...), Data: data,
+            })
+        }
+    }
+
+    for _, t := range types {
+        switch t {
+        case store.TypePlaylister:
+            for _, p := range r.withContacts() {
+                if p.DeletedAt.Valid {
+                    continue
+                }
+                add(t, p.ID, p.CuratorFullName.String, store.PlaylisterSearchText(p), p)
+            }
+        case store.TypePlaylist:
+            for _, p := range r.playlists {
+                if p.DeletedAt.Valid {
+                    continue
+                }
+                add(t, p.ID, p.CurrentPlaylistName.String, store.PlaylistSearchText(p), p)
+            }
+        case store.TypeCampaign:
+            for _, c := range r.campaigns {
+                if c.DeletedAt.Valid {
+                    continue
+                }
+                add(t, c.ID, c.CampaignName.String, store.CampaignSearchText(c), c)
+            }
+        default:
+            return nil, fmt.Errorf("unknown search type %q", t)
+        }
+    }
+
+    sort.Slice(results, func(i, j int) bool {
+        if results[i].Rank != results[j].Rank {
+            return results[i].Rank > results[j].Rank
+        }
+        if results[i].Type != results[j].Type {
+            return results[i].Type < results[j].Type
+        }
+        return results[i].ID < results[j].ID
+    })
+    if len(results) > limit {
+        results = results[:limit]
+    }
+    return results, nil
+}
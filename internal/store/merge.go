@@ -0,0 +1,25 @@
+package store
+
+import "github.com/alanowatson/LeadGenAPI/internal/models"
+
+// MergedPlaylister returns survivor with the contact details it lacks taken
+// from duplicate, and the later of their stored lastcontacted days, which
+// back the one derived from the message log. The unique spotifyuserid and
+// email stay with the record that has them.
+func MergedPlaylister(survivor, duplicate models.Playlister) models.Playlister {
+    merged := survivor
+    if !merged.Instagram.Valid {
+        merged.Instagram = duplicate.Instagram
+    }
+    if !merged.Facebook.Valid {
+        merged.Facebook = duplicate.Facebook
+    }
+    if !merged.Whatsapp.Valid {
+        merged.Whatsapp = duplicate.Whatsapp
+    }
+    // Dates are YYYY-MM-DD, so they compare as strings.
+    if duplicate.LastContacted.Valid && duplicate.LastContacted.String > merged.LastContacted.String {
+        merged.LastContacted = duplicate.LastContacted
+    }
+    return merged
+}
@@ -0,0 +1,27 @@
+package store
+
+import (
+    "time"
+
+    "github.com/alanowatson/LeadGenAPI/internal/models"
+)
+
+// SentAt returns when m was sent: its SentAt, or the current second when it
+// has none.
+func SentAt(m models.Message) (time.Time, error) {
+    if m.SentAt == "" {
+        return time.Now().UTC().Truncate(time.Second), nil
+    }
+    t, err := time.Parse(time.RFC3339, m.SentAt)
+    if err != nil {
+        return time.Time{}, err
+    }
+    return t.UTC(), nil
+}
+
+// The message log columns call SQL functions defined by the derived contact
+// migration, so the database computes them for filters and sorts too.
+const (
+    outboundMessagesColumn = "placement_outbound_messages(playlistid, campaignid)"
+    lastContactedColumn    = "playlister_last_contacted(playlisterid, lastcontacted)"
+)
@@ -0,0 +1,95 @@
+package postgres
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+
+    "github.com/alanowatson/LeadGenAPI/internal/audit"
+    "github.com/alanowatson/LeadGenAPI/internal/query"
+    "github.com/alanowatson/LeadGenAPI/internal/store"
+)
+
+var auditColumns = columns[audit.Entry]{
+    {"id", "id", func(e *audit.Entry) interface{} { return &e.ID }},
+    {"actor", "actor", func(e *audit.Entry) interface{} { return &e.Actor }},
+    {"at", "at", func(e *audit.Entry) interface{} { return &e.At }},
+    {"entity", "entity", func(e *audit.Entry) interface{} { return &e.Entity }},
+    {"key", "entity_key", func(e *audit.Entry) interface{} { return &e.Key }},
+    {"action", "action", func(e *audit.Entry) interface{} { return &e.Action }},
+    {"changes", "changes", func(e *audit.Entry) interface{} { return &e.Changes }},
+}
+
+type auditRepo struct {
+    db *sql.DB
+}
+
+func (r *auditRepo) List(ctx context.Context, opts store.ListOptions) (store.Page[audit.Entry], error) {
+    args := &query.Args{}
+    countWhere, countArgs, where := filter(opts, args, listing{})
+
+    total, err := count(ctx, r.db, opts, "audit_log", countWhere, countArgs)
+    if err != nil {
+        return store.Page[audit.Entry]{}, err
+    }
+
+    stmt := `SELECT ` + auditColumns.sql() + `
+        FROM audit_log ` + where + `
+        ` + orderBy(opts.Query, "id") + `
+        LIMIT ` + args.Add(opts.Limit()) + ` OFFSET ` + args.Add(opts.Page.Offset())
+    rows, err := r.db.QueryContext(ctx, stmt, args.Values...)
+    if err != nil {
+        return store.Page[audit.Entry]{}, fmt.Errorf("error querying audit log: %w", err)
+    }
+    defer rows.Close()
+
+    var entries []audit.Entry
+    for rows.Next() {
+        e, err := auditColumns.scan(rows)
+        if err != nil {
+            return store.Page[audit.Entry]{}, fmt.Errorf("error scanning audit row: %w", err)
+        }
+        entries = append(entries, e)
+    }
+    if err := rows.Err(); err != nil {
+        return store.Page[audit.Entry]{}, fmt.Errorf("error iterating audit rows: %w", err)
+    }
+    return store.NewPage(entries, total, opts, store.AuditRecord), nil
+}
+
+func (r *auditRepo) Each(ctx context.Context, opts store.ListOptions, fn func(audit.Entry) error) error {
+    args := &query.Args{}
+    _, _, where := filter(opts, args, listing{})
+    stmt := `SELECT ` + auditColumns.sql() + `
+        FROM audit_log ` + where + `
+        ` + orderBy(opts.Query, "id")
+    return each(ctx, r.db, "audit_log", stmt, args.Values, auditColumns.scan, fn)
+}
+
+// record writes the audit entry for a write made in tx.
+func record(ctx context.Context, tx *sql.Tx, action audit.Action, entity, key string, before, after interface{}) error {
+    e, err := audit.NewEntry(ctx, action, entity, key, before, after)
+    if err != nil {
+        return err
+    }
+    return recordEntry(ctx, tx, e)
+}
+
+// recordEntry writes an audit entry built by the caller.
+func recordEntry(ctx context.Context, tx *sql.Tx, e audit.Entry) error {
+    _, err := tx.ExecContext(ctx, `
+        INSERT INTO audit_log (actor, at, entity, entity_key, action, changes)
+        VALUES ($1, $2, $3, $4, $5, $6)
+    `, e.Actor, e.At, e.Entity, e.Key, e.Action, e.Changes)
+    if err != nil {
+        return fmt.Errorf("error writing audit entry: %w", err)
+    }
+    return nil
+}
+
+// lock loads the row of table matching where and locks it until tx ends, so
+// the caller can check its version and diff it against the new values.
+func lock[T any](ctx context.Context, tx *sql.Tx, cols columns[T], table, where string, args ...interface{}) (T, error) {
+    v, err := cols.scan(tx.QueryRowContext(ctx, `SELECT `+cols.sql()+` FROM `+table+` WHERE `+where+` FOR UPDATE`, args...))
+    return v, translate(err)
+}
@@ -0,0 +1,199 @@
+package postgres
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "time"
+
+    "github.com/alanowatson/LeadGenAPI/internal/audit"
+    "github.com/alanowatson/LeadGenAPI/internal/models"
+    "github.com/alanowatson/LeadGenAPI/internal/query"
+    "github.com/alanowatson/LeadGenAPI/internal/store"
+)
+
+var campaignColumns = columns[models.Campaign]{
+    {"campaignid", "campaignid", func(c *models.Campaign) interface{} { return &c.ID }},
+    {"campaignname", "campaignname", func(c *models.Campaign) interface{} { return &c.CampaignName }},
+    {"referenceartists", "referenceartists", func(c *models.Campaign) interface{} { return &c.ReferenceArtists }},
+    {"trello_link", "trello_link", func(c *models.Campaign) interface{} { return &c.TrelloLink }},
+    {"spotify_link", "spotify_link", func(c *models.Campaign) interface{} { return &c.SpotifyLink }},
+    {"launch_date", "to_char(launchdate, 'YYYY-MM-DD')", func(c *models.Campaign) interface{} { return &c.LaunchDate }},
+    {"promoted_artist", "promoted_artist", func(c *models.Campaign) interface{} { return &c.PromotedArtist }},
+    {"max_messages", "max_messages", func(c *models.Campaign) interface{} { return &c.MaxMessages }},
+    {"followup_sequence", "followup_sequence", func(c *models.Campaign) interface{} { return &c.FollowupSequence }},
+    {"deleted_at", deletedAtExpr, func(c *models.Campaign) interface{} { return &c.DeletedAt }},
+    {"version", "version", func(c *models.Campaign) interface{} { return &c.Version }},
+}
+
+type campaignRepo struct {
+    db *sql.DB
+}
+
+func scanCampaign(row scanner) (models.Campaign, error) {
+    return campaignColumns.scan(row)
+}
+
+func (r *campaignRepo) List(ctx context.Context, opts store.ListOptions) (store.Page[models.Campaign], error) {
+    args := &query.Args{}
+    countWhere, countArgs, where := filter(opts, args, listing{searchable: true, key: "campaignid", softDelete: true})
+
+    total, err := count(ctx, r.db, opts, "campaigns", countWhere, countArgs)
+    if err != nil {
+        return store.Page[models.Campaign]{}, err
+    }
+
+    cols := campaignColumns.pick(opts.Selected(), "campaignid")
+    stmt := `SELECT ` + cols.sql() + `
+        FROM campaigns ` + where + `
+        ` + orderBy(opts.Query, "campaignid") + `
+        LIMIT ` + args.Add(opts.Limit()) + ` OFFSET ` + args.Add(opts.Page.Offset())
+    rows, err := r.db.QueryContext(ctx, stmt, args.Values...)
+    if err != nil {
+        return store.Page[models.Campaign]{}, fmt.Errorf("error querying campaigns: %w", err)
+    }
+    defer rows.Close()
+
+    var campaigns []models.Campaign
+    for rows.Next() {
+        c, err := cols.scan(rows)
+        if err != nil {
+            return store.Page[models.Campaign]{}, fmt.Errorf("error scanning campaign row: %w", err)
+        }
+        campaigns = append(campaigns, c)
+    }
+    if err := rows.Err(); err != nil {
+        return store.Page[models.Campaign]{}, fmt.Errorf("error iterating campaign rows: %w", err)
+    }
+    return store.NewPage(campaigns, total, opts, store.CampaignRecord), nil
+}
+
+func (r *campaignRepo) Each(ctx context.Context, opts store.ListOptions, fn func(models.Campaign) error) error {
+    args := &query.Args{}
+    _, _, where := filter(opts, args, listing{searchable: true, key: "campaignid", softDelete: true})
+    cols := campaignColumns.pick(opts.Selected(), "campaignid")
+    stmt := `SELECT ` + cols.sql() + `
+        FROM campaigns ` + where + `
+        ` + orderBy(opts.Query, "campaignid")
+    return each(ctx, r.db, "campaigns", stmt, args.Values, cols.scan, fn)
+}
+
+func (r *campaignRepo) Get(ctx context.Context, id int, fields ...string) (models.Campaign, error) {
+    cols := campaignColumns.pick(fields, "campaignid")
+    stmt := `SELECT ` + cols.sql() + ` FROM campaigns WHERE campaignid = $1`
+    c, err := cols.scan(r.db.QueryRowContext(ctx, stmt, id))
+    return c, translate(err)
+}
+
+func (r *campaignRepo) GetMany(ctx context.Context, ids []int) (map[int]models.Campaign, error) {
+    stmt := `SELECT ` + campaignColumns.sql() + ` FROM campaigns WHERE campaignid = ANY($1)`
+    return getMany(ctx, r.db, "campaigns", stmt, ids, scanCampaign, func(c models.Campaign) int { return c.ID })
+}
+
+func (r *campaignRepo) Create(ctx context.Context, c *models.Campaign) error {
+    if c.MaxMessages == 0 {
+        c.MaxMessages = models.DefaultMaxMessages
+    }
+    return withTx(ctx, r.db, func(tx *sql.Tx) error {
+        err := tx.QueryRowContext(ctx, `
+            INSERT INTO campaigns (campaignname, referenceartists, trello_link, spotify_link, launchdate, promoted_artist, max_messages, followup_sequence)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+            RETURNING campaignid, version
+        `, c.CampaignName, c.ReferenceArtists, c.TrelloLink, c.SpotifyLink, c.LaunchDate, c.PromotedArtist, c.MaxMessages, c.FollowupSequence).Scan(&c.ID, &c.Version)
+        if err != nil {
+            return translate(err)
+        }
+        return record(ctx, tx, audit.Create, store.EntityCampaign, store.Key(c.ID), nil, *c)
+    })
+}
+
+func (r *campaignRepo) Update(ctx context.Context, c *models.Campaign) error {
+    return withTx(ctx, r.db, func(tx *sql.Tx) error {
+        before, err := lock(ctx, tx, campaignColumns, "campaigns", "campaignid = $1 AND deleted_at IS NULL", c.ID)
+        if err != nil {
+            return err
+        }
+        if err := store.CheckVersion(before.Version, c.Version); err != nil {
+            return err
+        }
+        if c.MaxMessages == 0 {
+            c.MaxMessages = before.MaxMessages
+        }
+
+        err = tx.QueryRowContext(ctx, `
+            UPDATE campaigns
+            SET campaignname = $1, referenceartists = $2, trello_link = $3,
+                spotify_link = $4, launchdate = $5, promoted_artist = $6,
+                max_messages = $7, followup_sequence = $8, version = version + 1
+            WHERE campaignid = $9
+            RETURNING version
+        `, c.CampaignName, c.ReferenceArtists, c.TrelloLink, c.SpotifyLink, c.LaunchDate, c.PromotedArtist, c.MaxMessages, c.FollowupSequence, c.ID).Scan(&c.Version)
+        if err != nil {
+            return translate(err)
+        }
+        return record(ctx, tx, audit.Update, store.EntityCampaign, store.Key(c.ID), before, *c)
+    })
+}
+
+func (r *campaignRepo) Delete(ctx context.Context, id, version int) error {
+    return withTx(ctx, r.db, func(tx *sql.Tx) error {
+        before, err := lock(ctx, tx, campaignColumns, "campaigns", "campaignid = $1 AND deleted_at IS NULL", id)
+        if err != nil {
+            return err
+        }
+        if err := store.CheckVersion(before.Version, version); err != nil {
+            return err
+        }
+        if err := checkUnreferenced(ctx, tx, "playlistcampaigns", "campaignid = $1", id); err != nil {
+            return err
+        }
+
+        deletedAt := time.Now()
+        if err := stamp(ctx, tx, "campaigns", "campaignid = $1", deletedAt, id); err != nil {
+            return err
+        }
+        after := before
+        after.DeletedAt = trashedAt(deletedAt)
+        after.Version++
+        return record(ctx, tx, audit.Delete, store.EntityCampaign, store.Key(id), before, after)
+    })
+}
+
+func (r *campaignRepo) Restore(ctx context.Context, id int) error {
+    return withTx(ctx, r.db, func(tx *sql.Tx) error {
+        before, err := lock(ctx, tx, campaignColumns, "campaigns", "campaignid = $1", id)
+        if err != nil {
+            return err
+        }
+        if !before.DeletedAt.Valid {
+            return store.ErrNotDeleted
+        }
+
+        if err := stamp(ctx, tx, "campaigns", "campaignid = $1", nil, id); err != nil {
+            return err
+        }
+        after := before
+        after.DeletedAt = sql.NullString{}
+        after.Version++
+        return record(ctx, tx, audit.Restore, store.EntityCampaign, store.Key(id), before, after)
+    })
+}
+
+// Purge relies on the foreign keys to reject removing a campaign that trashed
+// rows still reference.
+func (r *campaignRepo) Purge(ctx context.Context, id int) error {
+    return withTx(ctx, r.db, func(tx *sql.Tx) error {
+        before, err := lock(ctx, tx, campaignColumns, "campaigns", "campaignid = $1", id)
+        if err != nil {
+            return err
+        }
+        if !before.DeletedAt.Valid {
+            return store.ErrNotDeleted
+        }
+
+        if _, err := tx.ExecContext(ctx, "DELETE FROM campaigns WHERE campaignid = $1", id); err != nil {
+            return translate(err)
+        }
+        return record(ctx, tx, audit.Purge, store.EntityCampaign, store.Key(id), before, nil)
+    })
+}
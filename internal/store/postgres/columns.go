@@ -0,0 +1,68 @@
+package postgres
+
+import (
+    "strings"
+
+    "github.com/alanowatson/LeadGenAPI/internal/query"
+)
+
+// column maps one schema field onto its SELECT expression and the model
+// field it scans into.
+type column[T any] struct {
+    field string
+    expr  string
+    dest  func(*T) interface{}
+}
+
+// columns is a table's select list in table order.
+type columns[T any] []column[T]
+
+// pick narrows the list to fields plus the always-loaded keys, version and
+// deleted_at. A nil fields keeps every column.
+func (cs columns[T]) pick(fields []string, keys ...string) columns[T] {
+    if fields == nil {
+        return cs
+    }
+    wanted := map[string]bool{"version": true, "deleted_at": true}
+    // Appending keys to fields could write into the caller's backing array.
+    for _, f := range fields {
+        wanted[f] = true
+    }
+    for _, k := range keys {
+        wanted[k] = true
+    }
+    var picked columns[T]
+    for _, c := range cs {
+        if wanted[c.field] {
+            picked = append(picked, c)
+        }
+    }
+    return picked
+}
+
+// derived selects a computed field with the schema's SQL for it.
+func derived(s query.Schema, name string) string {
+    f, _ := s.Field(name)
+    return f.Column
+}
+
+// sql renders the select list.
+func (cs columns[T]) sql() string {
+    exprs := make([]string, len(cs))
+    for i, c := range cs {
+        exprs[i] = c.expr
+    }
+    return strings.Join(exprs, ", ")
+}
+
+// scan reads one row selected with cs.sql(). Fields left out of the list
+// keep their zero value.
+func (cs columns[T]) scan(row scanner) (T, error) {
+    var v T
+    dest := make([]interface{}, len(cs))
+    for i, c := range cs {
+        dest[i] = c.dest(&v)
+    }
+    err := row.Scan(dest...)
+    return v, err
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+    "context"
+    "fmt"
+
+    "github.com/alanowatson/LeadGenAPI/internal/models"
+    "github.com/alanowatson/LeadGenAPI/internal/query"
+    "github.com/alanowatson/LeadGenAPI/internal/store"
+    "github.com/lib/pq"
+)
+
+// dueFollowups mirrors store.NextFollowup: each placement's next step is the
+// element of its campaign's sequence at the number of outbound messages sent,
+// due its after_days after the latest of them, or after status_changed_at
+// for the first.
+const dueFollowups = `
+    SELECT pc.playlistid, pc.campaignid, pc.playlisterid, COALESCE(pc.assignee, '') AS assignee,
+        pc.placementstatus, outbound.sent + 1 AS step,
+        LEAST(jsonb_array_length(c.followup_sequence), c.max_messages) AS steps,
+        c.followup_sequence -> outbound.sent ->> 'channel' AS channel,
+        CASE WHEN outbound.sent > 0 THEN outbound.sent_at ELSE pc.status_changed_at END
+            + make_interval(days => (c.followup_sequence -> outbound.sent ->> 'after_days')::int) AS due_at
+    FROM playlistcampaigns pc
+    JOIN campaigns c ON c.campaignid = pc.campaignid AND c.deleted_at IS NULL
+    CROSS JOIN LATERAL (
+        SELECT count(*)::int AS sent, max(sent_at) AS sent_at
+        FROM messages m
+        WHERE m.playlistid = pc.playlistid AND m.campaignid = pc.campaignid AND m.direction = 'outbound'
+    ) outbound
+    WHERE pc.deleted_at IS NULL
+        AND pc.placementstatus = ANY($1)
+        AND outbound.sent < LEAST(jsonb_array_length(c.followup_sequence), c.max_messages)`
+
+func (r *playlistCampaignRepo) DueFollowups(ctx context.Context, q store.FollowupQuery) ([]models.Followup, error) {
+    args := &query.Args{}
+    args.Add(pq.Array(models.FollowupStatuses))
+    conditions := "due_at <= " + args.Add(q.Until)
+    if q.Assignee != "" {
+        conditions += " AND assignee = " + args.Add(q.Assignee)
+    }
+    if q.Channel != "" {
+        conditions += " AND channel = " + args.Add(q.Channel)
+    }
+    stmt := `SELECT playlistid, campaignid, playlisterid, assignee, placementstatus, step, steps, channel, ` + utcTimestamp("due_at") + `
+        FROM (` + dueFollowups + `) due
+        WHERE ` + conditions + `
+        ORDER BY due_at, playlistid, campaignid
+        LIMIT ` + args.Add(q.Limit)
+    rows, err := r.db.QueryContext(ctx, stmt, args.Values...)
+    if err != nil {
+        return nil, fmt.Errorf("error querying due follow-ups: %w", err)
+    }
+    defer rows.Close()
+
+    due := []models.Followup{}
+    for rows.Next() {
+        var f models.Followup
+        if err := rows.Scan(&f.PlaylistID, &f.CampaignID, &f.PlaylisterID, &f.Assignee, &f.PlacementStatus, &f.Step, &f.Steps, &f.Channel, &f.DueAt); err != nil {
+            return nil, fmt.Errorf("error scanning follow-up row: %w", err)
+        }
+        due = append(due, f)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating follow-up rows: %w", err)
+    }
+    return due, nil
+}
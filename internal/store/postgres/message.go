@@ -0,0 +1,91 @@
+package postgres
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "time"
+
+    "github.com/alanowatson/LeadGenAPI/internal/audit"
+    "github.com/alanowatson/LeadGenAPI/internal/models"
+    "github.com/alanowatson/LeadGenAPI/internal/store"
+)
+
+func (r *playlistCampaignRepo) LogMessage(ctx context.Context, m *models.Message) error {
+    return withTx(ctx, r.db, func(tx *sql.Tx) error {
+        // Locking the placement serializes the cap check with other messages
+        // logged for it.
+        if _, err := lock(ctx, tx, playlistCampaignColumns, "playlistcampaigns", "playlistid = $1 AND campaignid = $2 AND deleted_at IS NULL", m.PlaylistID, m.CampaignID); err != nil {
+            return err
+        }
+        sentAt, err := store.SentAt(*m)
+        if err != nil {
+            return err
+        }
+
+        if m.Direction == models.MessageOutbound {
+            var limit, sent int
+            err := tx.QueryRowContext(ctx, "SELECT max_messages FROM campaigns WHERE campaignid = $1", m.CampaignID).Scan(&limit)
+            if err != nil {
+                return fmt.Errorf("error reading message cap: %w", err)
+            }
+            err = tx.QueryRowContext(ctx, `
+                SELECT count(*) FROM messages
+                WHERE playlistid = $1 AND campaignid = $2 AND direction = 'outbound'
+            `, m.PlaylistID, m.CampaignID).Scan(&sent)
+            if err != nil {
+                return fmt.Errorf("error counting messages: %w", err)
+            }
+            if sent >= limit {
+                return &store.MessageCapError{Cap: limit}
+            }
+        }
+
+        m.Actor = audit.Actor(ctx)
+        err = tx.QueryRowContext(ctx, `
+            INSERT INTO messages (playlistid, campaignid, channel, direction, sent_at, body, external_id, actor)
+            VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8)
+            RETURNING messageid
+        `, m.PlaylistID, m.CampaignID, m.Channel, m.Direction, sentAt, m.Body, m.ExternalID, m.Actor).Scan(&m.ID)
+        if err != nil {
+            return translate(err)
+        }
+        m.SentAt = sentAt.Format(time.RFC3339)
+        return nil
+    })
+}
+
+func (r *playlistCampaignRepo) Messages(ctx context.Context, playlistID, campaignID int) ([]models.Message, error) {
+    var exists bool
+    err := r.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM playlistcampaigns WHERE playlistid = $1 AND campaignid = $2)", playlistID, campaignID).Scan(&exists)
+    if err != nil {
+        return nil, fmt.Errorf("error checking placement: %w", err)
+    }
+    if !exists {
+        return nil, store.ErrNotFound
+    }
+
+    rows, err := r.db.QueryContext(ctx, `
+        SELECT messageid, playlistid, campaignid, channel, direction, `+utcTimestamp("sent_at")+`,
+            COALESCE(body, ''), COALESCE(external_id, ''), actor
+        FROM messages
+        WHERE playlistid = $1 AND campaignid = $2
+        ORDER BY sent_at, messageid`, playlistID, campaignID)
+    if err != nil {
+        return nil, fmt.Errorf("error querying messages: %w", err)
+    }
+    defer rows.Close()
+
+    messages := []models.Message{}
+    for rows.Next() {
+        var m models.Message
+        if err := rows.Scan(&m.ID, &m.PlaylistID, &m.CampaignID, &m.Channel, &m.Direction, &m.SentAt, &m.Body, &m.ExternalID, &m.Actor); err != nil {
+            return nil, fmt.Errorf("error scanning message row: %w", err)
+        }
+        messages = append(messages, m)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating message rows: %w", err)
+    }
+    return messages, nil
+}
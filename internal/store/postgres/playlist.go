@@ -0,0 +1,385 @@
+package postgres
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "time"
+
+    "github.com/alanowatson/LeadGenAPI/internal/audit"
+    "github.com/alanowatson/LeadGenAPI/internal/models"
+    "github.com/alanowatson/LeadGenAPI/internal/query"
+    "github.com/alanowatson/LeadGenAPI/internal/store"
+)
+
+var playlistColumns = columns[models.Playlist]{
+    {"playlistid", "playlistid", func(p *models.Playlist) interface{} { return &p.ID }},
+    {"playlisterid", "playlisterid", func(p *models.Playlist) interface{} { return &p.PlaylisterId }},
+    {"playlistspotifyid", "playlistspotifyid", func(p *models.Playlist) interface{} { return &p.PlaylistSpotifyId }},
+    {"numberoffollowers", "numberoffollowers", func(p *models.Playlist) interface{} { return &p.NumberOfFollowers }},
+    {"current_playlist_name", "current_playlist_name", func(p *models.Playlist) interface{} { return &p.CurrentPlaylistName }},
+    {"lastfollowercountdate", "to_char(lastfollowercountdate, 'YYYY-MM-DD')", func(p *models.Playlist) interface{} { return &p.LastFollowerCountDate }},
+    {"last_exposed", "to_char(last_exposed, 'YYYY-MM-DD')", func(p *models.Playlist) interface{} { return &p.LastExposed }},
+    {"deleted_at", deletedAtExpr, func(p *models.Playlist) interface{} { return &p.DeletedAt }},
+    {"last_synced_at", utcTimestamp("last_synced_at"), func(p *models.Playlist) interface{} { return &p.LastSyncedAt }},
+    {"spotify_deleted_at", utcTimestamp("spotify_deleted_at"), func(p *models.Playlist) interface{} { return &p.SpotifyDeletedAt }},
+    {"version", "version", func(p *models.Playlist) interface{} { return &p.Version }},
+}
+
+// playlistReadColumns adds the growth windows, computed from the follower
+// snapshots, to the stored columns. Writes lock and audit the stored columns
+// only.
+var playlistReadColumns = append(playlistColumns[:len(playlistColumns):len(playlistColumns)], columns[models.Playlist]{
+    {"growth_7d", derived(store.PlaylistSchema, "growth_7d"), func(p *models.Playlist) interface{} { return &p.Growth.Days7 }},
+    {"growth_30d", derived(store.PlaylistSchema, "growth_30d"), func(p *models.Playlist) interface{} { return &p.Growth.Days30 }},
+    {"growth_90d", derived(store.PlaylistSchema, "growth_90d"), func(p *models.Playlist) interface{} { return &p.Growth.Days90 }},
+    {"growth_rate_7d", derived(store.PlaylistSchema, "growth_rate_7d"), func(p *models.Playlist) interface{} { return &p.Growth.Rate7 }},
+    {"growth_rate_30d", derived(store.PlaylistSchema, "growth_rate_30d"), func(p *models.Playlist) interface{} { return &p.Growth.Rate30 }},
+    {"growth_rate_90d", derived(store.PlaylistSchema, "growth_rate_90d"), func(p *models.Playlist) interface{} { return &p.Growth.Rate90 }},
+}...)
+
+type playlistRepo struct {
+    db *sql.DB
+}
+
+func scanPlaylist(row scanner) (models.Playlist, error) {
+    return playlistColumns.scan(row)
+}
+
+func (r *playlistRepo) List(ctx context.Context, opts store.ListOptions) (store.Page[models.Playlist], error) {
+    args := &query.Args{}
+    countWhere, countArgs, where := filter(opts, args, listing{searchable: true, key: "playlistid", softDelete: true})
+
+    total, err := count(ctx, r.db, opts, "playlists", countWhere, countArgs)
+    if err != nil {
+        return store.Page[models.Playlist]{}, err
+    }
+
+    cols := playlistReadColumns.pick(opts.Selected(), "playlistid")
+    stmt := `SELECT ` + cols.sql() + `
+        FROM playlists ` + where + `
+        ` + orderBy(opts.Query, "playlistid") + `
+        LIMIT ` + args.Add(opts.Limit()) + ` OFFSET ` + args.Add(opts.Page.Offset())
+    rows, err := r.db.QueryContext(ctx, stmt, args.Values...)
+    if err != nil {
+        return store.Page[models.Playlist]{}, fmt.Errorf("error querying playlists: %w", err)
+    }
+    defer rows.Close()
+
+    var playlists []models.Playlist
+    for rows.Next() {
+        p, err := cols.scan(rows)
+        if err != nil {
+            return store.Page[models.Playlist]{}, fmt.Errorf("error scanning playlist row: %w", err)
+        }
+        playlists = append(playlists, p)
+    }
+    if err := rows.Err(); err != nil {
+        return store.Page[models.Playlist]{}, fmt.Errorf("error iterating playlist rows: %w", err)
+    }
+    return store.NewPage(playlists, total, opts, store.PlaylistRecord), nil
+}
+
+func (r *playlistRepo) Each(ctx context.Context, opts store.ListOptions, fn func(models.Playlist) error) error {
+    args := &query.Args{}
+    _, _, where := filter(opts, args, listing{searchable: true, key: "playlistid", softDelete: true})
+    cols := playlistReadColumns.pick(opts.Selected(), "playlistid")
+    stmt := `SELECT ` + cols.sql() + `
+        FROM playlists ` + where + `
+        ` + orderBy(opts.Query, "playlistid")
+    return each(ctx, r.db, "playlists", stmt, args.Values, cols.scan, fn)
+}
+
+func (r *playlistRepo) Get(ctx context.Context, id int, fields ...string) (models.Playlist, error) {
+    cols := playlistReadColumns.pick(fields, "playlistid")
+    stmt := `SELECT ` + cols.sql() + ` FROM playlists WHERE playlistid = $1`
+    p, err := cols.scan(r.db.QueryRowContext(ctx, stmt, id))
+    return p, translate(err)
+}
+
+func (r *playlistRepo) GetMany(ctx context.Context, ids []int) (map[int]models.Playlist, error) {
+    stmt := `SELECT ` + playlistReadColumns.sql() + ` FROM playlists WHERE playlistid = ANY($1)`
+    return getMany(ctx, r.db, "playlists", stmt, ids, playlistReadColumns.scan, func(p models.Playlist) int { return p.ID })
+}
+
+func (r *playlistRepo) Followers(ctx context.Context, id int, since, until time.Time) ([]models.FollowerSnapshot, error) {
+    var exists bool
+    if err := r.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM playlists WHERE playlistid = $1)", id).Scan(&exists); err != nil {
+        return nil, fmt.Errorf("error checking playlist: %w", err)
+    }
+    if !exists {
+        return nil, store.ErrNotFound
+    }
+
+    args := &query.Args{}
+    conditions := []string{"playlistid = " + args.Add(id)}
+    if !since.IsZero() {
+        conditions = append(conditions, "recorded_at >= "+args.Add(since))
+    }
+    if !until.IsZero() {
+        conditions = append(conditions, "recorded_at < "+args.Add(until))
+    }
+    rows, err := r.db.QueryContext(ctx, `
+        SELECT playlistid, followers, to_char(recorded_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+        FROM playlist_follower_snapshots `+query.Where(conditions)+`
+        ORDER BY recorded_at, snapshotid`, args.Values...)
+    if err != nil {
+        return nil, fmt.Errorf("error querying follower snapshots: %w", err)
+    }
+    defer rows.Close()
+
+    snapshots := []models.FollowerSnapshot{}
+    for rows.Next() {
+        var s models.FollowerSnapshot
+        if err := rows.Scan(&s.PlaylistID, &s.Followers, &s.RecordedAt); err != nil {
+            return nil, fmt.Errorf("error scanning follower snapshot row: %w", err)
+        }
+        snapshots = append(snapshots, s)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating follower snapshot rows: %w", err)
+    }
+    return snapshots, nil
+}
+
+// snapshotFollowers records the playlist's current follower count.
+func snapshotFollowers(ctx context.Context, tx *sql.Tx, p models.Playlist) error {
+    _, err := tx.ExecContext(ctx,
+        "INSERT INTO playlist_follower_snapshots (playlistid, followers) VALUES ($1, $2)",
+        p.ID, p.NumberOfFollowers)
+    return translate(err)
+}
+
+func (r *playlistRepo) Create(ctx context.Context, p *models.Playlist) error {
+    return withTx(ctx, r.db, func(tx *sql.Tx) error {
+        if err := checkLive(ctx, tx, "playlisters", "playlisterid", p.PlaylisterId); err != nil {
+            return err
+        }
+        err := tx.QueryRowContext(ctx, `
+            INSERT INTO playlists (playlisterid, playlistspotifyid, numberoffollowers, current_playlist_name, lastfollowercountdate, last_exposed)
+            VALUES ($1, $2, $3, $4, $5, $6)
+            RETURNING playlistid, version
+        `, p.PlaylisterId, p.PlaylistSpotifyId, p.NumberOfFollowers, p.CurrentPlaylistName, p.LastFollowerCountDate, p.LastExposed).Scan(&p.ID, &p.Version)
+        if err != nil {
+            return translate(err)
+        }
+        if err := snapshotFollowers(ctx, tx, *p); err != nil {
+            return err
+        }
+        return record(ctx, tx, audit.Create, store.EntityPlaylist, store.Key(p.ID), nil, *p)
+    })
+}
+
+func (r *playlistRepo) Update(ctx context.Context, p *models.Playlist) error {
+    return withTx(ctx, r.db, func(tx *sql.Tx) error {
+        before, err := lock(ctx, tx, playlistColumns, "playlists", "playlistid = $1 AND deleted_at IS NULL", p.ID)
+        if err != nil {
+            return err
+        }
+        if err := store.CheckVersion(before.Version, p.Version); err != nil {
+            return err
+        }
+        if err := checkLive(ctx, tx, "playlisters", "playlisterid", p.PlaylisterId); err != nil {
+            return err
+        }
+
+        err = tx.QueryRowContext(ctx, `
+            UPDATE playlists
+            SET playlisterid = $1, playlistspotifyid = $2, numberoffollowers = $3,
+                current_playlist_name = $4, lastfollowercountdate = $5, last_exposed = $6,
+                version = version + 1
+            WHERE playlistid = $7
+            RETURNING version
+        `, p.PlaylisterId, p.PlaylistSpotifyId, p.NumberOfFollowers, p.CurrentPlaylistName, p.LastFollowerCountDate, p.LastExposed, p.ID).Scan(&p.Version)
+        if err != nil {
+            return translate(err)
+        }
+        p.LastSyncedAt = before.LastSyncedAt
+        p.SpotifyDeletedAt = before.SpotifyDeletedAt
+        if p.NumberOfFollowers != before.NumberOfFollowers {
+            if err := snapshotFollowers(ctx, tx, *p); err != nil {
+                return err
+            }
+        }
+        return record(ctx, tx, audit.Update, store.EntityPlaylist, store.Key(p.ID), before, *p)
+    })
+}
+
+// syncTried is when a playlist was last synced or failed to be, the order of
+// the sync queue.
+const syncTried = "GREATEST(last_synced_at, sync_failed_at)"
+
+func (r *playlistRepo) Stale(ctx context.Context, before time.Time, limit int) ([]models.Playlist, error) {
+    stmt := `SELECT ` + playlistColumns.sql() + `
+        FROM playlists
+        WHERE deleted_at IS NULL AND spotify_deleted_at IS NULL
+            AND (` + syncTried + ` IS NULL OR ` + syncTried + ` < $1)
+        ORDER BY ` + syncTried + ` NULLS FIRST, playlistid
+        LIMIT $2`
+    rows, err := r.db.QueryContext(ctx, stmt, before, limit)
+    if err != nil {
+        return nil, fmt.Errorf("error querying stale playlists: %w", err)
+    }
+    defer rows.Close()
+
+    playlists := []models.Playlist{}
+    for rows.Next() {
+        p, err := playlistColumns.scan(rows)
+        if err != nil {
+            return nil, fmt.Errorf("error scanning playlist row: %w", err)
+        }
+        playlists = append(playlists, p)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating playlist rows: %w", err)
+    }
+    return playlists, nil
+}
+
+func (r *playlistRepo) SyncFailed(ctx context.Context, id int, at time.Time) error {
+    _, err := r.db.ExecContext(ctx, "UPDATE playlists SET sync_failed_at = $1 WHERE playlistid = $2", at, id)
+    return translate(err)
+}
+
+func (r *playlistRepo) Sync(ctx context.Context, id int, result store.PlaylistSync) error {
+    return withTx(ctx, r.db, func(tx *sql.Tx) error {
+        before, err := lock(ctx, tx, playlistColumns, "playlists", "playlistid = $1 AND deleted_at IS NULL", id)
+        if err != nil {
+            return err
+        }
+
+        after := store.SyncedPlaylist(before, result)
+        err = tx.QueryRowContext(ctx, `
+            UPDATE playlists
+            SET numberoffollowers = $1, current_playlist_name = $2, lastfollowercountdate = $3,
+                last_synced_at = $4, spotify_deleted_at = $5, version = version + 1
+            WHERE playlistid = $6
+            RETURNING version
+        `, after.NumberOfFollowers, after.CurrentPlaylistName, after.LastFollowerCountDate,
+            after.LastSyncedAt, after.SpotifyDeletedAt, id).Scan(&after.Version)
+        if err != nil {
+            return translate(err)
+        }
+        if after.NumberOfFollowers != before.NumberOfFollowers {
+            if err := snapshotFollowers(ctx, tx, after); err != nil {
+                return err
+            }
+        }
+        return record(ctx, tx, audit.Update, store.EntityPlaylist, store.Key(id), before, after)
+    })
+}
+
+// Delete trashes the playlist together with its live placements, stamping
+// them all with the same deleted_at so Restore can bring them back as a unit.
+func (r *playlistRepo) Delete(ctx context.Context, id, version int) error {
+    return withTx(ctx, r.db, func(tx *sql.Tx) error {
+        before, err := lock(ctx, tx, playlistColumns, "playlists", "playlistid = $1 AND deleted_at IS NULL", id)
+        if err != nil {
+            return err
+        }
+        if err := store.CheckVersion(before.Version, version); err != nil {
+            return err
+        }
+
+        const live = "playlistid = $1 AND deleted_at IS NULL"
+        placements, err := lockAll(ctx, tx, playlistCampaignColumns, "playlistcampaigns", live, id)
+        if err != nil {
+            return err
+        }
+        deletedAt := time.Now()
+        if err := stamp(ctx, tx, "playlistcampaigns", live, deletedAt, id); err != nil {
+            return err
+        }
+        for _, pc := range placements {
+            trashed := pc
+            trashed.DeletedAt = trashedAt(deletedAt)
+            trashed.Version++
+            if err := record(ctx, tx, audit.Delete, store.EntityPlaylistCampaign, store.PlacementKey(pc.PlaylistID, pc.CampaignID), pc, trashed); err != nil {
+                return err
+            }
+        }
+
+        if err := stamp(ctx, tx, "playlists", "playlistid = $1", deletedAt, id); err != nil {
+            return err
+        }
+        after := before
+        after.DeletedAt = trashedAt(deletedAt)
+        after.Version++
+        return record(ctx, tx, audit.Delete, store.EntityPlaylist, store.Key(id), before, after)
+    })
+}
+
+// Restore brings the playlist back together with the placements its Delete
+// trashed, skipping those whose campaign is still in the trash.
+func (r *playlistRepo) Restore(ctx context.Context, id int) error {
+    return withTx(ctx, r.db, func(tx *sql.Tx) error {
+        before, err := lock(ctx, tx, playlistColumns, "playlists", "playlistid = $1", id)
+        if err != nil {
+            return err
+        }
+        if !before.DeletedAt.Valid {
+            return store.ErrNotDeleted
+        }
+        if err := checkLive(ctx, tx, "playlisters", "playlisterid", before.PlaylisterId); err != nil {
+            return err
+        }
+
+        const trashedWith = `playlistid = $1
+            AND deleted_at = (SELECT deleted_at FROM playlists WHERE playlistid = $1)
+            AND campaignid IN (SELECT campaignid FROM campaigns WHERE deleted_at IS NULL)`
+        placements, err := lockAll(ctx, tx, playlistCampaignColumns, "playlistcampaigns", trashedWith, id)
+        if err != nil {
+            return err
+        }
+        if err := stamp(ctx, tx, "playlistcampaigns", trashedWith, nil, id); err != nil {
+            return err
+        }
+        for _, pc := range placements {
+            restored := pc
+            restored.DeletedAt = sql.NullString{}
+            restored.Version++
+            if err := record(ctx, tx, audit.Restore, store.EntityPlaylistCampaign, store.PlacementKey(pc.PlaylistID, pc.CampaignID), pc, restored); err != nil {
+                return err
+            }
+        }
+
+        if err := stamp(ctx, tx, "playlists", "playlistid = $1", nil, id); err != nil {
+            return err
+        }
+        after := before
+        after.DeletedAt = sql.NullString{}
+        after.Version++
+        return record(ctx, tx, audit.Restore, store.EntityPlaylist, store.Key(id), before, after)
+    })
+}
+
+// Purge removes the trashed playlist and every placement on it for good.
+func (r *playlistRepo) Purge(ctx context.Context, id int) error {
+    return withTx(ctx, r.db, func(tx *sql.Tx) error {
+        before, err := lock(ctx, tx, playlistColumns, "playlists", "playlistid = $1", id)
+        if err != nil {
+            return err
+        }
+        if !before.DeletedAt.Valid {
+            return store.ErrNotDeleted
+        }
+
+        placements, err := lockAll(ctx, tx, playlistCampaignColumns, "playlistcampaigns", "playlistid = $1", id)
+        if err != nil {
+            return err
+        }
+        if _, err := tx.ExecContext(ctx, "DELETE FROM playlistcampaigns WHERE playlistid = $1", id); err != nil {
+            return translate(err)
+        }
+        for _, pc := range placements {
+            if err := record(ctx, tx, audit.Purge, store.EntityPlaylistCampaign, store.PlacementKey(pc.PlaylistID, pc.CampaignID), pc, nil); err != nil {
+                return err
+            }
+        }
+
+        if _, err := tx.ExecContext(ctx, "DELETE FROM playlists WHERE playlistid = $1", id); err != nil {
+            return translate(err)
+        }
+        return record(ctx, tx, audit.Purge, store.EntityPlaylist, store.Key(id), before, nil)
+    })
+}
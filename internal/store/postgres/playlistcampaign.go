@@ -0,0 +1,386 @@
+package postgres
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "time"
+
+    "github.com/alanowatson/LeadGenAPI/internal/audit"
+    "github.com/alanowatson/LeadGenAPI/internal/models"
+    "github.com/alanowatson/LeadGenAPI/internal/query"
+    "github.com/alanowatson/LeadGenAPI/internal/store"
+)
+
+var playlistCampaignColumns = columns[models.PlaylistCampaign]{
+    {"playlistid", "playlistid", func(pc *models.PlaylistCampaign) interface{} { return &pc.PlaylistID }},
+    {"campaignid", "campaignid", func(pc *models.PlaylistCampaign) interface{} { return &pc.CampaignID }},
+    {"playlisterid", "playlisterid", func(pc *models.PlaylistCampaign) interface{} { return &pc.PlaylisterId }},
+    {"referenceartists", "referenceartists", func(pc *models.PlaylistCampaign) interface{} { return &pc.ReferenceArtists }},
+    {"placementstatus", "placementstatus", func(pc *models.PlaylistCampaign) interface{} { return &pc.PlacementStatus }},
+    {"numberofmessages", derived(store.PlaylistCampaignSchema, "numberofmessages"), func(pc *models.PlaylistCampaign) interface{} { return &pc.NumberOfMessages }},
+    {"purchased", "purchased", func(pc *models.PlaylistCampaign) interface{} { return &pc.Purchased }},
+    {"assignee", "assignee", func(pc *models.PlaylistCampaign) interface{} { return &pc.Assignee }},
+    {"status_changed_at", utcTimestamp("status_changed_at"), func(pc *models.PlaylistCampaign) interface{} { return &pc.StatusChangedAt }},
+    {"deleted_at", deletedAtExpr, func(pc *models.PlaylistCampaign) interface{} { return &pc.DeletedAt }},
+    {"verified_at", utcTimestamp("verified_at"), func(pc *models.PlaylistCampaign) interface{} { return &pc.VerifiedAt }},
+    {"track_present", "track_present", func(pc *models.PlaylistCampaign) interface{} { return &pc.TrackPresent }},
+    {"track_first_seen_at", utcTimestamp("track_first_seen_at"), func(pc *models.PlaylistCampaign) interface{} { return &pc.TrackFirstSeenAt }},
+    {"track_removed_at", utcTimestamp("track_removed_at"), func(pc *models.PlaylistCampaign) interface{} { return &pc.TrackRemovedAt }},
+    {"version", "version", func(pc *models.PlaylistCampaign) interface{} { return &pc.Version }},
+}
+
+type playlistCampaignRepo struct {
+    db *sql.DB
+}
+
+func scanPlaylistCampaign(row scanner) (models.PlaylistCampaign, error) {
+    return playlistCampaignColumns.scan(row)
+}
+
+func (r *playlistCampaignRepo) List(ctx context.Context, opts store.ListOptions) (store.Page[models.PlaylistCampaign], error) {
+    args := &query.Args{}
+    countWhere, countArgs, where := filter(opts, args, listing{softDelete: true})
+
+    total, err := count(ctx, r.db, opts, "playlistcampaigns", countWhere, countArgs)
+    if err != nil {
+        return store.Page[models.PlaylistCampaign]{}, err
+    }
+
+    cols := playlistCampaignColumns.pick(store.PlacementFields(opts.Selected()), "playlistid", "campaignid")
+    stmt := `SELECT ` + cols.sql() + `
+        FROM playlistcampaigns ` + where + `
+        ` + orderBy(opts.Query, "playlistid, campaignid") + `
+        LIMIT ` + args.Add(opts.Limit()) + ` OFFSET ` + args.Add(opts.Page.Offset())
+    rows, err := r.db.QueryContext(ctx, stmt, args.Values...)
+    if err != nil {
+        return store.Page[models.PlaylistCampaign]{}, fmt.Errorf("error querying playlist campaigns: %w", err)
+    }
+    defer rows.Close()
+
+    var playlistCampaigns []models.PlaylistCampaign
+    for rows.Next() {
+        pc, err := cols.scan(rows)
+        if err != nil {
+            return store.Page[models.PlaylistCampaign]{}, fmt.Errorf("error scanning playlist campaign row: %w", err)
+        }
+        playlistCampaigns = append(playlistCampaigns, pc)
+    }
+    if err := rows.Err(); err != nil {
+        return store.Page[models.PlaylistCampaign]{}, fmt.Errorf("error iterating playlist campaign rows: %w", err)
+    }
+    return store.NewPage(playlistCampaigns, total, opts, store.PlaylistCampaignRecord), nil
+}
+
+func (r *playlistCampaignRepo) Each(ctx context.Context, opts store.ListOptions, fn func(models.PlaylistCampaign) error) error {
+    args := &query.Args{}
+    _, _, where := filter(opts, args, listing{softDelete: true})
+    cols := playlistCampaignColumns.pick(store.PlacementFields(opts.Selected()), "playlistid", "campaignid")
+    stmt := `SELECT ` + cols.sql() + `
+        FROM playlistcampaigns ` + where + `
+        ` + orderBy(opts.Query, "playlistid, campaignid")
+    return each(ctx, r.db, "playlistcampaigns", stmt, args.Values, cols.scan, fn)
+}
+
+func (r *playlistCampaignRepo) Get(ctx context.Context, playlistID, campaignID int, fields ...string) (models.PlaylistCampaign, error) {
+    cols := playlistCampaignColumns.pick(store.PlacementFields(fields), "playlistid", "campaignid")
+    stmt := `SELECT ` + cols.sql() + `
+        FROM playlistcampaigns
+        WHERE playlistid = $1 AND campaignid = $2`
+    pc, err := cols.scan(r.db.QueryRowContext(ctx, stmt, playlistID, campaignID))
+    return pc, translate(err)
+}
+
+func (r *playlistCampaignRepo) Create(ctx context.Context, pc *models.PlaylistCampaign) error {
+    if err := store.CheckTransition("", pc.PlacementStatus.String); err != nil {
+        return err
+    }
+    return withTx(ctx, r.db, func(tx *sql.Tx) error {
+        if err := checkOwner(ctx, tx, *pc); err != nil {
+            return err
+        }
+        changedAt := time.Now()
+        err := tx.QueryRowContext(ctx, `
+            INSERT INTO playlistcampaigns (playlistid, campaignid, playlisterid, referenceartists, placementstatus, purchased, assignee, status_changed_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+            RETURNING version
+        `, pc.PlaylistID, pc.CampaignID, pc.PlaylisterId, pc.ReferenceArtists, pc.PlacementStatus, pc.Purchased, pc.Assignee, changedAt).Scan(&pc.Version)
+        if err != nil {
+            return translate(err)
+        }
+        pc.NumberOfMessages = 0
+        pc.StatusChangedAt = timestampAt(changedAt)
+        if err := addTransition(ctx, tx, *pc, "", ""); err != nil {
+            return err
+        }
+        return record(ctx, tx, audit.Create, store.EntityPlaylistCampaign, store.PlacementKey(pc.PlaylistID, pc.CampaignID), nil, *pc)
+    })
+}
+
+func (r *playlistCampaignRepo) Update(ctx context.Context, pc *models.PlaylistCampaign) error {
+    return withTx(ctx, r.db, func(tx *sql.Tx) error {
+        before, err := lock(ctx, tx, playlistCampaignColumns, "playlistcampaigns", "playlistid = $1 AND campaignid = $2 AND deleted_at IS NULL", pc.PlaylistID, pc.CampaignID)
+        if err != nil {
+            return err
+        }
+        if err := store.CheckVersion(before.Version, pc.Version); err != nil {
+            return err
+        }
+        if err := checkOwner(ctx, tx, *pc); err != nil {
+            return err
+        }
+        from := before.PlacementStatus.String
+        changed := pc.PlacementStatus.String != from
+        pc.StatusChangedAt = before.StatusChangedAt
+        if changed {
+            if err := store.CheckTransition(from, pc.PlacementStatus.String); err != nil {
+                return err
+            }
+            pc.StatusChangedAt = timestampAt(time.Now())
+        }
+
+        err = tx.QueryRowContext(ctx, `
+            UPDATE playlistcampaigns
+            SET playlisterid = $1, referenceartists = $2, placementstatus = $3,
+                purchased = $4, assignee = $5, status_changed_at = $6, version = version + 1
+            WHERE playlistid = $7 AND campaignid = $8
+            RETURNING version
+        `, pc.PlaylisterId, pc.ReferenceArtists, pc.PlacementStatus, pc.Purchased, pc.Assignee, pc.StatusChangedAt, pc.PlaylistID, pc.CampaignID).Scan(&pc.Version)
+        if err != nil {
+            return translate(err)
+        }
+        if changed {
+            if err := addTransition(ctx, tx, *pc, from, ""); err != nil {
+                return err
+            }
+        }
+        pc.NumberOfMessages = before.NumberOfMessages
+        pc.VerifiedAt = before.VerifiedAt
+        pc.TrackPresent = before.TrackPresent
+        pc.TrackFirstSeenAt = before.TrackFirstSeenAt
+        pc.TrackRemovedAt = before.TrackRemovedAt
+        return record(ctx, tx, audit.Update, store.EntityPlaylistCampaign, store.PlacementKey(pc.PlaylistID, pc.CampaignID), before, *pc)
+    })
+}
+
+func (r *playlistCampaignRepo) Transition(ctx context.Context, playlistID, campaignID int, status, note string, version int) error {
+    return withTx(ctx, r.db, func(tx *sql.Tx) error {
+        before, err := lock(ctx, tx, playlistCampaignColumns, "playlistcampaigns", "playlistid = $1 AND campaignid = $2 AND deleted_at IS NULL", playlistID, campaignID)
+        if err != nil {
+            return err
+        }
+        if err := store.CheckVersion(before.Version, version); err != nil {
+            return err
+        }
+        if err := store.CheckTransition(before.PlacementStatus.String, status); err != nil {
+            return err
+        }
+
+        after := before
+        after.PlacementStatus = sql.NullString{String: status, Valid: true}
+        after.StatusChangedAt = timestampAt(time.Now())
+        err = tx.QueryRowContext(ctx, `
+            UPDATE playlistcampaigns
+            SET placementstatus = $1, status_changed_at = $2, version = version + 1
+            WHERE playlistid = $3 AND campaignid = $4
+            RETURNING version
+        `, after.PlacementStatus, after.StatusChangedAt, playlistID, campaignID).Scan(&after.Version)
+        if err != nil {
+            return translate(err)
+        }
+        if err := addTransition(ctx, tx, after, before.PlacementStatus.String, note); err != nil {
+            return err
+        }
+        return record(ctx, tx, audit.Update, store.EntityPlaylistCampaign, store.PlacementKey(playlistID, campaignID), before, after)
+    })
+}
+
+func (r *playlistCampaignRepo) Transitions(ctx context.Context, playlistID, campaignID int) ([]models.PlacementTransition, error) {
+    var exists bool
+    err := r.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM playlistcampaigns WHERE playlistid = $1 AND campaignid = $2)", playlistID, campaignID).Scan(&exists)
+    if err != nil {
+        return nil, fmt.Errorf("error checking placement: %w", err)
+    }
+    if !exists {
+        return nil, store.ErrNotFound
+    }
+
+    rows, err := r.db.QueryContext(ctx, `
+        SELECT playlistid, campaignid, COALESCE(from_status, ''), to_status, actor, `+utcTimestamp("at")+`, COALESCE(note, '')
+        FROM placement_transitions
+        WHERE playlistid = $1 AND campaignid = $2
+        ORDER BY at, transitionid`, playlistID, campaignID)
+    if err != nil {
+        return nil, fmt.Errorf("error querying placement transitions: %w", err)
+    }
+    defer rows.Close()
+
+    transitions := []models.PlacementTransition{}
+    for rows.Next() {
+        var t models.PlacementTransition
+        if err := rows.Scan(&t.PlaylistID, &t.CampaignID, &t.From, &t.To, &t.Actor, &t.At, &t.Note); err != nil {
+            return nil, fmt.Errorf("error scanning placement transition row: %w", err)
+        }
+        transitions = append(transitions, t)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating placement transition rows: %w", err)
+    }
+    return transitions, nil
+}
+
+// addTransition records that pc moved from the given status to its current
+// one at its StatusChangedAt.
+func addTransition(ctx context.Context, tx *sql.Tx, pc models.PlaylistCampaign, from, note string) error {
+    _, err := tx.ExecContext(ctx, `
+        INSERT INTO placement_transitions (playlistid, campaignid, from_status, to_status, actor, at, note)
+        VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, NULLIF($7, ''))
+    `, pc.PlaylistID, pc.CampaignID, from, pc.PlacementStatus.String, audit.Actor(ctx), pc.StatusChangedAt, note)
+    return translate(err)
+}
+
+// verifyTried is when a placement was last verified or failed to be, the
+// order of the verification queue.
+const verifyTried = "GREATEST(verified_at, verify_failed_at)"
+
+func (r *playlistCampaignRepo) Unverified(ctx context.Context, before time.Time, limit int) ([]models.PlaylistCampaign, error) {
+    stmt := `SELECT ` + playlistCampaignColumns.sql() + `
+        FROM playlistcampaigns
+        WHERE deleted_at IS NULL
+            AND (placementstatus = 'Placed' OR track_present)
+            AND campaignid IN (SELECT campaignid FROM campaigns WHERE spotify_link LIKE $1)
+            AND (` + verifyTried + ` IS NULL OR ` + verifyTried + ` < $2)
+        ORDER BY ` + verifyTried + ` NULLS FIRST, playlistid, campaignid
+        LIMIT $3`
+    rows, err := r.db.QueryContext(ctx, stmt, store.TrackLinkPrefix+"%", before, limit)
+    if err != nil {
+        return nil, fmt.Errorf("error querying placements to verify: %w", err)
+    }
+    defer rows.Close()
+
+    placements := []models.PlaylistCampaign{}
+    for rows.Next() {
+        pc, err := playlistCampaignColumns.scan(rows)
+        if err != nil {
+            return nil, fmt.Errorf("error scanning placement row: %w", err)
+        }
+        placements = append(placements, pc)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating placement rows: %w", err)
+    }
+    return placements, nil
+}
+
+func (r *playlistCampaignRepo) VerifyFailed(ctx context.Context, playlistID, campaignID int, at time.Time) error {
+    _, err := r.db.ExecContext(ctx, "UPDATE playlistcampaigns SET verify_failed_at = $1 WHERE playlistid = $2 AND campaignid = $3", at, playlistID, campaignID)
+    return translate(err)
+}
+
+func (r *playlistCampaignRepo) Verify(ctx context.Context, playlistID, campaignID int, check store.PlacementCheck) error {
+    return withTx(ctx, r.db, func(tx *sql.Tx) error {
+        before, err := lock(ctx, tx, playlistCampaignColumns, "playlistcampaigns", "playlistid = $1 AND campaignid = $2 AND deleted_at IS NULL", playlistID, campaignID)
+        if err != nil {
+            return err
+        }
+
+        after := store.VerifiedPlacement(before, check)
+        err = tx.QueryRowContext(ctx, `
+            UPDATE playlistcampaigns
+            SET verified_at = $1, track_present = $2, track_first_seen_at = $3, track_removed_at = $4,
+                version = version + 1
+            WHERE playlistid = $5 AND campaignid = $6
+            RETURNING version
+        `, after.VerifiedAt, after.TrackPresent, after.TrackFirstSeenAt, after.TrackRemovedAt, playlistID, campaignID).Scan(&after.Version)
+        if err != nil {
+            return translate(err)
+        }
+        return record(ctx, tx, audit.Update, store.EntityPlaylistCampaign, store.PlacementKey(playlistID, campaignID), before, after)
+    })
+}
+
+func (r *playlistCampaignRepo) Delete(ctx context.Context, playlistID, campaignID, version int) error {
+    return withTx(ctx, r.db, func(tx *sql.Tx) error {
+        before, err := lock(ctx, tx, playlistCampaignColumns, "playlistcampaigns", "playlistid = $1 AND campaignid = $2 AND deleted_at IS NULL", playlistID, campaignID)
+        if err != nil {
+            return err
+        }
+        if err := store.CheckVersion(before.Version, version); err != nil {
+            return err
+        }
+
+        deletedAt := time.Now()
+        if err := stamp(ctx, tx, "playlistcampaigns", "playlistid = $1 AND campaignid = $2", deletedAt, playlistID, campaignID); err != nil {
+            return err
+        }
+        after := before
+        after.DeletedAt = trashedAt(deletedAt)
+        after.Version++
+        return record(ctx, tx, audit.Delete, store.EntityPlaylistCampaign, store.PlacementKey(playlistID, campaignID), before, after)
+    })
+}
+
+func (r *playlistCampaignRepo) Restore(ctx context.Context, playlistID, campaignID int) error {
+    return withTx(ctx, r.db, func(tx *sql.Tx) error {
+        before, err := lock(ctx, tx, playlistCampaignColumns, "playlistcampaigns", "playlistid = $1 AND campaignid = $2", playlistID, campaignID)
+        if err != nil {
+            return err
+        }
+        if !before.DeletedAt.Valid {
+            return store.ErrNotDeleted
+        }
+        if err := checkOwner(ctx, tx, before); err != nil {
+            return err
+        }
+
+        if err := stamp(ctx, tx, "playlistcampaigns", "playlistid = $1 AND campaignid = $2", nil, playlistID, campaignID); err != nil {
+            return err
+        }
+        after := before
+        after.DeletedAt = sql.NullString{}
+        after.Version++
+        return record(ctx, tx, audit.Restore, store.EntityPlaylistCampaign, store.PlacementKey(playlistID, campaignID), before, after)
+    })
+}
+
+func (r *playlistCampaignRepo) Purge(ctx context.Context, playlistID, campaignID int) error {
+    return withTx(ctx, r.db, func(tx *sql.Tx) error {
+        before, err := lock(ctx, tx, playlistCampaignColumns, "playlistcampaigns", "playlistid = $1 AND campaignid = $2", playlistID, campaignID)
+        if err != nil {
+            return err
+        }
+        if !before.DeletedAt.Valid {
+            return store.ErrNotDeleted
+        }
+
+        if _, err := tx.ExecContext(ctx, "DELETE FROM playlistcampaigns WHERE playlistid = $1 AND campaignid = $2", playlistID, campaignID); err != nil {
+            return translate(err)
+        }
+        return record(ctx, tx, audit.Purge, store.EntityPlaylistCampaign, store.PlacementKey(playlistID, campaignID), before, nil)
+    })
+}
+
+// checkOwner verifies that the placement's playlist, campaign and playlister
+// are live and that the playlister owns the playlist. The playlist row is
+// locked so ownership cannot change before the write commits.
+func checkOwner(ctx context.Context, tx *sql.Tx, pc models.PlaylistCampaign) error {
+    var ownerID int
+    var live bool
+    err := tx.QueryRowContext(ctx, "SELECT playlisterid, deleted_at IS NULL FROM playlists WHERE playlistid = $1 FOR SHARE", pc.PlaylistID).Scan(&ownerID, &live)
+    if err == sql.ErrNoRows || (err == nil && !live) {
+        return &store.ConstraintError{Kind: store.MissingReference, Column: "playlistid", Table: "playlists"}
+    }
+    if err != nil {
+        return fmt.Errorf("error checking playlist ownership: %w", err)
+    }
+    if err := checkLive(ctx, tx, "campaigns", "campaignid", pc.CampaignID); err != nil {
+        return err
+    }
+    if err := checkLive(ctx, tx, "playlisters", "playlisterid", pc.PlaylisterId); err != nil {
+        return err
+    }
+    if ownerID != pc.PlaylisterId {
+        return store.ErrOwnerMismatch
+    }
+    return nil
+}
@@ -0,0 +1,320 @@
+package postgres
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "time"
+
+    "github.com/alanowatson/LeadGenAPI/internal/audit"
+    "github.com/alanowatson/LeadGenAPI/internal/models"
+    "github.com/alanowatson/LeadGenAPI/internal/query"
+    "github.com/alanowatson/LeadGenAPI/internal/store"
+)
+
+var playlisterColumns = columns[models.Playlister]{
+    {"playlisterid", "playlisterid", func(p *models.Playlister) interface{} { return &p.ID }},
+    {"spotifyuserid", "spotifyuserid", func(p *models.Playlister) interface{} { return &p.SpotifyUserID }},
+    {"curatorfullname", "curatorfullname", func(p *models.Playlister) interface{} { return &p.CuratorFullName }},
+    {"email", "email", func(p *models.Playlister) interface{} { return &p.Email }},
+    {"instagram", "instagram", func(p *models.Playlister) interface{} { return &p.Instagram }},
+    {"facebook", "facebook", func(p *models.Playlister) interface{} { return &p.Facebook }},
+    {"whatsapp", "whatsapp", func(p *models.Playlister) interface{} { return &p.Whatsapp }},
+    {"lastcontacted", lastContactedExpr, func(p *models.Playlister) interface{} { return &p.LastContacted }},
+    {"preferredlanguage", "preferredlanguage", func(p *models.Playlister) interface{} { return &p.PreferredLanguage }},
+    {"followupstatus", "followupstatus", func(p *models.Playlister) interface{} { return &p.FollowupStatus }},
+    {"deleted_at", deletedAtExpr, func(p *models.Playlister) interface{} { return &p.DeletedAt }},
+    {"version", "version", func(p *models.Playlister) interface{} { return &p.Version }},
+}
+
+// lastContactedExpr reads the lastcontacted day derived from the message log.
+var lastContactedExpr = "to_char(" + derived(store.PlaylisterSchema, "lastcontacted") + ", 'YYYY-MM-DD')"
+
+type playlisterRepo struct {
+    db *sql.DB
+}
+
+func scanPlaylister(row scanner) (models.Playlister, error) {
+    return playlisterColumns.scan(row)
+}
+
+func (r *playlisterRepo) List(ctx context.Context, opts store.ListOptions) (store.Page[models.Playlister], error) {
+    args := &query.Args{}
+    countWhere, countArgs, where := filter(opts, args, listing{searchable: true, softDelete: true})
+
+    total, err := count(ctx, r.db, opts, "playlisters", countWhere, countArgs)
+    if err != nil {
+        return store.Page[models.Playlister]{}, err
+    }
+
+    cols := playlisterColumns.pick(opts.Selected(), "playlisterid")
+    stmt := `SELECT ` + cols.sql() + `
+        FROM playlisters ` + where + `
+        ` + orderBy(opts.Query, "playlisterid") + `
+        LIMIT ` + args.Add(opts.Limit()) + ` OFFSET ` + args.Add(opts.Page.Offset())
+    rows, err := r.db.QueryContext(ctx, stmt, args.Values...)
+    if err != nil {
+        return store.Page[models.Playlister]{}, fmt.Errorf("error querying playlisters: %w", err)
+    }
+    defer rows.Close()
+
+    var playlisters []models.Playlister
+    for rows.Next() {
+        p, err := cols.scan(rows)
+        if err != nil {
+            return store.Page[models.Playlister]{}, fmt.Errorf("error scanning playlister row: %w", err)
+        }
+        playlisters = append(playlisters, p)
+    }
+    if err := rows.Err(); err != nil {
+        return store.Page[models.Playlister]{}, fmt.Errorf("error iterating playlister rows: %w", err)
+    }
+    return store.NewPage(playlisters, total, opts, store.PlaylisterRecord), nil
+}
+
+func (r *playlisterRepo) Each(ctx context.Context, opts store.ListOptions, fn func(models.Playlister) error) error {
+    args := &query.Args{}
+    _, _, where := filter(opts, args, listing{searchable: true, softDelete: true})
+    cols := playlisterColumns.pick(opts.Selected(), "playlisterid")
+    stmt := `SELECT ` + cols.sql() + `
+        FROM playlisters ` + where + `
+        ` + orderBy(opts.Query, "playlisterid")
+    return each(ctx, r.db, "playlisters", stmt, args.Values, cols.scan, fn)
+}
+
+func (r *playlisterRepo) Get(ctx context.Context, id int, fields ...string) (models.Playlister, error) {
+    cols := playlisterColumns.pick(fields, "playlisterid")
+    stmt := `SELECT ` + cols.sql() + ` FROM playlisters WHERE playlisterid = $1`
+    p, err := cols.scan(r.db.QueryRowContext(ctx, stmt, id))
+    return p, translate(err)
+}
+
+func (r *playlisterRepo) GetMany(ctx context.Context, ids []int) (map[int]models.Playlister, error) {
+    stmt := `SELECT ` + playlisterColumns.sql() + ` FROM playlisters WHERE playlisterid = ANY($1)`
+    return getMany(ctx, r.db, "playlisters", stmt, ids, scanPlaylister, func(p models.Playlister) int { return p.ID })
+}
+
+func (r *playlisterRepo) Create(ctx context.Context, p *models.Playlister) error {
+    return withTx(ctx, r.db, func(tx *sql.Tx) error {
+        stmt := `
+            INSERT INTO playlisters (spotifyuserid, curatorfullname, email,
+                                     instagram, facebook, whatsapp,
+                                     preferredlanguage, followupstatus)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+            RETURNING playlisterid, version`
+        err := tx.QueryRowContext(ctx, stmt,
+            p.SpotifyUserID,
+            p.CuratorFullName,
+            p.Email,
+            p.Instagram,
+            p.Facebook,
+            p.Whatsapp,
+            p.PreferredLanguage,
+            p.FollowupStatus,
+        ).Scan(&p.ID, &p.Version)
+        if err != nil {
+            return translate(err)
+        }
+        p.LastContacted = sql.NullString{}
+        return record(ctx, tx, audit.Create, store.EntityPlaylister, store.Key(p.ID), nil, *p)
+    })
+}
+
+func (r *playlisterRepo) Update(ctx context.Context, p *models.Playlister) error {
+    return withTx(ctx, r.db, func(tx *sql.Tx) error {
+        before, err := lock(ctx, tx, playlisterColumns, "playlisters", "playlisterid = $1 AND deleted_at IS NULL", p.ID)
+        if err != nil {
+            return err
+        }
+        if err := store.CheckVersion(before.Version, p.Version); err != nil {
+            return err
+        }
+
+        stmt := `
+            UPDATE playlisters
+            SET spotifyuserid = $1, curatorfullname = $2, email = $3,
+                instagram = $4, facebook = $5, whatsapp = $6,
+                preferredlanguage = $7, followupstatus = $8, version = version + 1
+            WHERE playlisterid = $9
+            RETURNING version`
+        err = tx.QueryRowContext(ctx, stmt,
+            p.SpotifyUserID,
+            p.CuratorFullName,
+            p.Email,
+            p.Instagram,
+            p.Facebook,
+            p.Whatsapp,
+            p.PreferredLanguage,
+            p.FollowupStatus,
+            p.ID,
+        ).Scan(&p.Version)
+        if err != nil {
+            return translate(err)
+        }
+        p.LastContacted = before.LastContacted
+        return record(ctx, tx, audit.Update, store.EntityPlaylister, store.Key(p.ID), before, *p)
+    })
+}
+
+func (r *playlisterRepo) Delete(ctx context.Context, id, version int) error {
+    return withTx(ctx, r.db, func(tx *sql.Tx) error {
+        before, err := lock(ctx, tx, playlisterColumns, "playlisters", "playlisterid = $1 AND deleted_at IS NULL", id)
+        if err != nil {
+            return err
+        }
+        if err := store.CheckVersion(before.Version, version); err != nil {
+            return err
+        }
+        if err := checkUnreferenced(ctx, tx, "playlists", "playlisterid = $1", id); err != nil {
+            return err
+        }
+        if err := checkUnreferenced(ctx, tx, "playlistcampaigns", "playlisterid = $1", id); err != nil {
+            return err
+        }
+
+        deletedAt := time.Now()
+        if err := stamp(ctx, tx, "playlisters", "playlisterid = $1", deletedAt, id); err != nil {
+            return err
+        }
+        after := before
+        after.DeletedAt = trashedAt(deletedAt)
+        after.Version++
+        return record(ctx, tx, audit.Delete, store.EntityPlaylister, store.Key(id), before, after)
+    })
+}
+
+func (r *playlisterRepo) Restore(ctx context.Context, id int) error {
+    return withTx(ctx, r.db, func(tx *sql.Tx) error {
+        before, err := lock(ctx, tx, playlisterColumns, "playlisters", "playlisterid = $1", id)
+        if err != nil {
+            return err
+        }
+        if !before.DeletedAt.Valid {
+            return store.ErrNotDeleted
+        }
+
+        if err := stamp(ctx, tx, "playlisters", "playlisterid = $1", nil, id); err != nil {
+            return err
+        }
+        after := before
+        after.DeletedAt = sql.NullString{}
+        after.Version++
+        return record(ctx, tx, audit.Restore, store.EntityPlaylister, store.Key(id), before, after)
+    })
+}
+
+// Purge relies on the foreign keys to reject removing a playlister that trashed
+// rows still reference.
+func (r *playlisterRepo) Purge(ctx context.Context, id int) error {
+    return withTx(ctx, r.db, func(tx *sql.Tx) error {
+        before, err := lock(ctx, tx, playlisterColumns, "playlisters", "playlisterid = $1", id)
+        if err != nil {
+            return err
+        }
+        if !before.DeletedAt.Valid {
+            return store.ErrNotDeleted
+        }
+
+        if _, err := tx.ExecContext(ctx, "DELETE FROM playlisters WHERE playlisterid = $1", id); err != nil {
+            return translate(err)
+        }
+        return record(ctx, tx, audit.Purge, store.EntityPlaylister, store.Key(id), before, nil)
+    })
+}
+
+func (r *playlisterRepo) Merge(ctx context.Context, survivorID, duplicateID, version int) error {
+    if survivorID == duplicateID {
+        return &store.ConstraintError{Kind: store.MissingReference, Column: "duplicate_id", Table: "playlisters"}
+    }
+    return withTx(ctx, r.db, func(tx *sql.Tx) error {
+        // Lock the pair in ID order so concurrent merges cannot deadlock.
+        first, second := survivorID, duplicateID
+        if first > second {
+            first, second = second, first
+        }
+        locked := map[int]models.Playlister{}
+        for _, id := range []int{first, second} {
+            p, err := lock(ctx, tx, playlisterColumns, "playlisters", "playlisterid = $1 AND deleted_at IS NULL", id)
+            if err == store.ErrNotFound && id == duplicateID {
+                return &store.ConstraintError{Kind: store.MissingReference, Column: "duplicate_id", Table: "playlisters"}
+            }
+            if err != nil {
+                return err
+            }
+            locked[id] = p
+        }
+        survivor, duplicate := locked[survivorID], locked[duplicateID]
+        if err := store.CheckVersion(survivor.Version, version); err != nil {
+            return err
+        }
+
+        playlists, err := lockAll(ctx, tx, playlistColumns, "playlists", "playlisterid = $1", duplicateID)
+        if err != nil {
+            return err
+        }
+        placements, err := lockAll(ctx, tx, playlistCampaignColumns, "playlistcampaigns", "playlisterid = $1", duplicateID)
+        if err != nil {
+            return err
+        }
+        for _, table := range []string{"playlists", "playlistcampaigns"} {
+            stmt := "UPDATE " + table + " SET playlisterid = $1, version = version + 1 WHERE playlisterid = $2"
+            if _, err := tx.ExecContext(ctx, stmt, survivorID, duplicateID); err != nil {
+                return translate(err)
+            }
+        }
+        for _, pl := range playlists {
+            moved := pl
+            moved.PlaylisterId = survivorID
+            moved.Version++
+            if err := record(ctx, tx, audit.Update, store.EntityPlaylist, store.Key(pl.ID), pl, moved); err != nil {
+                return err
+            }
+        }
+        for _, pc := range placements {
+            moved := pc
+            moved.PlaylisterId = survivorID
+            moved.Version++
+            if err := record(ctx, tx, audit.Update, store.EntityPlaylistCampaign, store.PlacementKey(pc.PlaylistID, pc.CampaignID), pc, moved); err != nil {
+                return err
+            }
+        }
+
+        // The stored lastcontacted only backs the day derived from the
+        // message log, so it keeps the later of the two stored days and the
+        // merged record reads the derived one back.
+        merged := store.MergedPlaylister(survivor, duplicate)
+        err = tx.QueryRowContext(ctx, `
+            UPDATE playlisters
+            SET instagram = $1, facebook = $2, whatsapp = $3, version = version + 1,
+                lastcontacted = GREATEST(lastcontacted, (SELECT lastcontacted FROM playlisters WHERE playlisterid = $4))
+            WHERE playlisterid = $5
+            RETURNING version, `+lastContactedExpr,
+            merged.Instagram, merged.Facebook, merged.Whatsapp, duplicateID, survivorID,
+        ).Scan(&merged.Version, &merged.LastContacted)
+        if err != nil {
+            return translate(err)
+        }
+        kept, err := audit.NewEntry(ctx, audit.Merge, store.EntityPlaylister, store.Key(survivorID), survivor, merged)
+        if err != nil {
+            return err
+        }
+        kept.Note("merged_from", duplicateID)
+        if err := recordEntry(ctx, tx, kept); err != nil {
+            return err
+        }
+
+        deletedAt := time.Now()
+        if err := stamp(ctx, tx, "playlisters", "playlisterid = $1", deletedAt, duplicateID); err != nil {
+            return err
+        }
+        trashed := duplicate
+        trashed.DeletedAt = trashedAt(deletedAt)
+        trashed.Version++
+        folded, err := audit.NewEntry(ctx, audit.Merge, store.EntityPlaylister, store.Key(duplicateID), duplicate, trashed)
+        if err != nil {
+            return err
+        }
+        folded.Note("merged_into", survivorID)
+        return recordEntry(ctx, tx, folded)
+    })
+}
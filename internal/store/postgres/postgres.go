@@ -0,0 +1,182 @@
+// Package postgres implements the store repositories on top of database/sql
+// and the lib/pq driver.
+package postgres
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+
+    "github.com/alanowatson/LeadGenAPI/internal/db"
+    "github.com/alanowatson/LeadGenAPI/internal/query"
+    "github.com/alanowatson/LeadGenAPI/internal/search"
+    "github.com/alanowatson/LeadGenAPI/internal/store"
+    "github.com/lib/pq"
+)
+
+// New returns a Store whose repositories all share the given connection pool.
+func New(conn *sql.DB) *store.Store {
+    return &store.Store{
+        Playlisters:       &playlisterRepo{db: conn},
+        Playlists:         &playlistRepo{db: conn},
+        Campaigns:         &campaignRepo{db: conn},
+        PlaylistCampaigns: &playlistCampaignRepo{db: conn},
+        Search:            &searchRepo{db: conn},
+        Audit:             &auditRepo{db: conn},
+    }
+}
+
+// Date columns are selected with to_char so they scan into the models'
+// sql.NullString fields as YYYY-MM-DD rather than RFC 3339 timestamps.
+
+// scanner is satisfied by both *sql.Row and *sql.Rows.
+type scanner interface {
+    Scan(dest ...interface{}) error
+}
+
+// translate maps driver errors onto the store's error vocabulary.
+func translate(err error) error {
+    switch {
+    case err == nil:
+        return nil
+    case err == sql.ErrNoRows:
+        return store.ErrNotFound
+    case db.IsUniqueViolation(err):
+        return &store.ConstraintError{Kind: store.Unique, Column: db.ConstraintColumn(err)}
+    case db.IsStillReferenced(err):
+        return &store.ConstraintError{Kind: store.StillReferenced, Table: db.ConstraintTable(err)}
+    case db.IsForeignKeyViolation(err):
+        return &store.ConstraintError{Kind: store.MissingReference, Column: db.ConstraintColumn(err), Table: db.ConstraintTable(err)}
+    }
+    return err
+}
+
+// listing describes how a table takes part in the optional list features.
+type listing struct {
+    // searchable tables have a search_vector column.
+    searchable bool
+    // key is the column matched against placements for ViaPlacement, or ""
+    // when the table cannot be scoped that way.
+    key string
+    // softDelete tables have a deleted_at column; their trashed rows are left
+    // out unless the caller asks for them.
+    softDelete bool
+}
+
+// filter compiles the listing's filters, search terms, placement scope and
+// cursor into a WHERE clause. The clause and arguments without the cursor
+// are returned separately for counting.
+func filter(opts store.ListOptions, args *query.Args, l listing) (countWhere string, countArgs []interface{}, pageWhere string) {
+    conditions := opts.Query.Conditions(args)
+    if l.softDelete && !opts.IncludeDeleted {
+        conditions = append(conditions, "deleted_at IS NULL")
+    }
+    if l.searchable && len(opts.Search) > 0 {
+        conditions = append(conditions, "search_vector @@ to_tsquery('simple', "+args.Add(search.TSQuery(opts.Search))+")")
+    }
+    if via := opts.ViaPlacement; via != nil && l.key != "" && (via.Field == "playlistid" || via.Field == "campaignid") {
+        live := " AND deleted_at IS NULL"
+        if opts.IncludeDeleted {
+            live = ""
+        }
+        conditions = append(conditions, fmt.Sprintf("%s IN (SELECT %s FROM playlistcampaigns WHERE %s = %s%s)",
+            l.key, l.key, via.Field, args.Add(via.ID), live))
+    }
+    countWhere = query.Where(conditions)
+    countArgs = append([]interface{}(nil), args.Values...)
+    if opts.After != nil {
+        conditions = append(conditions, opts.Query.After(opts.After, args))
+    }
+    return countWhere, countArgs, query.Where(conditions)
+}
+
+// count returns the number of rows in table matching where, or -1 when the
+// caller opted out of counting.
+func count(ctx context.Context, conn *sql.DB, opts store.ListOptions, table, where string, args []interface{}) (int, error) {
+    if opts.Page.SkipCount {
+        return -1, nil
+    }
+    var total int
+    if err := conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table+" "+where, args...).Scan(&total); err != nil {
+        return 0, fmt.Errorf("error counting %s: %w", table, err)
+    }
+    return total, nil
+}
+
+// orderBy compiles the requested sort, falling back to the primary key for
+// callers that did not go through query.Parse.
+func orderBy(spec query.Spec, fallback string) string {
+    if clause := spec.OrderBy(); clause != "" {
+        return clause
+    }
+    return "ORDER BY " + fallback
+}
+
+// getMany runs stmt, which must select from table with the ID list as $1, and
+// collects the scanned rows by ID.
+func getMany[T any](ctx context.Context, conn *sql.DB, table, stmt string, ids []int, scan func(scanner) (T, error), id func(T) int) (map[int]T, error) {
+    found := make(map[int]T, len(ids))
+    if len(ids) == 0 {
+        return found, nil
+    }
+
+    rows, err := conn.QueryContext(ctx, stmt, pq.Array(ids))
+    if err != nil {
+        return nil, fmt.Errorf("error querying %s: %w", table, err)
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        v, err := scan(rows)
+        if err != nil {
+            return nil, fmt.Errorf("error scanning %s row: %w", table, err)
+        }
+        found[id(v)] = v
+    }
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating %s rows: %w", table, err)
+    }
+    return found, nil
+}
+
+// each runs stmt and calls fn with every scanned row as it arrives from the
+// cursor.
+func each[T any](ctx context.Context, conn *sql.DB, table, stmt string, args []interface{}, scan func(scanner) (T, error), fn func(T) error) error {
+    rows, err := conn.QueryContext(ctx, stmt, args...)
+    if err != nil {
+        return fmt.Errorf("error querying %s: %w", table, err)
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        v, err := scan(rows)
+        if err != nil {
+            return fmt.Errorf("error scanning %s row: %w", table, err)
+        }
+        if err := fn(v); err != nil {
+            return err
+        }
+    }
+    if err := rows.Err(); err != nil {
+        return fmt.Errorf("error iterating %s rows: %w", table, err)
+    }
+    return nil
+}
+
+// withTx runs fn inside a transaction, committing only when fn succeeds.
+func withTx(ctx context.Context, conn *sql.DB, fn func(tx *sql.Tx) error) error {
+    tx, err := conn.BeginTx(ctx, nil)
+    if err != nil {
+        return fmt.Errorf("error starting transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    if err := fn(tx); err != nil {
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+    return nil
+}
@@ -0,0 +1,104 @@
+package postgres
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "sort"
+
+    "github.com/alanowatson/LeadGenAPI/internal/search"
+    "github.com/alanowatson/LeadGenAPI/internal/store"
+)
+
+type searchRepo struct {
+    db *sql.DB
+}
+
+// Search ranks each requested table with ts_rank against its search_vector
+// and merges the per-table top results.
+func (r *searchRepo) Search(ctx context.Context, terms []string, types []string, limit int) ([]store.SearchResult, error) {
+    tsquery := search.TSQuery(terms)
+    var results []store.SearchResult
+
+    for _, t := range types {
+        var (
+            found []store.SearchResult
+            err   error
+        )
+        switch t {
+        case store.TypePlaylister:
+            found, err = searchTable(ctx, r.db, "playlisters", playlisterColumns.sql(), "playlisterid", tsquery, limit,
+                func(row scanner) (store.SearchResult, error) {
+                    var rank float64
+                    p, err := scanPlaylister(rankScanner{row, &rank})
+                    return store.SearchResult{Type: t, ID: p.ID, Rank: rank, Title: p.CuratorFullName.String,
+                        Snippet: search.Snippet(terms, store.PlaylisterSearchText(p)...), Data: p}, err
+                })
+        case store.TypePlaylist:
+            found, err = searchTable(ctx, r.db, "playlists", playlistColumns.sql(), "playlistid", tsquery, limit,
+                func(row scanner) (store.SearchResult, error) {
+                    var rank float64
+                    p, err := scanPlaylist(rankScanner{row, &rank})
+                    return store.SearchResult{Type: t, ID: p.ID, Rank: rank, Title: p.CurrentPlaylistName.String,
+                        Snippet: search.Snippet(terms, store.PlaylistSearchText(p)...), Data: p}, err
+                })
+        case store.TypeCampaign:
+            found, err = searchTable(ctx, r.db, "campaigns", campaignColumns.sql(), "campaignid", tsquery, limit,
+                func(row scanner) (store.SearchResult, error) {
+                    var rank float64
+                    c, err := scanCampaign(rankScanner{row, &rank})
+                    return store.SearchResult{Type: t, ID: c.ID, Rank: rank, Title: c.CampaignName.String,
+                        Snippet: search.Snippet(terms, store.CampaignSearchText(c)...), Data: c}, err
+                })
+        default:
+            return nil, fmt.Errorf("unknown search type %q", t)
+        }
+        if err != nil {
+            return nil, err
+        }
+        results = append(results, found...)
+    }
+
+    sort.SliceStable(results, func(i, j int) bool { return results[i].Rank > results[j].Rank })
+    if len(results) > limit {
+        results = results[:limit]
+    }
+    return results, nil
+}
+
+// rankScanner appends the trailing rank column to a model's scan targets.
+type rankScanner struct {
+    row  scanner
+    rank *float64
+}
+
+func (s rankScanner) Scan(dest ...interface{}) error {
+    return s.row.Scan(append(dest, s.rank)...)
+}
+
+func searchTable(ctx context.Context, conn *sql.DB, table, columns, key, tsquery string, limit int,
+    scan func(scanner) (store.SearchResult, error)) ([]store.SearchResult, error) {
+    stmt := `SELECT ` + columns + `, ts_rank(search_vector, q) AS rank
+        FROM ` + table + `, to_tsquery('simple', $1) q
+        WHERE search_vector @@ q AND deleted_at IS NULL
+        ORDER BY rank DESC, ` + key + `
+        LIMIT $2`
+    rows, err := conn.QueryContext(ctx, stmt, tsquery, limit)
+    if err != nil {
+        return nil, fmt.Errorf("error searching %s: %w", table, err)
+    }
+    defer rows.Close()
+
+    var results []store.SearchResult
+    for rows.Next() {
+        result, err := scan(rows)
+        if err != nil {
+            return nil, fmt.Errorf("error scanning %s search row: %w", table, err)
+        }
+        results = append(results, result)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating %s search rows: %w", table, err)
+    }
+    return results, nil
+}
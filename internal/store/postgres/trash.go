@@ -0,0 +1,92 @@
+package postgres
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "time"
+
+    "github.com/alanowatson/LeadGenAPI/internal/store"
+)
+
+// deletedAtExpr selects deleted_at as an RFC 3339 UTC timestamp, the format
+// the models carry DeletedAt in.
+var deletedAtExpr = utcTimestamp("deleted_at")
+
+// utcTimestamp selects a timestamptz column as an RFC 3339 UTC timestamp.
+func utcTimestamp(column string) string {
+    return `to_char(` + column + ` AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')`
+}
+
+// trashedAt returns the DeletedAt value a row stamped with t reads back as.
+func trashedAt(t time.Time) sql.NullString {
+    return timestampAt(t)
+}
+
+// timestampAt returns what a timestamptz column set to t reads back as
+// through utcTimestamp.
+func timestampAt(t time.Time) sql.NullString {
+    return sql.NullString{String: t.UTC().Format(time.RFC3339), Valid: true}
+}
+
+// stamp sets deleted_at on the rows of table matching where and bumps their
+// version. A nil deletedAt takes the rows out of the trash.
+func stamp(ctx context.Context, tx *sql.Tx, table, where string, deletedAt interface{}, args ...interface{}) error {
+    stmt := fmt.Sprintf("UPDATE %s SET deleted_at = $%d, version = version + 1 WHERE %s", table, len(args)+1, where)
+    if _, err := tx.ExecContext(ctx, stmt, append(args, deletedAt)...); err != nil {
+        return translate(err)
+    }
+    return nil
+}
+
+// lockAll loads and locks every row of table matching where, for writes that
+// touch several rows and audit each of them.
+func lockAll[T any](ctx context.Context, tx *sql.Tx, cols columns[T], table, where string, args ...interface{}) ([]T, error) {
+    rows, err := tx.QueryContext(ctx, `SELECT `+cols.sql()+` FROM `+table+` WHERE `+where+` FOR UPDATE`, args...)
+    if err != nil {
+        return nil, fmt.Errorf("error locking %s: %w", table, err)
+    }
+    defer rows.Close()
+
+    var locked []T
+    for rows.Next() {
+        v, err := cols.scan(rows)
+        if err != nil {
+            return nil, fmt.Errorf("error scanning %s row: %w", table, err)
+        }
+        locked = append(locked, v)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating %s rows: %w", table, err)
+    }
+    return locked, nil
+}
+
+// checkLive rejects a reference through column to a row of table that is
+// missing or in the trash. The row is locked so it cannot be trashed before
+// the write commits.
+func checkLive(ctx context.Context, tx *sql.Tx, table, column string, id int) error {
+    var live bool
+    err := tx.QueryRowContext(ctx, "SELECT deleted_at IS NULL FROM "+table+" WHERE "+column+" = $1 FOR SHARE", id).Scan(&live)
+    if err == sql.ErrNoRows || (err == nil && !live) {
+        return &store.ConstraintError{Kind: store.MissingReference, Column: column, Table: table}
+    }
+    if err != nil {
+        return fmt.Errorf("error checking %s: %w", table, err)
+    }
+    return nil
+}
+
+// checkUnreferenced rejects trashing a row while live rows of table matching
+// where still point at it.
+func checkUnreferenced(ctx context.Context, tx *sql.Tx, table, where string, args ...interface{}) error {
+    var found bool
+    err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM "+table+" WHERE "+where+" AND deleted_at IS NULL)", args...).Scan(&found)
+    if err != nil {
+        return fmt.Errorf("error checking %s: %w", table, err)
+    }
+    if found {
+        return &store.ConstraintError{Kind: store.StillReferenced, Table: table}
+    }
+    return nil
+}
@@ -0,0 +1,257 @@
+package store
+
+import (
+    "database/sql"
+
+    "github.com/alanowatson/LeadGenAPI/internal/models"
+    "github.com/alanowatson/LeadGenAPI/internal/pagination"
+    "github.com/alanowatson/LeadGenAPI/internal/query"
+)
+
+// ListOptions narrows, orders and pages a List call.
+type ListOptions struct {
+    Query query.Spec
+    Page  pagination.PaginationParams
+    // After is the decoded Page.Cursor; when set, the listing starts with the
+    // first row sorting after it.
+    After query.Record
+    // Search restricts the listing to full-text matches of these folded
+    // terms. It is ignored by placements, which have no searchable text.
+    Search []string
+    // ViaPlacement restricts playlists or campaigns to those linked to one
+    // parent through a placement, e.g. the campaigns placed on a playlist.
+    ViaPlacement *PlacementLink
+    // IncludeDeleted lists trashed records along with live ones.
+    IncludeDeleted bool
+    // Fields limits the schema fields a repository loads; nil loads them all.
+    // Unloaded fields are left at their zero value.
+    Fields []string
+}
+
+// PlacementLink names the parent side of a placement: Field is "playlistid"
+// or "campaignid" and ID is the parent's key.
+type PlacementLink struct {
+    Field string
+    ID    int
+}
+
+// Selected returns Fields plus the sort fields, which cursors are built
+// from, or nil when every field is wanted.
+func (o ListOptions) Selected() []string {
+    if o.Fields == nil {
+        return nil
+    }
+    selected := append([]string(nil), o.Fields...)
+    for _, s := range o.Query.Sort {
+        selected = append(selected, s.Field.Name)
+    }
+    return selected
+}
+
+// Limit is the number of rows a repository should fetch: one more than the
+// page size, so NewPage can tell whether another page follows.
+func (o ListOptions) Limit() int {
+    return o.Page.PerPage + 1
+}
+
+// Page is one page of a listing.
+type Page[T any] struct {
+    Items []T
+    // TotalItems is -1 when the caller opted out of counting.
+    TotalItems int
+    // NextCursor resumes the listing after Items, or is empty on the last page.
+    NextCursor string
+}
+
+// NewPage trims rows fetched with opts.Limit() to the page size and derives
+// the cursor for the following page.
+func NewPage[T any](rows []T, totalItems int, opts ListOptions, toRecord func(T) query.Record) Page[T] {
+    page := Page[T]{Items: rows, TotalItems: totalItems}
+    if len(rows) > opts.Page.PerPage {
+        page.Items = rows[:opts.Page.PerPage]
+        page.NextCursor = opts.Query.EncodeCursor(toRecord(page.Items[len(page.Items)-1]))
+    }
+    if page.Items == nil {
+        page.Items = []T{}
+    }
+    return page
+}
+
+// The schemas below whitelist the fields each list endpoint can filter and
+// sort on. Field names match the JSON output; columns match the SQL schema.
+
+// deletedAtDay filters and sorts trashed records by the UTC day they were
+// deleted on.
+const deletedAtDay = "(deleted_at AT TIME ZONE 'UTC')::date"
+
+var PlaylisterSchema = query.NewSchema([]query.Field{
+    {Name: "playlisterid", Column: "playlisterid", Type: query.Int},
+    {Name: "spotifyuserid", Column: "spotifyuserid", Type: query.String},
+    {Name: "curatorfullname", Column: "curatorfullname", Type: query.String},
+    {Name: "email", Column: "email", Type: query.String},
+    {Name: "instagram", Column: "instagram", Type: query.String},
+    {Name: "facebook", Column: "facebook", Type: query.String},
+    {Name: "whatsapp", Column: "whatsapp", Type: query.String},
+    {Name: "lastcontacted", Column: lastContactedColumn, Type: query.Date},
+    {Name: "preferredlanguage", Column: "preferredlanguage", Type: query.String},
+    {Name: "followupstatus", Column: "followupstatus", Type: query.String},
+    {Name: "deleted_at", Column: deletedAtDay, Type: query.Date},
+}, "playlisterid")
+
+var PlaylistSchema = query.NewSchema([]query.Field{
+    {Name: "playlistid", Column: "playlistid", Type: query.Int},
+    {Name: "playlisterid", Column: "playlisterid", Type: query.Int},
+    {Name: "playlistspotifyid", Column: "playlistspotifyid", Type: query.String},
+    {Name: "numberoffollowers", Column: "numberoffollowers", Type: query.Int},
+    {Name: "current_playlist_name", Column: "current_playlist_name", Type: query.String},
+    {Name: "lastfollowercountdate", Column: "lastfollowercountdate", Type: query.Date},
+    {Name: "last_exposed", Column: "last_exposed", Type: query.Date},
+    {Name: "deleted_at", Column: deletedAtDay, Type: query.Date},
+    {Name: "last_synced_at", Column: "(last_synced_at AT TIME ZONE 'UTC')::date", Type: query.Date},
+    {Name: "spotify_deleted_at", Column: "(spotify_deleted_at AT TIME ZONE 'UTC')::date", Type: query.Date},
+    {Name: "growth_7d", Column: growthColumn(7), Type: query.Int},
+    {Name: "growth_30d", Column: growthColumn(30), Type: query.Int},
+    {Name: "growth_90d", Column: growthColumn(90), Type: query.Int},
+    {Name: "growth_rate_7d", Column: growthRateColumn(7), Type: query.Float},
+    {Name: "growth_rate_30d", Column: growthRateColumn(30), Type: query.Float},
+    {Name: "growth_rate_90d", Column: growthRateColumn(90), Type: query.Float},
+}, "playlistid")
+
+var CampaignSchema = query.NewSchema([]query.Field{
+    {Name: "campaignid", Column: "campaignid", Type: query.Int},
+    {Name: "campaignname", Column: "campaignname", Type: query.String},
+    {Name: "referenceartists", Column: "referenceartists", Type: query.String},
+    {Name: "trello_link", Column: "trello_link", Type: query.String},
+    {Name: "spotify_link", Column: "spotify_link", Type: query.String},
+    {Name: "launch_date", Column: "launchdate", Type: query.Date},
+    {Name: "promoted_artist", Column: "promoted_artist", Type: query.String},
+    {Name: "max_messages", Column: "max_messages", Type: query.Int},
+    {Name: "deleted_at", Column: deletedAtDay, Type: query.Date},
+}, "campaignid")
+
+var PlaylistCampaignSchema = query.NewSchema([]query.Field{
+    {Name: "playlistid", Column: "playlistid", Type: query.Int},
+    {Name: "campaignid", Column: "campaignid", Type: query.Int},
+    {Name: "playlisterid", Column: "playlisterid", Type: query.Int},
+    {Name: "referenceartists", Column: "referenceartists", Type: query.String},
+    {Name: "placementstatus", Column: "placementstatus", Type: query.String},
+    {Name: "numberofmessages", Column: outboundMessagesColumn, Type: query.Int},
+    {Name: "purchased", Column: "purchased", Type: query.Bool},
+    {Name: "assignee", Column: "assignee", Type: query.String},
+    {Name: "status_changed_at", Column: "(status_changed_at AT TIME ZONE 'UTC')::date", Type: query.Date},
+    {Name: "deleted_at", Column: deletedAtDay, Type: query.Date},
+    {Name: "verified_at", Column: "(verified_at AT TIME ZONE 'UTC')::date", Type: query.Date},
+    {Name: "track_present", Column: "track_present", Type: query.Bool},
+    {Name: "track_first_seen_at", Column: "(track_first_seen_at AT TIME ZONE 'UTC')::date", Type: query.Date},
+    {Name: "track_removed_at", Column: "(track_removed_at AT TIME ZONE 'UTC')::date", Type: query.Date},
+    {Name: "flagged", Column: flaggedColumn, Type: query.Bool},
+}, "playlistid", "campaignid")
+
+// flaggedColumn mirrors PlaylistCampaign.Flagged.
+const flaggedColumn = "(placementstatus = 'Placed' AND track_present IS FALSE)"
+
+// PlacementFields returns the fields a placement repository loads for fields:
+// flagged is worked out from placementstatus and track_present, so naming it
+// loads them too. A nil fields stays nil.
+func PlacementFields(fields []string) []string {
+    for _, f := range fields {
+        if f == "flagged" {
+            return append(fields[:len(fields):len(fields)], "placementstatus", "track_present")
+        }
+    }
+    return fields
+}
+
+// The record functions expose a model's fields under their schema names so
+// rows can be filtered, sorted and turned into cursors outside of SQL.
+
+func PlaylisterRecord(p models.Playlister) query.Record {
+    return query.Record{
+        "playlisterid":      p.ID,
+        "spotifyuserid":     nullable(p.SpotifyUserID),
+        "curatorfullname":   nullable(p.CuratorFullName),
+        "email":             nullable(p.Email),
+        "instagram":         nullable(p.Instagram),
+        "facebook":          nullable(p.Facebook),
+        "whatsapp":          nullable(p.Whatsapp),
+        "lastcontacted":     nullable(p.LastContacted),
+        "preferredlanguage": nullable(p.PreferredLanguage),
+        "followupstatus":    nullable(p.FollowupStatus),
+        "deleted_at":        day(p.DeletedAt),
+    }
+}
+
+func PlaylistRecord(p models.Playlist) query.Record {
+    r := query.Record{
+        "playlistid":            p.ID,
+        "playlisterid":          p.PlaylisterId,
+        "playlistspotifyid":     nullable(p.PlaylistSpotifyId),
+        "numberoffollowers":     p.NumberOfFollowers,
+        "current_playlist_name": nullable(p.CurrentPlaylistName),
+        "lastfollowercountdate": nullable(p.LastFollowerCountDate),
+        "last_exposed":          nullable(p.LastExposed),
+        "deleted_at":            day(p.DeletedAt),
+        "last_synced_at":        day(p.LastSyncedAt),
+        "spotify_deleted_at":    day(p.SpotifyDeletedAt),
+    }
+    growthRecord(r, p.Growth)
+    return r
+}
+
+func CampaignRecord(c models.Campaign) query.Record {
+    return query.Record{
+        "campaignid":       c.ID,
+        "campaignname":     nullable(c.CampaignName),
+        "referenceartists": nullable(c.ReferenceArtists),
+        "trello_link":      nullable(c.TrelloLink),
+        "spotify_link":     nullable(c.SpotifyLink),
+        "launch_date":      nullable(c.LaunchDate),
+        "promoted_artist":  nullable(c.PromotedArtist),
+        "max_messages":     c.MaxMessages,
+        "deleted_at":       day(c.DeletedAt),
+    }
+}
+
+func PlaylistCampaignRecord(pc models.PlaylistCampaign) query.Record {
+    return query.Record{
+        "playlistid":          pc.PlaylistID,
+        "campaignid":          pc.CampaignID,
+        "playlisterid":        pc.PlaylisterId,
+        "referenceartists":    nullable(pc.ReferenceArtists),
+        "placementstatus":     nullable(pc.PlacementStatus),
+        "numberofmessages":    pc.NumberOfMessages,
+        "purchased":           pc.Purchased,
+        "assignee":            nullable(pc.Assignee),
+        "status_changed_at":   day(pc.StatusChangedAt),
+        "deleted_at":          day(pc.DeletedAt),
+        "verified_at":         day(pc.VerifiedAt),
+        "track_present":       nullableBool(pc.TrackPresent),
+        "track_first_seen_at": day(pc.TrackFirstSeenAt),
+        "track_removed_at":    day(pc.TrackRemovedAt),
+        "flagged":             pc.Flagged(),
+    }
+}
+
+// day truncates a nullable RFC 3339 timestamp to its YYYY-MM-DD date.
+func day(s sql.NullString) interface{} {
+    if !s.Valid || len(s.String) < 10 {
+        return nil
+    }
+    return s.String[:10]
+}
+
+// nullable converts a nullable column into a query.Record value.
+func nullable(s sql.NullString) interface{} {
+    if !s.Valid {
+        return nil
+    }
+    return s.String
+}
+
+// nullableBool is nullable for boolean columns.
+func nullableBool(b sql.NullBool) interface{} {
+    if !b.Valid {
+        return nil
+    }
+    return b.Bool
+}
@@ -0,0 +1,46 @@
+package store
+
+import (
+    "context"
+
+    "github.com/alanowatson/LeadGenAPI/internal/models"
+)
+
+// Resource types returned by SearchRepository.
+const (
+    TypePlaylister = "playlister"
+    TypePlaylist   = "playlist"
+    TypeCampaign   = "campaign"
+)
+
+// SearchResult is one ranked match from a cross-resource search.
+type SearchResult struct {
+    Type    string      `json:"type"`
+    ID      int         `json:"id"`
+    Rank    float64     `json:"rank"`
+    Title   string      `json:"title"`
+    Snippet string      `json:"snippet"`
+    Data    interface{} `json:"data"`
+}
+
+// SearchRepository finds playlisters, playlists and campaigns matching
+// folded search terms (see the search package). Results are ordered by rank.
+type SearchRepository interface {
+    Search(ctx context.Context, terms []string, types []string, limit int) ([]SearchResult, error)
+}
+
+// The search text functions list the fields indexed for full-text search,
+// most significant first. They must stay in step with the search_vector
+// columns defined in the migrations.
+
+func PlaylisterSearchText(p models.Playlister) []string {
+    return []string{p.CuratorFullName.String, p.Instagram.String, p.Email.String, p.Facebook.String, p.SpotifyUserID.String}
+}
+
+func PlaylistSearchText(p models.Playlist) []string {
+    return []string{p.CurrentPlaylistName.String, p.PlaylistSpotifyId.String}
+}
+
+func CampaignSearchText(c models.Campaign) []string {
+    return []string{c.CampaignName.String, c.PromotedArtist.String, c.ReferenceArtists.String}
+}
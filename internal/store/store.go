@@ -0,0 +1,252 @@
+// Package store defines the repository interfaces the HTTP handlers depend on.
+// Implementations live in the postgres and memory subpackages.
+package store
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/alanowatson/LeadGenAPI/internal/models"
+)
+
+// ErrNotFound is returned when the requested record does not exist.
+var ErrNotFound = errors.New("record not found")
+
+// ConstraintKind identifies which integrity rule a write broke.
+type ConstraintKind int
+
+const (
+    // Unique means another record already holds the value of Column.
+    Unique ConstraintKind = iota
+    // MissingReference means Column points at a record that does not exist.
+    MissingReference
+    // StillReferenced means the record is still referenced from Table.
+    StillReferenced
+)
+
+// ConstraintError reports a write rejected by an integrity constraint.
+type ConstraintError struct {
+    Kind   ConstraintKind
+    Column string
+    Table  string
+}
+
+func (e *ConstraintError) Error() string {
+    switch e.Kind {
+    case Unique:
+        return fmt.Sprintf("duplicate value for %s", e.Column)
+    case MissingReference:
+        return fmt.Sprintf("referenced %s does not exist", e.Column)
+    default:
+        return fmt.Sprintf("record is still referenced by %s", e.Table)
+    }
+}
+
+// PlaylisterRepository manages curators. LastContacted is the day of the
+// latest message logged for any of the curator's placements; Create and
+// Update leave it as it was.
+//
+// The other repositories' methods of the same names behave like these.
+type PlaylisterRepository interface {
+    // List returns a page of records, leaving trashed ones out unless
+    // opts.IncludeDeleted is set.
+    List(ctx context.Context, opts ListOptions) (Page[models.Playlister], error)
+    // Each calls fn with every record List would return for opts, across all
+    // pages and in order, stopping at the first error fn returns.
+    Each(ctx context.Context, opts ListOptions, fn func(models.Playlister) error) error
+    // Get loads a record, trashed or not, with only the named schema fields
+    // and the key when fields are given.
+    Get(ctx context.Context, id int, fields ...string) (models.Playlister, error)
+    // GetMany loads every record whose ID is in ids with one query, keyed by
+    // ID; IDs that do not exist are absent.
+    GetMany(ctx context.Context, ids []int) (map[int]models.Playlister, error)
+    // Create stores a new record and sets its ID and Version in p.
+    Create(ctx context.Context, p *models.Playlister) error
+    // Update writes a live record and stores its incremented Version in p. A
+    // non-zero Version must match the stored one, or ErrVersionConflict.
+    Update(ctx context.Context, p *models.Playlister) error
+    // Delete moves a live record to the trash by stamping its DeletedAt. A
+    // non-zero version guards it like Update's.
+    Delete(ctx context.Context, id, version int) error
+    // Restore takes a record back out of the trash, failing with
+    // ErrNotDeleted for one that is not in it.
+    Restore(ctx context.Context, id int) error
+    // Purge removes a trashed record for good, failing with ErrNotDeleted
+    // for one that is not in the trash.
+    Purge(ctx context.Context, id int) error
+    // Merge folds the playlister duplicateID into survivorID: every playlist
+    // and placement of the duplicate, trashed ones included, moves to the
+    // survivor, the survivor takes the contact details only the duplicate
+    // has (see MergedPlaylister) and the duplicate is trashed, all in one
+    // transaction. version guards the survivor like Update's. A missing or
+    // trashed duplicate is a MissingReference on duplicate_id.
+    Merge(ctx context.Context, survivorID, duplicateID, version int) error
+}
+
+type PlaylistRepository interface {
+    List(ctx context.Context, opts ListOptions) (Page[models.Playlist], error)
+    Each(ctx context.Context, opts ListOptions, fn func(models.Playlist) error) error
+    Get(ctx context.Context, id int, fields ...string) (models.Playlist, error)
+    GetMany(ctx context.Context, ids []int) (map[int]models.Playlist, error)
+    Create(ctx context.Context, p *models.Playlist) error
+    Update(ctx context.Context, p *models.Playlist) error
+    // Delete trashes the playlist and its placements with it.
+    Delete(ctx context.Context, id, version int) error
+    // Restore brings back the playlist and the placements its Delete took.
+    Restore(ctx context.Context, id int) error
+    Purge(ctx context.Context, id int) error
+    // Followers returns the playlist's follower snapshots recorded from since
+    // up to but not including until, oldest first. A zero time leaves that
+    // end open. Create records the first snapshot and Update another each
+    // time NumberOfFollowers changes; List, Each, Get and GetMany fill in
+    // the Growth computed from them.
+    Followers(ctx context.Context, id int, since, until time.Time) ([]models.FollowerSnapshot, error)
+    // Stale returns up to limit live playlists last synced with Spotify, or
+    // last failing to, before the given time, never tried ones first and then
+    // the least recently tried. Playlists Spotify reported as deleted are
+    // left out.
+    Stale(ctx context.Context, before time.Time, limit int) ([]models.Playlist, error)
+    // SyncFailed notes that refreshing playlist id failed at the given time,
+    // so Stale passes it over until that is stale too. It only reorders the
+    // sync queue: the version is not bumped and nothing is audited.
+    SyncFailed(ctx context.Context, id int, at time.Time) error
+    // Sync applies a refresh from Spotify to the live playlist id, as
+    // SyncedPlaylist describes, bumping its version and recording a follower
+    // snapshot when the count changed. Update leaves the sync fields alone.
+    Sync(ctx context.Context, id int, result PlaylistSync) error
+}
+
+// CampaignRepository manages campaigns. Create gives a campaign without a
+// MaxMessages the default, and Update leaves it as it was.
+type CampaignRepository interface {
+    List(ctx context.Context, opts ListOptions) (Page[models.Campaign], error)
+    Each(ctx context.Context, opts ListOptions, fn func(models.Campaign) error) error
+    Get(ctx context.Context, id int, fields ...string) (models.Campaign, error)
+    GetMany(ctx context.Context, ids []int) (map[int]models.Campaign, error)
+    Create(ctx context.Context, c *models.Campaign) error
+    Update(ctx context.Context, c *models.Campaign) error
+    Delete(ctx context.Context, id, version int) error
+    Restore(ctx context.Context, id int) error
+    Purge(ctx context.Context, id int) error
+}
+
+// PlaylistCampaignRepository manages placements, which are keyed by the
+// playlist and campaign they join. Create and Update reject a placement whose
+// playlister does not own its playlist with ErrOwnerMismatch, and leave the
+// verification fields, which only Verify sets, as they were.
+//
+// A placement's status follows the state machine in models: Update and
+// Transition fail with a *TransitionError for a change it does not allow, and
+// Create with one from "" for a status a placement cannot start in. Create,
+// and every change of status, stamp StatusChangedAt and record a transition
+// attributed to the context's actor.
+//
+// NumberOfMessages counts the outbound messages logged with LogMessage and is
+// read from them; Create starts it at zero and Update leaves it as it was.
+type PlaylistCampaignRepository interface {
+    List(ctx context.Context, opts ListOptions) (Page[models.PlaylistCampaign], error)
+    Each(ctx context.Context, opts ListOptions, fn func(models.PlaylistCampaign) error) error
+    Get(ctx context.Context, playlistID, campaignID int, fields ...string) (models.PlaylistCampaign, error)
+    Create(ctx context.Context, pc *models.PlaylistCampaign) error
+    Update(ctx context.Context, pc *models.PlaylistCampaign) error
+    Delete(ctx context.Context, playlistID, campaignID, version int) error
+    Restore(ctx context.Context, playlistID, campaignID int) error
+    Purge(ctx context.Context, playlistID, campaignID int) error
+    // Unverified returns up to limit live placements due a check of their
+    // playlist's tracks: those marked Placed or whose track was last seen
+    // there, on a campaign whose spotify_link is a track, and not verified,
+    // or failing to be, since before. Never tried ones come first, then the
+    // least recently tried.
+    Unverified(ctx context.Context, before time.Time, limit int) ([]models.PlaylistCampaign, error)
+    // VerifyFailed notes that checking the placement failed at the given
+    // time, so Unverified passes it over until that is stale too. Like
+    // Playlists.SyncFailed it neither bumps the version nor audits.
+    VerifyFailed(ctx context.Context, playlistID, campaignID int, at time.Time) error
+    // Verify applies a check of the live placement's playlist, as
+    // VerifiedPlacement describes, and bumps its version.
+    Verify(ctx context.Context, playlistID, campaignID int, check PlacementCheck) error
+    // Transition moves the live placement to status, noting why. version
+    // guards it like Update's.
+    Transition(ctx context.Context, playlistID, campaignID int, status, note string, version int) error
+    // Transitions returns the placement's status changes, oldest first.
+    Transitions(ctx context.Context, playlistID, campaignID int) ([]models.PlacementTransition, error)
+    // LogMessage records a message of the live placement m names, stamping
+    // its ID and actor, and its SentAt when empty. An outbound message fails
+    // with a *MessageCapError once the placement has as many as its
+    // campaign's MaxMessages, counted from the messages already logged. An
+    // ExternalID already logged on the channel is a Unique violation on
+    // "channel, external_id".
+    LogMessage(ctx context.Context, m *models.Message) error
+    // Messages returns the placement's messages, oldest first.
+    Messages(ctx context.Context, playlistID, campaignID int) ([]models.Message, error)
+    // DueFollowups returns the next follow-up of every live placement on a
+    // live campaign, as NextFollowup computes it, that q selects, the
+    // longest overdue first.
+    DueFollowups(ctx context.Context, q FollowupQuery) ([]models.Followup, error)
+}
+
+// ErrVersionConflict is returned when a conditional write names a version
+// other than the stored one.
+var ErrVersionConflict = errors.New("record has been modified")
+
+// ErrNotDeleted is returned when restoring or purging a record that is not in
+// the trash.
+var ErrNotDeleted = errors.New("record is not deleted")
+
+// CheckVersion reports ErrVersionConflict when expected names a version other
+// than stored. An expected version of 0 matches any.
+func CheckVersion(stored, expected int) error {
+    if expected != 0 && stored != expected {
+        return ErrVersionConflict
+    }
+    return nil
+}
+
+// TransitionError is returned when a write would move a placement between
+// two statuses the state machine does not connect.
+type TransitionError struct {
+    From string
+    To   string
+}
+
+func (e *TransitionError) Error() string {
+    if e.From == "" {
+        return fmt.Sprintf("placement cannot start as %s", e.To)
+    }
+    return fmt.Sprintf("placement cannot move from %s to %s", e.From, e.To)
+}
+
+// CheckTransition reports a *TransitionError unless a placement may move
+// from one status to the other. A from of "" checks a new placement's.
+func CheckTransition(from, to string) error {
+    if !models.CanTransition(from, to) {
+        return &TransitionError{From: from, To: to}
+    }
+    return nil
+}
+
+// MessageCapError is returned when logging an outbound message for a
+// placement that already has as many as its campaign allows.
+type MessageCapError struct {
+    Cap int
+}
+
+func (e *MessageCapError) Error() string {
+    return fmt.Sprintf("placement has reached its cap of %d outbound messages", e.Cap)
+}
+
+// ErrOwnerMismatch is returned when a placement names a playlister that does
+// not own the placement's playlist.
+var ErrOwnerMismatch = errors.New("playlister does not own the referenced playlist")
+
+// Store bundles one repository per entity.
+type Store struct {
+    Playlisters       PlaylisterRepository
+    Playlists         PlaylistRepository
+    Campaigns         CampaignRepository
+    PlaylistCampaigns PlaylistCampaignRepository
+    Search            SearchRepository
+    Audit             AuditRepository
+}
@@ -0,0 +1,52 @@
+package store
+
+import (
+    "errors"
+    "testing"
+
+    "github.com/alanowatson/LeadGenAPI/internal/models"
+)
+
+func TestCheckTransition(t *testing.T) {
+    tests := []struct {
+        from, to string
+        ok       bool
+    }{
+        {"", models.PlacementDrafted, true},
+        {"", models.PlacementPitched, true},
+        {"", models.PlacementPlaced, false},
+        {"", models.PlacementRejected, false},
+        {models.PlacementDrafted, models.PlacementPitched, true},
+        {models.PlacementDrafted, models.PlacementPlaced, false},
+        {models.PlacementPitched, models.PlacementReplied, true},
+        {models.PlacementPitched, models.PlacementPlaced, true},
+        {models.PlacementPitched, models.PlacementDrafted, false},
+        {models.PlacementReplied, models.PlacementNegotiating, true},
+        {models.PlacementNegotiating, models.PlacementPlaced, true},
+        {models.PlacementNegotiating, models.PlacementPitched, false},
+        {models.PlacementPlaced, models.PlacementRemoved, true},
+        {models.PlacementPlaced, models.PlacementRejected, false},
+        {models.PlacementRemoved, models.PlacementPlaced, true},
+        {models.PlacementRejected, models.PlacementPitched, true},
+        {models.PlacementExpired, models.PlacementPitched, true},
+        {models.PlacementExpired, models.PlacementPlaced, false},
+        {models.PlacementPitched, models.PlacementPitched, false},
+        {models.PlacementPitched, "Unknown", false},
+        {"Unknown", models.PlacementPitched, false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+            err := CheckTransition(tt.from, tt.to)
+            if tt.ok {
+                if err != nil {
+                    t.Errorf("CheckTransition(%q, %q) = %v, want nil", tt.from, tt.to, err)
+                }
+                return
+            }
+            var terr *TransitionError
+            if !errors.As(err, &terr) || terr.From != tt.from || terr.To != tt.to {
+                t.Errorf("CheckTransition(%q, %q) = %v, want a *TransitionError", tt.from, tt.to, err)
+            }
+        })
+    }
+}
@@ -0,0 +1,41 @@
+package store
+
+import (
+    "database/sql"
+    "time"
+
+    "github.com/alanowatson/LeadGenAPI/internal/models"
+)
+
+// PlaylistSync is what a refresh from Spotify found out about a playlist.
+// Found is false when Spotify reports the playlist as deleted, and Name and
+// Followers are then unset.
+type PlaylistSync struct {
+    Found     bool
+    Name      string
+    Followers int
+    At        time.Time
+}
+
+// SyncedPlaylist returns p brought up to date with s. A found playlist takes
+// the name and follower count Spotify reported, counted on the day of s.At,
+// and loses any earlier deletion mark; a missing one keeps its data and is
+// marked deleted as of the first sync that missed it.
+func SyncedPlaylist(p models.Playlist, s PlaylistSync) models.Playlist {
+    at := s.At.UTC()
+    p.LastSyncedAt = sql.NullString{String: at.Format(time.RFC3339), Valid: true}
+    if !s.Found {
+        if !p.SpotifyDeletedAt.Valid {
+            p.SpotifyDeletedAt = p.LastSyncedAt
+        }
+        return p
+    }
+
+    if s.Name != "" {
+        p.CurrentPlaylistName = sql.NullString{String: s.Name, Valid: true}
+    }
+    p.NumberOfFollowers = s.Followers
+    p.LastFollowerCountDate = sql.NullString{String: at.Format("2006-01-02"), Valid: true}
+    p.SpotifyDeletedAt = sql.NullString{}
+    return p
+}
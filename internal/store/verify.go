@@ -0,0 +1,46 @@
+package store
+
+import (
+    "database/sql"
+    "time"
+
+    "github.com/alanowatson/LeadGenAPI/internal/models"
+)
+
+// PlacementCheck is what a look at a playlist's tracks found for one
+// placement: whether the campaign's track was on it at At.
+type PlacementCheck struct {
+    Present bool
+    At      time.Time
+}
+
+// VerifiedPlacement returns pc updated with c. The first check to see the
+// track stamps TrackFirstSeenAt; a check that misses a track the previous one
+// saw stamps TrackRemovedAt, which a later sighting clears again.
+func VerifiedPlacement(pc models.PlaylistCampaign, c PlacementCheck) models.PlaylistCampaign {
+    at := sql.NullString{String: c.At.UTC().Format(time.RFC3339), Valid: true}
+    wasPresent := pc.TrackPresent.Valid && pc.TrackPresent.Bool
+
+    pc.VerifiedAt = at
+    pc.TrackPresent = sql.NullBool{Bool: c.Present, Valid: true}
+    switch {
+    case c.Present:
+        if !pc.TrackFirstSeenAt.Valid {
+            pc.TrackFirstSeenAt = at
+        }
+        pc.TrackRemovedAt = sql.NullString{}
+    case wasPresent:
+        pc.TrackRemovedAt = at
+    }
+    return pc
+}
+
+// TrackLinkPrefix starts the canonical spotify_link of a campaign promoting a
+// track, the only kind of campaign placements can be verified for.
+const TrackLinkPrefix = "https://open.spotify.com/track/"
+
+// NeedsVerification reports whether a live placement is one Unverified
+// considers: marked Placed, or with its track seen on the last check.
+func NeedsVerification(pc models.PlaylistCampaign) bool {
+    return pc.PlacementStatus.String == models.PlacementPlaced || pc.TrackPresent.Valid && pc.TrackPresent.Bool
+}
@@ -1,8 +1,11 @@
 package validation
 
 import (
+    "database/sql"
+    "reflect"
     "strings"
 
+    "github.com/alanowatson/LeadGenAPI/internal/spotify"
     "github.com/go-playground/validator/v10"
 )
 
@@ -13,12 +16,43 @@ func init() {
 
     // Register a custom function for the iso639_1 tag
     validate.RegisterValidation("iso639_1", validateISO639_1)
+
+    // Spotify identifiers must be stored in their canonical form
+    validate.RegisterValidation("spotify_playlist", canonicalSpotifyID(spotify.Playlist))
+    validate.RegisterValidation("spotify_user", canonicalSpotifyID(spotify.User))
+    validate.RegisterValidation("spotify_link", validateSpotifyLink)
+
+    // Validate nullable columns by their underlying value; NULL counts as empty
+    validate.RegisterCustomTypeFunc(nullStringValue, sql.NullString{})
+}
+
+func nullStringValue(field reflect.Value) interface{} {
+    if ns, ok := field.Interface().(sql.NullString); ok && ns.Valid {
+        return ns.String
+    }
+    return nil
 }
 
 func ValidateStruct(s interface{}) error {
     return validate.Struct(s)
 }
 
+// canonicalSpotifyID accepts bare Spotify IDs of type t.
+func canonicalSpotifyID(t spotify.Type) validator.Func {
+    return func(fl validator.FieldLevel) bool {
+        s := fl.Field().String()
+        id, err := spotify.ParseAs(s, t)
+        return err == nil && id.ID == s
+    }
+}
+
+// validateSpotifyLink accepts canonical open.spotify.com links.
+func validateSpotifyLink(fl validator.FieldLevel) bool {
+    s := fl.Field().String()
+    id, err := spotify.Parse(s)
+    return err == nil && id.Type != "" && id.URL() == s
+}
+
 func validateISO639_1(fl validator.FieldLevel) bool {
     // Later we might want to check against a comprehensive list of ISO 639-1 codes.
     code := fl.Field().String()